@@ -46,13 +46,36 @@ func Run(configPath string, configName string) {
 	if err := initAdmin(db); err != nil {
 		logrus.Fatalf("failed to init admin: %s", err.Error())
 	}
+	// Загружаем ключи подписи токенов
+	if err := initKeyring(&cfg.Auth.Tokens); err != nil {
+		logrus.Fatalf("failed to init token keys: %s", err.Error())
+	}
+
+	// Настраиваем отправку писем
+	mailer, err := initMailer(&cfg.Mail)
+	if err != nil {
+		logrus.Fatalf("failed to init mailer: %s", err.Error())
+	}
+
+	// Загружаем парольную политику и список утекших паролей
+	passwords, err := service.NewPasswordPolicy(&cfg.Auth.PasswordPolicy)
+	if err != nil {
+		logrus.Fatalf("failed to init password policy: %s", err.Error())
+	}
+
+	// Загружаем политики доступа к пользователям
+	policy, err := service.NewAccessPolicy(&cfg.Auth.AccessPolicy)
+	if err != nil {
+		logrus.Fatalf("failed to init access policy: %s", err.Error())
+	}
+
 	// Инициализируем Swagger
 	initSwagger()
 
 	// Создаем репозитории, сервисы и контроллер
 	repository := repository.NewRepository(db)
-	service := service.NewService(repository)
-	controller := ctrl.NewController(service)
+	service := service.NewService(repository, cfg, mailer, passwords, policy)
+	controller := ctrl.NewController(service, cfg)
 
 	// Запускаем сервер в отдельной горутине
 	var srv server.Server
@@ -3,14 +3,20 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/kolibriee/users-rest-api/docs"
 	"github.com/sirupsen/logrus"
 	"github.com/uptrace/bun"
 
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
 	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
 	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
 )
 
 func initAdmin(db *bun.DB) error {
@@ -19,7 +25,7 @@ func initAdmin(db *bun.DB) error {
 	// Проверяем, существует ли уже администратор
 	exists, err := db.NewSelect().
 		Model((*bunEntities.User)(nil)).
-		Where("role = ?", "admin").
+		Where("role = ?", entities.RoleAdmin).
 		Exists(ctx)
 	if err != nil {
 		// Возвращаем ошибку, если не удалось проверить существование администратора
@@ -32,17 +38,28 @@ func initAdmin(db *bun.DB) error {
 		return nil
 	}
 
+	// Генерируем хэш пароля
+	passwordHash, err := auth.GeneratePasswordHash("admin")
+	if err != nil {
+		return errors.New("failed to hash admin password: " + err.Error())
+	}
+
 	// Создаем нового администратора
 	admin := &bunEntities.User{
-		Role:         "admin",
+		Role:         entities.RoleAdmin,
 		Name:         "admin",
 		Username:     "admin",
-		PasswordHash: auth.GeneratePasswordHash("admin"), // Генерируем хэш пароля
+		PasswordHash: passwordHash,
 		City:         "city",
 	}
 
-	// Вставляем нового администратора в базу данных
-	_, err = db.NewInsert().Model(admin).Exec(ctx)
+	// Вставляем нового администратора в базу данных и добавляем его в организацию default, как при регистрации
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(admin).Exec(ctx); err != nil {
+			return err
+		}
+		return repository.JoinDefaultOrganization(ctx, tx, admin.ID, admin.Role)
+	})
 	if err != nil {
 		return errors.New("failed to create admin: " + err.Error())
 	}
@@ -59,3 +76,66 @@ func initSwagger() {
 	}
 	docs.SwaggerInfo.Host = host
 }
+
+func initKeyring(cfg *config.Tokens) error {
+	keyring, err := auth.LoadKeyring(cfg.KeysDir)
+	if err != nil {
+		return err
+	}
+
+	activeKeyID := cfg.ActiveKeyID
+	switch {
+	case keyring.Len() == 0:
+		if !cfg.AllowEphemeralKey {
+			return errors.New("no signing keys found in " + cfg.KeysDir + ", add a key or set allowEphemeralKey for development")
+		}
+		// Временный ключ только для разработки: после перезапуска выданные токены станут недействительны
+		activeKeyID = fmt.Sprintf("ephemeral-%d", time.Now().Unix())
+		if err := keyring.GenerateEd25519Key(activeKeyID); err != nil {
+			return errors.New("failed to generate signing key: " + err.Error())
+		}
+		logrus.Warnf("no signing keys found in %q, using ephemeral key %s", cfg.KeysDir, activeKeyID)
+	case activeKeyID == "" && keyring.Len() == 1:
+		activeKeyID = keyring.KeyIDs()[0]
+	case activeKeyID == "":
+		return errors.New("activeKeyId must be set when several signing keys are configured")
+	}
+	if err := keyring.SetActive(activeKeyID); err != nil {
+		return err
+	}
+
+	// Переходный период: принимаем ранее выданные токены HS256
+	if cfg.HS256AcceptUntil != "" {
+		until, err := time.Parse(time.RFC3339, cfg.HS256AcceptUntil)
+		if err != nil {
+			return errors.New("invalid hs256AcceptUntil: " + err.Error())
+		}
+		secret := os.Getenv("TOKEN_SECRET_KEY")
+		if secret == "" {
+			return errors.New("TOKEN_SECRET_KEY is required to accept HS256 tokens")
+		}
+		keyring.AcceptHS256Until([]byte(secret), until)
+	}
+
+	auth.SetKeyring(keyring)
+	logrus.Infof("token signing key %s loaded, %d key(s) published", activeKeyID, keyring.Len())
+	return nil
+}
+
+func initMailer(cfg *config.Mail) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		if cfg.SMTP.Host == "" {
+			return nil, errors.New("mail.smtp.host is required for smtp driver")
+		}
+		return mailer.NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From), nil
+	case "file", "":
+		logrus.Warnf("emails are written to %s instead of being sent", cfg.OutboxDir)
+		return mailer.NewFileMailer(cfg.OutboxDir, cfg.From)
+	case "memory":
+		logrus.Warn("emails are kept in memory and never sent")
+		return mailer.NewMemoryMailer(), nil
+	default:
+		return nil, errors.New("unknown mail driver " + cfg.Driver)
+	}
+}
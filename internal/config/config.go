@@ -2,6 +2,10 @@ package config
 
 import (
 	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,6 +17,24 @@ import (
 type Config struct {
 	Postgres Postgres // Конфигурация PostgreSQL
 	Server   Server   `mapstructure:"server"` // Конфигурация сервера
+	Auth     Auth     `mapstructure:"auth"`   // Конфигурация аутентификации
+	Mail     Mail     `mapstructure:"mail"`   // Конфигурация отправки писем
+}
+
+// Структура конфигурации отправки писем
+type Mail struct {
+	Driver    string `mapstructure:"driver"`    // smtp, file или memory
+	From      string `mapstructure:"from"`      // Адрес отправителя
+	OutboxDir string `mapstructure:"outboxDir"` // Каталог для писем при driver: file
+	SMTP      SMTP   `mapstructure:"smtp"`
+}
+
+// Структура конфигурации SMTP-сервера. Пароль задается в SMTP_PASSWORD
+type SMTP struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"-"`
 }
 
 // Структура конфигурации сервера
@@ -21,6 +43,156 @@ type Server struct {
 	MaxHeaderBytes int           `mapstructure:"maxHeaderBytes"`
 	ReadTimeout    time.Duration `mapstructure:"readTimeout"`
 	WriteTimeout   time.Duration `mapstructure:"writeTimeout"`
+	TrustedProxies []string      `mapstructure:"trustedProxies"` // Адреса и подсети прокси, которым доверяем X-Forwarded-For
+}
+
+// Структура конфигурации аутентификации
+type Auth struct {
+	Tokens            Tokens            `mapstructure:"tokens"`
+	LoginThrottle     LoginThrottle     `mapstructure:"loginThrottle"`
+	TwoFactor         TwoFactor         `mapstructure:"twoFactor"`
+	OIDC              OIDC              `mapstructure:"oidc"`
+	PasswordReset     PasswordReset     `mapstructure:"passwordReset"`
+	EmailVerification EmailVerification `mapstructure:"emailVerification"`
+	PasswordPolicy    PasswordPolicy    `mapstructure:"passwordPolicy"`
+	MagicLink         MagicLink         `mapstructure:"magicLink"`
+	RefreshToken      RefreshToken      `mapstructure:"refreshToken"`
+	CSRF              CSRF              `mapstructure:"csrf"`
+	Impersonation     Impersonation     `mapstructure:"impersonation"`
+	StepUp            StepUp            `mapstructure:"stepUp"`
+	OAuth             OAuth             `mapstructure:"oauth"`
+	AccessPolicy      AccessPolicy      `mapstructure:"accessPolicy"`
+}
+
+// Структура конфигурации политик доступа к пользователям по атрибутам
+type AccessPolicy struct {
+	File string `mapstructure:"file"` // YAML-файл с правилами; пустое значение — только права ролей
+}
+
+// Структура конфигурации OAuth-сервера
+type OAuth struct {
+	TokenURL      string        `mapstructure:"tokenUrl"` // Адрес token endpoint, ожидаемый в aud утверждения клиента (private_key_jwt)
+	LoginURL      string        `mapstructure:"loginUrl"` // Страница входа для /oauth/authorize без сессии, адрес возврата передается параметром return_to
+	Introspection Introspection `mapstructure:"introspection"`
+}
+
+// Структура конфигурации проверки токенов другими сервисами (RFC 7662)
+type Introspection struct {
+	CacheTTL  time.Duration `mapstructure:"cacheTtl"`  // Сколько хранить результат проверки; 0 отключает кэш
+	CacheSize int           `mapstructure:"cacheSize"` // Сколько результатов хранить одновременно
+}
+
+// Структура конфигурации повторного подтверждения личности перед важными изменениями
+type StepUp struct {
+	MaxAge time.Duration `mapstructure:"maxAge"` // Сколько после /auth/reauthenticate изменения не требуют текущего пароля
+}
+
+// Структура конфигурации входа администратора от имени пользователя
+type Impersonation struct {
+	TokenTTL time.Duration `mapstructure:"tokenTtl"` // Время жизни access token имперсонации
+}
+
+// Структура конфигурации защиты от CSRF для запросов с кукой refresh token
+type CSRF struct {
+	AllowedOrigins   []string `mapstructure:"allowedOrigins"`   // Origin, с которых запросы принимаются без CSRF-токена, например https://app.example.com
+	LegacyGetRefresh bool     `mapstructure:"legacyGetRefresh"` // Оставить устаревший GET /auth/refresh без проверки CSRF
+}
+
+// Структура конфигурации передачи refresh token
+type RefreshToken struct {
+	Cookie    RefreshCookie `mapstructure:"cookie"`
+	AllowBody bool          `mapstructure:"allowBody"` // Разрешить клиентам получать refresh token в теле ответа вместо куки
+}
+
+// Структура конфигурации куки с refresh token
+type RefreshCookie struct {
+	Name       string `mapstructure:"name"`       // По умолчанию refreshToken
+	Path       string `mapstructure:"path"`       // По умолчанию /
+	Domain     string `mapstructure:"domain"`     // Пустое значение — только текущий хост
+	Secure     bool   `mapstructure:"secure"`     // Отправлять куки только по HTTPS
+	SameSite   string `mapstructure:"sameSite"`   // lax, strict или none, по умолчанию lax
+	HostPrefix bool   `mapstructure:"hostPrefix"` // Добавить к имени префикс __Host- (требует secure, path / и пустой domain)
+}
+
+// Структура конфигурации входа по ссылке из письма
+type MagicLink struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	TokenTTL    time.Duration `mapstructure:"tokenTtl"`    // Время жизни ссылки
+	URL         string        `mapstructure:"url"`         // Адрес входа по ссылке, токен добавляется параметром token
+	MaxRequests int           `mapstructure:"maxRequests"` // Сколько ссылок можно запросить на один адрес или IP за window
+	Window      time.Duration `mapstructure:"window"`      // Через сколько после последнего запроса счетчик сбрасывается
+}
+
+// Структура конфигурации парольной политики
+type PasswordPolicy struct {
+	MinLength        int    `mapstructure:"minLength"`
+	MaxLength        int    `mapstructure:"maxLength"`
+	RequireUpper     bool   `mapstructure:"requireUpper"`
+	RequireLower     bool   `mapstructure:"requireLower"`
+	RequireDigit     bool   `mapstructure:"requireDigit"`
+	RequireSymbol    bool   `mapstructure:"requireSymbol"`
+	BreachedListFile string `mapstructure:"breachedListFile"` // Файл с утекшими паролями, по одному в строке
+	HistorySize      int    `mapstructure:"historySize"`      // Сколько последних паролей нельзя использовать повторно
+}
+
+// Структура конфигурации подтверждения адреса электронной почты
+type EmailVerification struct {
+	Required bool          `mapstructure:"required"` // Запрещать вход по паролю с неподтвержденным адресом
+	TokenTTL time.Duration `mapstructure:"tokenTtl"` // Время жизни ссылки подтверждения
+	URL      string        `mapstructure:"url"`      // Страница подтверждения, токен добавляется параметром token
+}
+
+// Структура конфигурации восстановления пароля
+type PasswordReset struct {
+	TokenTTL time.Duration `mapstructure:"tokenTtl"` // Время жизни ссылки для сброса пароля
+	URL      string        `mapstructure:"url"`      // Страница сброса пароля, токен добавляется параметром token
+}
+
+// Структура конфигурации входа через внешних провайдеров OpenID Connect
+type OIDC struct {
+	StateTTL  time.Duration           `mapstructure:"stateTtl"`  // Сколько ждать возврата пользователя от провайдера
+	Providers map[string]OIDCProvider `mapstructure:"providers"` // Провайдеры по имени, используемому в URL
+}
+
+// Структура конфигурации провайдера OpenID Connect. Секрет клиента можно задать в OIDC_<ИМЯ>_CLIENT_SECRET
+type OIDCProvider struct {
+	Issuer        string   `mapstructure:"issuer"`
+	ClientID      string   `mapstructure:"clientId"`
+	ClientSecret  string   `mapstructure:"clientSecret"`
+	RedirectURL   string   `mapstructure:"redirectUrl"`   // Адрес /auth/oidc/<имя>/callback этого сервиса
+	Scopes        []string `mapstructure:"scopes"`        // По умолчанию openid profile email
+	UsernameClaim string   `mapstructure:"usernameClaim"` // Claim с именем пользователя, по умолчанию preferred_username
+	LinkExisting  bool     `mapstructure:"linkExisting"`  // Привязывать вход к пользователю с тем же подтвержденным email (кроме ролей с правами)
+	CreateUsers   bool     `mapstructure:"createUsers"`   // Создавать пользователя при первом входе
+}
+
+// Структура конфигурации двухфакторной аутентификации
+type TwoFactor struct {
+	Issuer string `mapstructure:"issuer"` // Название сервиса в приложении-аутентификаторе
+}
+
+// Структура конфигурации ключей подписи токенов
+type Tokens struct {
+	KeysDir           string `mapstructure:"keysDir"`           // Каталог с закрытыми ключами <kid>.pem (RSA или Ed25519)
+	ActiveKeyID       string `mapstructure:"activeKeyId"`       // kid ключа, которым подписываются новые токены
+	HS256AcceptUntil  string `mapstructure:"hs256AcceptUntil"`  // До какого момента (RFC 3339) принимать токены HS256 с TOKEN_SECRET_KEY
+	AllowEphemeralKey bool   `mapstructure:"allowEphemeralKey"` // Без ключей на диске подписывать токены временным ключом (только для разработки)
+}
+
+// Структура конфигурации защиты от перебора паролей
+type LoginThrottle struct {
+	Window   time.Duration `mapstructure:"window"`   // Через сколько после последней ошибки счетчик сбрасывается
+	Username ThrottleRule  `mapstructure:"username"` // Ограничения по имени пользователя
+	IP       ThrottleRule  `mapstructure:"ip"`       // Ограничения по IP клиента
+}
+
+// Правило задержки и блокировки после неудачных попыток входа
+type ThrottleRule struct {
+	FreeAttempts     int           `mapstructure:"freeAttempts"`     // Попыток без задержки
+	BaseDelay        time.Duration `mapstructure:"baseDelay"`        // Первая задержка, далее удваивается
+	MaxDelay         time.Duration `mapstructure:"maxDelay"`         // Максимальная задержка
+	LockoutThreshold int           `mapstructure:"lockoutThreshold"` // Попыток до временной блокировки
+	LockoutDuration  time.Duration `mapstructure:"lockoutDuration"`  // Длительность блокировки
 }
 
 // Структура конфигурации PostgreSQL
@@ -59,5 +231,92 @@ func New(path string, fileName string) (*Config, error) {
 		return nil, errors.New("failed to process env variables: " + err.Error())
 	}
 
+	cfg.Mail.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+
+	// Секреты клиентов OIDC можно не хранить в файле конфигурации
+	for name, provider := range cfg.Auth.OIDC.Providers {
+		if secret := os.Getenv("OIDC_" + strings.ToUpper(name) + "_CLIENT_SECRET"); secret != "" {
+			provider.ClientSecret = secret
+			cfg.Auth.OIDC.Providers[name] = provider
+		}
+	}
+
+	if err := validateRefreshCookie(&cfg.Auth.RefreshToken.Cookie); err != nil {
+		return nil, err
+	}
+
+	origins, err := normalizeOrigins(cfg.Auth.CSRF.AllowedOrigins)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.CSRF.AllowedOrigins = origins
+
+	proxies, err := normalizeProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Server.TrustedProxies = proxies
+
 	return cfg, nil
 }
+
+// normalizeProxies приводит адреса прокси к подсетям; отдельный адрес становится подсетью из одного адреса
+func normalizeProxies(proxies []string) ([]string, error) {
+	normalized := make([]string, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			normalized = append(normalized, (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String())
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.New("invalid trusted proxy: " + proxy)
+		}
+		normalized = append(normalized, network.String())
+	}
+	return normalized, nil
+}
+
+// normalizeOrigins приводит origin к виду scheme://host[:port] в нижнем регистре
+func normalizeOrigins(origins []string) ([]string, error) {
+	normalized := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		u, err := url.Parse(strings.TrimSpace(origin))
+		if err != nil || u.Scheme == "" || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+			return nil, errors.New("invalid csrf allowed origin: " + origin)
+		}
+		normalized = append(normalized, strings.ToLower(u.Scheme+"://"+u.Host))
+	}
+	return normalized, nil
+}
+
+// validateRefreshCookie проверяет сочетание атрибутов куки, которое иначе отбросит браузер
+func validateRefreshCookie(cookie *RefreshCookie) error {
+	switch strings.ToLower(cookie.SameSite) {
+	case "", "lax", "strict":
+	case "none":
+		if !cookie.Secure {
+			return errors.New("invalid refresh token cookie config: sameSite none requires secure")
+		}
+	default:
+		return errors.New("invalid refresh token cookie config: unknown sameSite " + cookie.SameSite)
+	}
+
+	if cookie.HostPrefix {
+		if !cookie.Secure {
+			return errors.New("invalid refresh token cookie config: hostPrefix requires secure")
+		}
+		if cookie.Domain != "" {
+			return errors.New("invalid refresh token cookie config: hostPrefix requires an empty domain")
+		}
+		if cookie.Path != "" && cookie.Path != "/" {
+			return errors.New("invalid refresh token cookie config: hostPrefix requires path /")
+		}
+	}
+	return nil
+}
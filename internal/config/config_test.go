@@ -0,0 +1,59 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidateRefreshCookie(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  RefreshCookie
+		wantErr bool
+	}{
+		{name: "defaults", cookie: RefreshCookie{}},
+		{name: "strict", cookie: RefreshCookie{SameSite: "Strict"}},
+		{name: "none with secure", cookie: RefreshCookie{SameSite: "none", Secure: true}},
+		{name: "none without secure", cookie: RefreshCookie{SameSite: "none"}, wantErr: true},
+		{name: "unknown same site", cookie: RefreshCookie{SameSite: "relaxed"}, wantErr: true},
+		{name: "host prefix", cookie: RefreshCookie{HostPrefix: true, Secure: true, Path: "/"}},
+		{name: "host prefix without secure", cookie: RefreshCookie{HostPrefix: true}, wantErr: true},
+		{name: "host prefix with domain", cookie: RefreshCookie{HostPrefix: true, Secure: true, Domain: "example.com"}, wantErr: true},
+		{name: "host prefix with path", cookie: RefreshCookie{HostPrefix: true, Secure: true, Path: "/auth"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRefreshCookie(&tt.cookie); (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", origins: nil, want: []string{}},
+		{name: "lower case and trailing slash", origins: []string{" HTTPS://App.Example.com/ "}, want: []string{"https://app.example.com"}},
+		{name: "port kept", origins: []string{"http://localhost:3000"}, want: []string{"http://localhost:3000"}},
+		{name: "no scheme", origins: []string{"app.example.com"}, wantErr: true},
+		{name: "with path", origins: []string{"https://app.example.com/login"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeOrigins(tt.origins)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
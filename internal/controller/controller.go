@@ -3,6 +3,7 @@ package controller
 import (
 	"net/http"
 
+	"github.com/kolibriee/users-rest-api/internal/config"
 	v1 "github.com/kolibriee/users-rest-api/internal/controller/http/v1"
 	"github.com/kolibriee/users-rest-api/internal/service"
 )
@@ -15,8 +16,8 @@ type Controller struct {
 	Handler RouterInitializer
 }
 
-func NewController(services *service.Service) *Controller {
+func NewController(services *service.Service, cfg *config.Config) *Controller {
 	return &Controller{
-		Handler: v1.NewHandler(services),
+		Handler: v1.NewHandler(services, &cfg.Auth, &cfg.Server),
 	}
 }
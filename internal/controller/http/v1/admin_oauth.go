@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// GetOAuthClients godoc
+//
+//	@Summary		Get OAuth clients
+//	@Description	Get list of registered OAuth clients (requires the oauth_clients:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		entities.OAuthClient
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/oauth/clients [get]
+func (h *Handler) GetOAuthClients(c echo.Context) error {
+	clients, err := h.services.OAuth.GetClients(c.Request().Context())
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get oauth clients; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, clients)
+}
+
+// CreateOAuthClient godoc
+//
+//	@Summary		Register an OAuth client
+//	@Description	Register an OAuth client (requires the oauth_clients:write permission). The client secret of a confidential client is returned only once.
+//	@Description	Service clients use the client_credentials grant; with publicKey they authenticate with a signed JWT instead of a secret.
+//	@Description	A service client gets the permissions of its role; without a role it has none.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			input	body		entities.OAuthClientInput	true	"Client data"
+//	@Success		201		{object}	entities.OAuthClientCredentials
+//	@Failure		400		{object}	statusResponse	"invalid request or unknown role"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/oauth/clients [post]
+func (h *Handler) CreateOAuthClient(c echo.Context) error {
+	var input entities.OAuthClientInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateOAuthClientInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	client, err := h.services.OAuth.CreateClient(c.Request().Context(), input)
+	if err != nil {
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return newErrorResponse(c, http.StatusBadRequest, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't create oauth client; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, client)
+}
+
+// DeleteOAuthClient godoc
+//
+//	@Summary		Delete an OAuth client
+//	@Description	Delete an OAuth client and revoke its refresh tokens (requires the oauth_clients:write permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			clientId	path		string			true	"Client ID"
+//	@Success		200			{object}	statusResponse	"ok"
+//	@Failure		404			{object}	statusResponse	"oauth client not found"
+//	@Failure		500			{object}	statusResponse	"internal server error"
+//	@Router			/admin/oauth/clients/{clientId} [delete]
+func (h *Handler) DeleteOAuthClient(c echo.Context) error {
+	if err := h.services.OAuth.DeleteClient(c.Request().Context(), c.Param("clientId")); err != nil {
+		if errors.Is(err, service.ErrOAuthClientNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't delete oauth client; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// GetOrganizations godoc
+//
+//	@Summary		Get organizations
+//	@Description	Get list of all organizations (requires the organizations:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		entities.Organization
+//	@Failure		403	{object}	statusResponse	"missing permission"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations [get]
+func (h *Handler) GetOrganizations(c echo.Context) error {
+	organizations, err := h.services.Organizations.GetOrganizations(c.Request().Context())
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get organizations; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, organizations)
+}
+
+// GetOrganization godoc
+//
+//	@Summary		Get an organization
+//	@Description	Get an organization by ID (requires the organizations:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Organization ID"
+//	@Success		200	{object}	entities.Organization
+//	@Failure		400	{object}	statusResponse	"invalid organization id"
+//	@Failure		403	{object}	statusResponse	"missing permission"
+//	@Failure		404	{object}	statusResponse	"organization not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations/{id} [get]
+func (h *Handler) GetOrganization(c echo.Context) error {
+	organizationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+	}
+
+	organization, err := h.services.Organizations.GetOrganization(c.Request().Context(), organizationId)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, organization)
+}
+
+// CreateOrganization godoc
+//
+//	@Summary		Create an organization
+//	@Description	Create an organization without members (requires the organizations:write permission)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			input	body		entities.OrganizationInput	true	"Organization data"
+//	@Success		201		{object}	entities.Organization
+//	@Failure		400		{object}	statusResponse	"invalid request"
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		409		{object}	statusResponse	"organization with this slug already exists"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations [post]
+func (h *Handler) CreateOrganization(c echo.Context) error {
+	var input entities.OrganizationInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateOrganizationInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	organization, err := h.services.Organizations.CreateOrganization(c.Request().Context(), input)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, organization)
+}
+
+// DeleteOrganization godoc
+//
+//	@Summary		Delete an organization
+//	@Description	Delete an organization and all memberships in it; the user accounts stay (requires the organizations:write permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int				true	"Organization ID"
+//	@Success		200	{object}	statusResponse	"ok"
+//	@Failure		400	{object}	statusResponse	"invalid organization id"
+//	@Failure		403	{object}	statusResponse	"missing permission"
+//	@Failure		404	{object}	statusResponse	"organization not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations/{id} [delete]
+func (h *Handler) DeleteOrganization(c echo.Context) error {
+	organizationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+	}
+
+	if err := h.services.Organizations.DeleteOrganization(c.Request().Context(), organizationId); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// AdminGetMembers godoc
+//
+//	@Summary		Get organization members
+//	@Description	Get members of any organization with their roles in it (requires the organizations:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Organization ID"
+//	@Success		200	{array}		entities.Member
+//	@Failure		400	{object}	statusResponse	"invalid organization id"
+//	@Failure		403	{object}	statusResponse	"missing permission"
+//	@Failure		404	{object}	statusResponse	"organization not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations/{id}/members [get]
+func (h *Handler) AdminGetMembers(c echo.Context) error {
+	organizationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+	}
+	return h.getMembers(c, organizationId)
+}
+
+// AdminAddMember godoc
+//
+//	@Summary		Add an organization member
+//	@Description	Add an existing user to any organization. Without a role the member gets the default role
+//	@Description	(requires the organizations:write permission)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int						true	"Organization ID"
+//	@Param			input	body		entities.MemberInput	true	"User and role"
+//	@Success		201		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid request or unknown role"
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		404		{object}	statusResponse	"organization or user not found"
+//	@Failure		409		{object}	statusResponse	"user is already a member"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations/{id}/members [post]
+func (h *Handler) AdminAddMember(c echo.Context) error {
+	organizationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+	}
+	return h.addMember(c, organizationId)
+}
+
+// AdminUpdateMember godoc
+//
+//	@Summary		Change a member role
+//	@Description	Change the role of a user in any organization (requires the organizations:write permission)
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int							true	"Organization ID"
+//	@Param			userId	path		int							true	"User ID"
+//	@Param			input	body		entities.MemberUpdateInput	true	"New role"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid request or unknown role"
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		404		{object}	statusResponse	"user is not a member"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations/{id}/members/{userId} [put]
+func (h *Handler) AdminUpdateMember(c echo.Context) error {
+	organizationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+	}
+	return h.updateMember(c, organizationId)
+}
+
+// AdminRemoveMember godoc
+//
+//	@Summary		Remove an organization member
+//	@Description	Remove a user from any organization; the user account stays (requires the organizations:write permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int				true	"Organization ID"
+//	@Param			userId	path		int				true	"User ID"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid organization id or user id"
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		404		{object}	statusResponse	"user is not a member"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/organizations/{id}/members/{userId} [delete]
+func (h *Handler) AdminRemoveMember(c echo.Context) error {
+	organizationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+	}
+	return h.removeMember(c, organizationId)
+}
+
+// organizationErrorResponse преобразует ошибки сервиса организаций в HTTP-ответ
+func organizationErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrMemberNotFound), errors.Is(err, service.ErrUserNotFound):
+		return newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrOrganizationExists), errors.Is(err, service.ErrMemberExists):
+		return newErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrRoleNotFound):
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// GetRoles godoc
+//
+//	@Summary		Get roles
+//	@Description	Get list of roles with their permissions (requires the roles:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		entities.Role
+//	@Failure		403	{object}	statusResponse	"missing permission"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/roles [get]
+func (h *Handler) GetRoles(c echo.Context) error {
+	roles, err := h.services.Roles.GetRoles(c.Request().Context())
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get roles; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, roles)
+}
+
+// GetRoleByName godoc
+//
+//	@Summary		Get a role
+//	@Description	Get a role with its permissions (requires the roles:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string	true	"Role name"
+//	@Success		200		{object}	entities.Role
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		404		{object}	statusResponse	"role not found"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/roles/{name} [get]
+func (h *Handler) GetRoleByName(c echo.Context) error {
+	role, err := h.services.Roles.GetRoleByName(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// CreateRole godoc
+//
+//	@Summary		Create a role
+//	@Description	Create a role with a set of permissions (requires the roles:write permission).
+//	@Description	With isDefault the role is given to new users instead of the current default role.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			input	body		entities.RoleInput	true	"Role data"
+//	@Success		201		{object}	entities.Role
+//	@Failure		400		{object}	statusResponse	"invalid request or unknown permission"
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		409		{object}	statusResponse	"role already exists"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/roles [post]
+func (h *Handler) CreateRole(c echo.Context) error {
+	var input entities.RoleInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateRoleInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	role, err := h.services.Roles.CreateRole(c.Request().Context(), input)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole godoc
+//
+//	@Summary		Update a role
+//	@Description	Change the description, permissions or default flag of a role (requires the roles:write permission).
+//	@Description	The permissions list replaces the current one; permissions of the admin role can't be changed.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string					true	"Role name"
+//	@Param			input	body		entities.RoleUpdateInput	true	"Changes"
+//	@Success		200		{object}	entities.Role
+//	@Failure		400		{object}	statusResponse	"invalid request, unknown permission or admin role"
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		404		{object}	statusResponse	"role not found"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/roles/{name} [put]
+func (h *Handler) UpdateRole(c echo.Context) error {
+	var input entities.RoleUpdateInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateRoleUpdateInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	role, err := h.services.Roles.UpdateRole(c.Request().Context(), c.Param("name"), input)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole godoc
+//
+//	@Summary		Delete a role
+//	@Description	Delete a role that is not built-in, not the default role and not assigned to any user
+//	@Description	(requires the roles:write permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			name	path		string			true	"Role name"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"built-in or default role"
+//	@Failure		403		{object}	statusResponse	"missing permission"
+//	@Failure		404		{object}	statusResponse	"role not found"
+//	@Failure		409		{object}	statusResponse	"role is assigned to users"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/roles/{name} [delete]
+func (h *Handler) DeleteRole(c echo.Context) error {
+	if err := h.services.Roles.DeleteRole(c.Request().Context(), c.Param("name")); err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// GetPermissions godoc
+//
+//	@Summary		Get permissions
+//	@Description	Get list of permissions that can be given to roles (requires the roles:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		entities.Permission
+//	@Failure		403	{object}	statusResponse	"missing permission"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/permissions [get]
+func (h *Handler) GetPermissions(c echo.Context) error {
+	permissions, err := h.services.Roles.GetPermissions(c.Request().Context())
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get permissions; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, permissions)
+}
+
+// roleErrorResponse преобразует ошибки сервиса ролей в HTTP-ответ
+func roleErrorResponse(c echo.Context, err error) error {
+	var permissionErr *service.UnknownPermissionError
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		return newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrRoleExists), errors.Is(err, service.ErrRoleInUse):
+		return newErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrBuiltinRole), errors.Is(err, service.ErrDefaultRole), errors.As(err, &permissionErr):
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
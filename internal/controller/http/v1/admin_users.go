@@ -3,9 +3,11 @@ package v1
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/kolibriee/users-rest-api/internal/entities"
 	bun_entities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/service"
 	"github.com/labstack/echo/v4"
 )
 
@@ -14,32 +16,39 @@ var _ = bun_entities.User{}
 // GetAllUsers godoc
 //
 //	@Summary		Get all users
-//	@Description	Get list of all users (admin only)
+//	@Description	Get list of users across all organizations, or of one organization with organizationId
+//	@Description	(requires the users:read permission). Users hidden by access policy deny rules are left out.
+//	@Description	A token with an active organization only sees members of that organization.
 //	@Tags			admin
 //	@Produce		json
 //	@Security		ApiKeyAuth
-//	@Success		200	{array}		bun_entities.User
-//	@Failure		403	{object}	statusResponse	"access denied"
-//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Param			organizationId	query		int	false	"Only members of this organization"
+//	@Success		200				{array}		bun_entities.User
+//	@Failure		400				{object}	statusResponse	"invalid organization id"
+//	@Failure		403				{object}	statusResponse	"access denied or another organization"
+//	@Failure		500				{object}	statusResponse	"internal server error"
 //	@Router			/admin/users [get]
 func (h *Handler) GetAllUsers(c echo.Context) error {
-	role, err := getRole(c) // Получение роли пользователя
-	if err != nil || role != "admin" {
-		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error()) // Проверка на доступ
-	}
-
-	users, err := h.services.GetAllUsers(c.Request().Context()) // Получение всех пользователей
-	if err != nil {
-		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get users; ").Error()+err.Error()) // Обработка ошибки
+	ctx := c.Request().Context()
+	if param := c.QueryParam("organizationId"); param != "" {
+		organizationId, err := strconv.Atoi(param)
+		if err != nil {
+			return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+		}
+		// Токен с активной организацией не дает смотреть участников других организаций
+		if activeId, err := getOrganizationId(c); err == nil && activeId != organizationId {
+			return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+		}
+		ctx = service.WithOrganization(ctx, organizationId) // Только участники указанной организации
 	}
 
-	return c.JSON(http.StatusOK, users) // Возвращение списка пользователей
+	return h.usersResponse(c, ctx)
 }
 
 // GetUserByID godoc
 //
 //	@Summary		Get user by ID
-//	@Description	Get a user by their ID (admin or user themselves)
+//	@Description	Get a user by their ID (requires the users:read permission)
 //	@Tags			admin
 //	@Produce		json
 //	@Security		ApiKeyAuth
@@ -56,14 +65,16 @@ func (h *Handler) AdminGetUserByID(c echo.Context) error {
 // CreateUser godoc
 //
 //	@Summary		Create a new user
-//	@Description	Create a new user (admin only)
+//	@Description	Create a new user (requires the users:write permission). Without a role the user gets the default role;
+//	@Description	choosing a role requires the roles:assign permission.
 //	@Tags			admin
 //	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
 //	@Param			user	body		entities.CreateUserInput	true	"User data"
 //	@Success		201		{object}	map[string]interface{}		"user created"
-//	@Failure		400		{object}	statusResponse				"invalid request"
+//	@Failure		400		{object}	passwordErrorResponse		"invalid request, unknown role or password rejected by the policy"
+//	@Failure		403		{object}	statusResponse				"missing permission"
 //	@Failure		500		{object}	statusResponse				"internal server error"
 //	@Router			/admin/users [post]
 func (h *Handler) CreateUser(c echo.Context) error {
@@ -74,8 +85,18 @@ func (h *Handler) CreateUser(c echo.Context) error {
 	if err := user.ValidateCreateUserInput(); err != nil {
 		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность данных
 	}
+	if user.Role != "" && !h.hasPermission(c, entities.PermissionRolesAssign) {
+		return newErrorResponse(c, http.StatusForbidden, service.ErrRoleAssignmentDenied.Error()) // Выбор роли требует отдельного права
+	}
 	id, err := h.services.CreateUser(c.Request().Context(), user) // Создание нового пользователя
 	if err != nil {
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return newPasswordErrorResponse(c, policyErr) // Пароль не соответствует политике
+		}
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return newErrorResponse(c, http.StatusBadRequest, err.Error())
+		}
 		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't create user; ").Error()+err.Error()) // Обработка ошибки
 	}
 
@@ -87,7 +108,7 @@ func (h *Handler) CreateUser(c echo.Context) error {
 // UpdateUser godoc
 //
 //	@Summary		Update a user
-//	@Description	Update user information (admin or user themselves)
+//	@Description	Update user information (requires the users:write permission; changing the role also requires roles:assign)
 //	@Tags			admin
 //	@Accept			json
 //	@Produce		json
@@ -95,7 +116,7 @@ func (h *Handler) CreateUser(c echo.Context) error {
 //	@Param			id		path		int							true	"User ID"
 //	@Param			user	body		entities.UserUpdateInput	true	"Updated user data"
 //	@Success		200		{object}	statusResponse				"ok"
-//	@Failure		400		{object}	statusResponse				"invalid request"
+//	@Failure		400		{object}	passwordErrorResponse		"invalid request or password rejected by the policy"
 //	@Failure		403		{object}	statusResponse				"access denied"
 //	@Failure		500		{object}	statusResponse				"internal server error"
 //	@Router			/admin/users/{id} [put]
@@ -106,7 +127,7 @@ func (h *Handler) AdminUpdateUser(c echo.Context) error {
 // DeleteUser godoc
 //
 //	@Summary		Delete a user
-//	@Description	Delete a user by their ID (admin or user themselves)
+//	@Description	Delete a user by their ID (requires the users:write permission)
 //	@Tags			admin
 //	@Produce		json
 //	@Security		ApiKeyAuth
@@ -119,3 +140,64 @@ func (h *Handler) AdminUpdateUser(c echo.Context) error {
 func (h *Handler) AdminDeleteUser(c echo.Context) error {
 	return h.DeleteUser(c)
 }
+
+// GetUserLock godoc
+//
+//	@Summary		Get user login lock state
+//	@Description	Get failed login counter and lockout state of a user (requires the users:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	entities.LoginLockState
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		404	{object}	statusResponse	"user not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/users/{id}/lock [get]
+func (h *Handler) AdminGetUserLock(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	state, err := h.services.Authorization.GetLoginLockState(c.Request().Context(), userId) // Получение состояния блокировки
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get lock state; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, state)
+}
+
+// UnlockUser godoc
+//
+//	@Summary		Unlock user
+//	@Description	Remove login lockout and reset failed login counter of a user (requires the users:write permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int				true	"User ID"
+//	@Success		200	{object}	statusResponse	"ok"
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		404	{object}	statusResponse	"user not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/users/{id}/lock [delete]
+func (h *Handler) AdminUnlockUser(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	if err := h.services.Authorization.UnlockUser(c.Request().Context(), userId); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't unlock user; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
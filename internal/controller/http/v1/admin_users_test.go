@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+func TestAdminUserRoutesTenantScope(t *testing.T) {
+	const (
+		orgAdminToken   = "org-admin-token"   // Администратор организации 1 без права organizations:read
+		superAdminToken = "super-admin-token" // Администратор с правом organizations:read, активна организация 1
+		patToken        = "pat_org-admin"     // Личный токен администратора организации 1
+		member          = 2                   // Участник организации 1
+		otherMember     = 3                   // Участник организации 2
+		missingUser     = 99
+	)
+	routes := []struct {
+		method string
+		path   string
+		call   string
+	}{
+		{http.MethodGet, "/lock", "lock state"},
+		{http.MethodDelete, "/lock", "unlock"},
+		{http.MethodDelete, "/2fa", "reset 2fa"},
+		{http.MethodGet, "/sessions", "sessions"},
+		{http.MethodDelete, "/sessions/5", "revoke session"},
+	}
+	tests := []struct {
+		name       string
+		token      string
+		userId     int
+		wantStatus int
+	}{
+		{name: "member of the active organization", token: orgAdminToken, userId: member, wantStatus: http.StatusOK},
+		{name: "member of another organization", token: orgAdminToken, userId: otherMember, wantStatus: http.StatusNotFound},
+		{name: "nonexistent user", token: orgAdminToken, userId: missingUser, wantStatus: http.StatusNotFound},
+		{name: "organizations reader sees other organizations", token: superAdminToken, userId: otherMember, wantStatus: http.StatusOK},
+		{name: "organizations reader and nonexistent user", token: superAdminToken, userId: missingUser, wantStatus: http.StatusNotFound},
+		{name: "api token bound to the owner's organization", token: patToken, userId: otherMember, wantStatus: http.StatusNotFound},
+		{name: "api token and member of the owner's organization", token: patToken, userId: member, wantStatus: http.StatusOK},
+	}
+
+	adminPermissions := []string{entities.PermissionUsersRead, entities.PermissionUsersWrite, entities.PermissionSessionsRead, entities.PermissionSessionsRevoke}
+	organizationID := 1
+
+	for _, tt := range tests {
+		for _, route := range routes {
+			t.Run(tt.name+" "+route.method+" "+route.path, func(t *testing.T) {
+				authorization := &fakeAuthorization{accessTokens: map[string]*auth.CustomClaims{
+					orgAdminToken:   {StandardClaims: jwt.StandardClaims{Subject: "1"}, Role: "org-admin", Organization: organizationID},
+					superAdminToken: {StandardClaims: jwt.StandardClaims{Subject: "1"}, Role: entities.RoleAdmin, Organization: organizationID},
+				}}
+				router := newTestRouter(&service.Service{
+					Authorization: authorization,
+					TwoFactor:     &fakeTwoFactor{authorization: authorization},
+					APITokens: &fakeAPITokens{token: patToken, principal: entities.APITokenPrincipal{
+						TokenID:        1,
+						UserID:         1,
+						Role:           "org-admin",
+						Scopes:         []string{entities.ScopeAdminRead, entities.ScopeAdminWrite},
+						OrganizationID: &organizationID,
+					}},
+					Roles: &fakeRoles{permissions: map[string][]string{
+						"org-admin":        adminPermissions,
+						entities.RoleAdmin: append(slices.Clone(adminPermissions), entities.PermissionOrganizationsRead),
+					}},
+					Users: &fakeUsers{organizations: map[int]int{1: 1, member: 1, otherMember: 2}},
+				}, &config.Auth{})
+
+				req := httptest.NewRequest(route.method, "/admin/users/"+strconv.Itoa(tt.userId)+route.path, nil)
+				req.Header.Set(authorizationHeader, "Bearer "+tt.token)
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+				}
+				// Действие с невидимым пользователем не выполняется
+				var wantCalls []string
+				if tt.wantStatus == http.StatusOK {
+					wantCalls = []string{route.call + " " + strconv.Itoa(tt.userId)}
+				}
+				if !slices.Equal(authorization.calls, wantCalls) {
+					t.Errorf("calls = %q, want %q", authorization.calls, wantCalls)
+				}
+			})
+		}
+	}
+}
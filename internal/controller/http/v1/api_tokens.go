@@ -0,0 +1,238 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// GetPersonalAccessTokens godoc
+//
+//	@Summary		Get personal access tokens
+//	@Description	Get personal access tokens of a user without the token values (user themselves or the tokens:read permission)
+//	@Tags			users
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{array}		entities.APIToken
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		403	{object}	statusResponse	"access denied"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/tokens [get]
+func (h *Handler) GetPersonalAccessTokens(c echo.Context) error {
+	// Извлечение ID пользователя из параметров запроса
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	// Проверка прав доступа
+	if !h.canAccessUser(c, userId, entities.PermissionTokensRead) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	tokens, err := h.services.APITokens.GetAPITokens(c.Request().Context(), userId, entities.APITokenKindPersonal)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get tokens; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// CreatePersonalAccessToken godoc
+//
+//	@Summary		Create a personal access token
+//	@Description	Create a long-lived token for scripts and CI (user themselves, signed in with a password).
+//	@Description	The token is returned only once; send it as "Authorization: Bearer pat_...".
+//	@Description	Scopes: read (GET requests), write (other requests), admin (/admin routes, admins only), or narrower
+//	@Description	resource scopes such as profile:read, sessions:write or admin:read. A token limited by scope can't
+//	@Description	create a token with wider scopes.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int						true	"User ID"
+//	@Param			input	body		entities.APITokenInput	true	"Token name, scopes and expiry"
+//	@Success		201		{object}	entities.APITokenCreated
+//	@Failure		400		{object}	statusResponse	"invalid request"
+//	@Failure		403		{object}	statusResponse	"access denied"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/tokens [post]
+func (h *Handler) CreatePersonalAccessToken(c echo.Context) error {
+	// Извлечение ID пользователя из параметров запроса
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	// Токен можно выпустить только себе и только после входа, а не другим API-токеном
+	currentUserId, err := getUserId(c)
+	if err != nil || currentUserId != userId || isAPITokenRequest(c) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	var input entities.APITokenInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateAPITokenInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	// Токен, ограниченный scope, не может выпустить токен с более широкими scope
+	if scopes, ok := getScopes(c); ok {
+		for _, scope := range input.Scopes {
+			if !entities.ScopeAllows(scopes, scope) {
+				return newErrorResponse(c, http.StatusForbidden, "scope "+scope+" exceeds the current token")
+			}
+		}
+	}
+
+	token, err := h.services.APITokens.CreateAPIToken(c.Request().Context(), userId, entities.APITokenKindPersonal, input)
+	if err != nil {
+		return apiTokenErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, token)
+}
+
+// DeletePersonalAccessToken godoc
+//
+//	@Summary		Revoke a personal access token
+//	@Description	Revoke a personal access token of a user (user themselves or the tokens:revoke permission)
+//	@Tags			users
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int				true	"User ID"
+//	@Param			tokenId	path		int				true	"Token ID"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid user id"
+//	@Failure		403		{object}	statusResponse	"access denied"
+//	@Failure		404		{object}	statusResponse	"api token not found"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/tokens/{tokenId} [delete]
+func (h *Handler) DeletePersonalAccessToken(c echo.Context) error {
+	// Извлечение ID пользователя и токена из параметров запроса
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+	tokenId, err := strconv.Atoi(c.Param("tokenId"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid token id").Error())
+	}
+
+	// Проверка прав доступа
+	if !h.canAccessUser(c, userId, entities.PermissionTokensRevoke) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	if err := h.services.APITokens.DeleteAPIToken(c.Request().Context(), userId, entities.APITokenKindPersonal, tokenId); err != nil {
+		return apiTokenErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// GetAPIKeys godoc
+//
+//	@Summary		Get service API keys
+//	@Description	Get all service API keys without the key values (requires the api_keys:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		entities.APIToken
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/api-keys [get]
+func (h *Handler) GetAPIKeys(c echo.Context) error {
+	keys, err := h.services.APITokens.GetAPITokens(c.Request().Context(), 0, entities.APITokenKindService)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get api keys; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create a service API key
+//	@Description	Create an API key for a service (requires the api_keys:write permission, signed in with a password). The key acts on behalf of the admin who created it
+//	@Description	and is returned only once; send it as "Authorization: Bearer sak_...".
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			input	body		entities.APITokenInput	true	"Key name, scopes and expiry"
+//	@Success		201		{object}	entities.APITokenCreated
+//	@Failure		400		{object}	statusResponse	"invalid request"
+//	@Failure		403		{object}	statusResponse	"access denied"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/api-keys [post]
+func (h *Handler) CreateAPIKey(c echo.Context) error {
+	// Ключ выпускается только после входа, а не другим API-токеном
+	adminId, err := getUserId(c)
+	if err != nil || isAPITokenRequest(c) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	var input entities.APITokenInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateAPITokenInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	key, err := h.services.APITokens.CreateAPIToken(c.Request().Context(), adminId, entities.APITokenKindService, input)
+	if err != nil {
+		return apiTokenErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// DeleteAPIKey godoc
+//
+//	@Summary		Revoke a service API key
+//	@Description	Revoke a service API key (requires the api_keys:write permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			keyId	path		int				true	"Key ID"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid key id"
+//	@Failure		404		{object}	statusResponse	"api token not found"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/api-keys/{keyId} [delete]
+func (h *Handler) DeleteAPIKey(c echo.Context) error {
+	keyId, err := strconv.Atoi(c.Param("keyId"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid key id").Error())
+	}
+
+	if err := h.services.APITokens.DeleteAPIToken(c.Request().Context(), 0, entities.APITokenKindService, keyId); err != nil {
+		return apiTokenErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// apiTokenErrorResponse преобразует ошибки сервиса API-токенов в HTTP-ответ
+func apiTokenErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAPITokenNotFound):
+		return newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAPITokenAdminScope):
+		return newErrorResponse(c, http.StatusForbidden, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
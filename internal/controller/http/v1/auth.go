@@ -1,21 +1,28 @@
 package v1
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
 	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
 )
 
 // SignUp godoc
 //
 //	@Summary	Register a new user
+//	@Description	Creates the user and sends a verification link to the email.
 //	@Tags		auth
 //	@Accept		json
 //	@Produce	json
 //	@Param		input	body		entities.SignUpInput	true	"SignUp input"
 //	@Success	200		{object}	map[string]interface{}
-//	@Failure	400		{object}	string	"Invalid input body"
+//	@Failure	400		{object}	passwordErrorResponse	"Invalid input body or password rejected by the policy"
 //	@Failure	500		{object}	string	"Internal server error"
 //	@Router		/auth/sign-up [post]
 func (h *Handler) SignUp(c echo.Context) error {
@@ -29,6 +36,10 @@ func (h *Handler) SignUp(c echo.Context) error {
 	}
 	id, err := h.services.Authorization.SignUp(c.Request().Context(), input) // Регистрация пользователя
 	if err != nil {
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return newPasswordErrorResponse(c, policyErr) // Пароль не соответствует политике
+		}
 		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -42,9 +53,19 @@ func (h *Handler) SignUp(c echo.Context) error {
 //	@Tags		auth
 //	@Accept		json
 //	@Produce	json
+//	@Description	Returns an access token and sets the refresh token cookie. If two-factor authentication is enabled,
+//	@Description	returns {"twoFactorRequired": true, "challengeToken": "..."} instead; finish with /auth/sign-in/2fa.
+//	@Description	With the header X-Refresh-Token-Transport: body the refresh token is returned in the response instead of the cookie.
+//	@Description	An optional scope (space-separated, e.g. "profile:read sessions:read") limits the tokens to part of the user's rights;
+//	@Description	refreshed tokens keep it. Without scope the tokens can do everything the user can.
+//	@Description	admin:read, admin:write and admin are only allowed for roles with permissions; other roles get 400.
 //	@Param		input	body		entities.SignInInput	true	"SignIn input"
+//	@Param		X-Refresh-Token-Transport	header	string	false	"cookie (default) or body"
 //	@Success	200		{object}	map[string]interface{}
-//	@Failure	400		{object}	string	"Invalid input body"
+//	@Failure	400		{object}	string	"Invalid input body or scope not allowed"
+//	@Failure	401		{object}	string	"Invalid username or password"
+//	@Failure	403		{object}	string	"Email address is not verified"
+//	@Failure	429		{object}	string	"Too many failed login attempts"
 //	@Failure	500		{object}	string	"Internal server error"
 //	@Router		/auth/sign-in [post]
 func (h *Handler) SignIn(c echo.Context) error {
@@ -57,55 +78,460 @@ func (h *Handler) SignIn(c echo.Context) error {
 		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
 	}
 
-	accessToken, refreshToken, err := h.services.Authorization.SignIn(c.Request().Context(), input) // Авторизация пользователя
+	inBody, err := h.refreshInBody(c)
 	if err != nil {
-		return newErrorResponse(c, http.StatusInternalServerError, err.Error()) // Обработка ошибки
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
 
-	// Установка куки с refreshToken
-	c.SetCookie(&http.Cookie{
-		Name:     "refreshToken",
-		Value:    refreshToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false,
-	})
+	result, err := h.services.Authorization.SignIn(c.Request().Context(), input, clientInfo(c)) // Авторизация пользователя
+	if err != nil {
+		return signInErrorResponse(c, err)
+	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"accessToken": accessToken,
-	})
+	return h.signInResultResponse(c, result, inBody)
+}
+
+// SignInTwoFactor godoc
+//
+//	@Summary	Finish login with a two-factor code
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		input	body		entities.TwoFactorSignInInput	true	"Challenge token and TOTP or recovery code"
+//	@Param		X-Refresh-Token-Transport	header	string	false	"cookie (default) or body"
+//	@Success	200		{object}	map[string]interface{}
+//	@Failure	400		{object}	string	"Invalid input body"
+//	@Failure	401		{object}	string	"Invalid challenge token or code"
+//	@Failure	429		{object}	string	"Too many failed login attempts"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/sign-in/2fa [post]
+func (h *Handler) SignInTwoFactor(c echo.Context) error {
+	var input entities.TwoFactorSignInInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+
+	if err := input.ValidateTwoFactorSignInInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	inBody, err := h.refreshInBody(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	accessToken, refreshToken, err := h.services.Authorization.SignInTwoFactor(c.Request().Context(), input, clientInfo(c))
+	if err != nil {
+		return signInErrorResponse(c, err)
+	}
+
+	return h.tokensResponse(c, accessToken, refreshToken, inBody)
 }
 
 // Refresh godoc
 //
 //	@Summary	Refresh access token
+//	@Description	Rotates the refresh token from the cookie. Cookie requests must send X-CSRF-Token with the value of the csrfToken cookie
+//	@Description	or come from an origin in auth.csrf.allowedOrigins. With the header X-Refresh-Token-Transport: body
+//	@Description	the refresh token is read from the request body and the new one is returned in the response.
 //	@Tags		auth
+//	@Accept		json
 //	@Produce	json
-//	@Success	200	{object}	map[string]interface{}
-//	@Failure	401	{object}	string	"No refresh token provided"
-//	@Failure	500	{object}	string	"Internal server error"
-//	@Router		/auth/refresh [get]
+//	@Param		X-Refresh-Token-Transport	header	string	false	"cookie (default) or body"
+//	@Param		X-CSRF-Token	header	string	false	"Value of the csrfToken cookie"
+//	@Param		input	body		entities.RefreshTokenInput	false	"Refresh token (body transport only)"
+//	@Success	200		{object}	map[string]interface{}
+//	@Failure	400		{object}	string	"Unknown or disabled refresh token transport"
+//	@Failure	401		{object}	string	"No refresh token provided, invalid or reused refresh token"
+//	@Failure	403		{object}	string	"CSRF token missing or invalid"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/refresh [post]
 func (h *Handler) Refresh(c echo.Context) error {
-	refreshTokenCookie, err := c.Cookie("refreshToken") // Получение refreshToken из куки
+	inBody, err := h.refreshInBody(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	refreshToken, err := h.readRefreshToken(c, inBody) // Получение refreshToken из куки или тела запроса
 	if err != nil {
-		return newErrorResponse(c, http.StatusUnauthorized, "no refresh token provided") // Проверка наличия куки
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error())
 	}
 
-	accessToken, newRefreshToken, err := h.services.Authorization.Refresh(c.Request().Context(), refreshTokenCookie.Value) // Обновление токенов
+	accessToken, newRefreshToken, err := h.services.Authorization.Refresh(c.Request().Context(), refreshToken, clientInfo(c)) // Обновление токенов
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			if !inBody {
+				h.clearRefreshTokenCookie(c)
+			}
+			return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		}
 		return newErrorResponse(c, http.StatusInternalServerError, err.Error()) // Обработка ошибки
 	}
 
-	// Установка нового refreshToken
-	c.SetCookie(&http.Cookie{
-		Name:     "refreshToken",
-		Value:    newRefreshToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false,
+	return h.tokensResponse(c, accessToken, newRefreshToken, inBody)
+}
+
+// Logout godoc
+//
+//	@Summary	Logout from the current session
+//	@Description	Revokes the refresh token cookie and, if an Authorization header is sent, the access token.
+//	@Description	Cookie requests are checked against CSRF like /auth/refresh.
+//	@Description	With the header X-Refresh-Token-Transport: body the refresh token is read from the request body.
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		X-Refresh-Token-Transport	header	string	false	"cookie (default) or body"
+//	@Param		X-CSRF-Token	header	string	false	"Value of the csrfToken cookie"
+//	@Param		input	body		entities.RefreshTokenInput	false	"Refresh token (body transport only)"
+//	@Success	200		{object}	statusResponse	"ok"
+//	@Failure	400		{object}	string	"Unknown or disabled refresh token transport"
+//	@Failure	401		{object}	string	"No refresh token provided"
+//	@Failure	403		{object}	string	"CSRF token missing or invalid"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/logout [post]
+func (h *Handler) Logout(c echo.Context) error {
+	inBody, err := h.refreshInBody(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	refreshToken, err := h.readRefreshToken(c, inBody) // Получение refreshToken из куки или тела запроса
+	if err != nil {
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	if err := h.services.Authorization.Logout(c.Request().Context(), refreshToken); err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	// Если передан access token, отзываем и его
+	if headerParts := strings.Split(c.Request().Header.Get(authorizationHeader), " "); len(headerParts) == 2 {
+		if err := h.services.Authorization.RevokeAccessToken(c.Request().Context(), headerParts[1]); err != nil {
+			logrus.Warnf("can't revoke access token on logout: %s", err.Error())
+		}
+	}
+
+	h.clearRefreshTokenCookie(c)
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
 	})
+}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"accessToken": accessToken,
+// LogoutAll godoc
+//
+//	@Summary	Logout from all sessions
+//	@Tags		auth
+//	@Produce	json
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	statusResponse	"ok"
+//	@Failure	401	{object}	string	"Unauthorized"
+//	@Failure	500	{object}	string	"Internal server error"
+//	@Router		/auth/logout-all [post]
+func (h *Handler) LogoutAll(c echo.Context) error {
+	userId, err := getUserId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	if err := h.services.Authorization.LogoutAll(c.Request().Context(), userId); err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	h.clearRefreshTokenCookie(c)
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// ForgotPassword godoc
+//
+//	@Summary	Request a password reset link
+//	@Description	Send a single-use password reset link to the email. The response does not reveal whether the email is registered.
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		input	body		entities.ForgotPasswordInput	true	"Email"
+//	@Success	200		{object}	statusResponse	"ok"
+//	@Failure	400		{object}	string	"Invalid input body"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/password/forgot [post]
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	var input entities.ForgotPasswordInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+
+	if err := input.ValidateForgotPasswordInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	if err := h.services.Authorization.ForgotPassword(c.Request().Context(), input.Email); err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// ResetPassword godoc
+//
+//	@Summary	Reset password
+//	@Description	Set a new password with the token from the reset email. All sessions of the user are revoked.
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		input	body		entities.ResetPasswordInput	true	"Reset token and new password"
+//	@Success	200		{object}	statusResponse	"ok"
+//	@Failure	400		{object}	passwordErrorResponse	"Invalid input body, invalid or expired token or password rejected by the policy"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/password/reset [post]
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var input entities.ResetPasswordInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+
+	if err := input.ValidateResetPasswordInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	if err := h.services.Authorization.ResetPassword(c.Request().Context(), input); err != nil {
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return newPasswordErrorResponse(c, policyErr) // Пароль не соответствует политике
+		}
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			return newErrorResponse(c, http.StatusBadRequest, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// VerifyEmail godoc
+//
+//	@Summary	Confirm email address
+//	@Description	Confirm the email address with the token from the verification email.
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		input	body		entities.VerifyEmailInput	true	"Verification token"
+//	@Success	200		{object}	statusResponse	"ok"
+//	@Failure	400		{object}	string	"Invalid input body or invalid or expired token"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/verify-email [post]
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	var input entities.VerifyEmailInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+
+	if err := input.ValidateVerifyEmailInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	if err := h.services.Authorization.VerifyEmail(c.Request().Context(), input.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			return newErrorResponse(c, http.StatusBadRequest, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// ResendEmailVerification godoc
+//
+//	@Summary	Resend email verification link
+//	@Description	Send a new verification link if the email is registered and not verified yet. The response does not reveal whether the email is registered.
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		input	body		entities.ResendVerificationInput	true	"Email"
+//	@Success	200		{object}	statusResponse	"ok"
+//	@Failure	400		{object}	string	"Invalid input body"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/verify-email/resend [post]
+func (h *Handler) ResendEmailVerification(c echo.Context) error {
+	var input entities.ResendVerificationInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+
+	if err := input.ValidateResendVerificationInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	if err := h.services.Authorization.ResendEmailVerification(c.Request().Context(), input.Email); err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
 	})
 }
+
+// RequestMagicLink godoc
+//
+//	@Summary	Request a sign-in link
+//	@Description	Send a single-use sign-in link to a verified email. The response does not reveal whether the email is registered.
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		input	body		entities.MagicLinkInput	true	"Email"
+//	@Success	200		{object}	statusResponse	"ok"
+//	@Failure	400		{object}	string	"Invalid input body"
+//	@Failure	404		{object}	string	"Sign-in by email link is disabled"
+//	@Failure	429		{object}	string	"Too many requests"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/magic-link [post]
+func (h *Handler) RequestMagicLink(c echo.Context) error {
+	var input entities.MagicLinkInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+
+	if err := input.ValidateMagicLinkInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	if err := h.services.Authorization.RequestMagicLink(c.Request().Context(), input.Email, c.RealIP()); err != nil {
+		return magicLinkErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// MagicLinkCallback godoc
+//
+//	@Summary	Confirm sign-in with a link from email
+//	@Description	Show a page that signs in with the token from the sign-in email after the user confirms. The link is not used up here, so mail scanners that open it don't spend it.
+//	@Tags		auth
+//	@Produce	html
+//	@Param		token	query		string	true	"Token from the email"
+//	@Success	200		{string}	string	"Confirmation page"
+//	@Failure	400		{object}	string	"Token is required"
+//	@Failure	404		{object}	string	"Sign-in by email link is disabled"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/magic-link/callback [get]
+func (h *Handler) MagicLinkCallback(c echo.Context) error {
+	if !h.cfg.MagicLink.Enabled {
+		return newErrorResponse(c, http.StatusNotFound, service.ErrMagicLinkDisabled.Error())
+	}
+	token := c.QueryParam("token")
+	if token == "" {
+		return newErrorResponse(c, http.StatusBadRequest, "token is required")
+	}
+
+	csrfToken, err := h.csrfFormToken(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+	return renderMagicLinkPage(c, token, csrfToken)
+}
+
+// MagicLinkSignIn godoc
+//
+//	@Summary	Sign in with a link from email
+//	@Description	Exchange the token from the sign-in email for tokens, like /auth/sign-in (including the two-factor step).
+//	@Description	A form submission must carry the CSRF token from the confirmation page in csrf_token or X-CSRF-Token.
+//	@Tags		auth
+//	@Accept		json,x-www-form-urlencoded
+//	@Produce	json
+//	@Param		input	body		entities.MagicLinkSignInInput	true	"Token from the email"
+//	@Success	200		{object}	map[string]interface{}
+//	@Failure	400		{object}	string	"Invalid or expired link"
+//	@Failure	403		{object}	string	"csrf token missing or invalid"
+//	@Failure	404		{object}	string	"Sign-in by email link is disabled"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/magic-link/callback [post]
+func (h *Handler) MagicLinkSignIn(c echo.Context) error {
+	var input entities.MagicLinkSignInInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+	// Форму браузер может отправить и с чужого сайта, чтобы войти под чужой учетной записью
+	if !isJSONRequest(c) && !h.validCSRFForm(c) && !h.validCSRFToken(c) && !h.trustedOrigin(c.Request()) {
+		return newErrorResponse(c, http.StatusForbidden, "csrf token missing or invalid")
+	}
+	if err := input.ValidateMagicLinkSignInInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "token is required")
+	}
+
+	result, err := h.services.Authorization.MagicLinkSignIn(c.Request().Context(), input.Token, clientInfo(c))
+	if err != nil {
+		return magicLinkErrorResponse(c, err)
+	}
+
+	return h.signInResultResponse(c, result, false)
+}
+
+// magicLinkErrorResponse преобразует ошибки входа по ссылке в HTTP-ответ
+func magicLinkErrorResponse(c echo.Context, err error) error {
+	var limited *service.RateLimitedError
+	if errors.As(err, &limited) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		return newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+	}
+	switch {
+	case errors.Is(err, service.ErrMagicLinkDisabled):
+		return newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidMagicLink):
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// clientInfo возвращает IP и User-Agent клиента для метаданных сессии
+func clientInfo(c echo.Context) entities.ClientInfo {
+	return entities.ClientInfo{
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+}
+
+// signInResultResponse отправляет результат входа: пару токенов
+// либо, если требуется код второго фактора, токен второго шага
+func (h *Handler) signInResultResponse(c echo.Context, result entities.SignInResult, inBody bool) error {
+	if result.ChallengeToken != "" {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"twoFactorRequired": true,
+			"challengeToken":    result.ChallengeToken,
+		})
+	}
+
+	return h.tokensResponse(c, result.AccessToken, result.RefreshToken, inBody)
+}
+
+// signInErrorResponse преобразует ошибку входа в HTTP-ответ
+func signInErrorResponse(c echo.Context, err error) error {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		// Сообщаем клиенту, через сколько секунд можно повторить попытку
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+	}
+	if errors.Is(err, service.ErrInvalidCredentials) ||
+		errors.Is(err, service.ErrInvalidTwoFactorCode) ||
+		errors.Is(err, service.ErrTwoFactorNotEnabled) {
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error()) // Неверный логин, пароль или код
+	}
+	if errors.Is(err, service.ErrEmailNotVerified) {
+		return newErrorResponse(c, http.StatusForbidden, err.Error()) // Адрес не подтвержден
+	}
+	if errors.Is(err, service.ErrScopeNotAllowed) {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Scope недоступен роли пользователя
+	}
+	return newErrorResponse(c, http.StatusInternalServerError, err.Error()) // Обработка ошибки
+}
@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	csrfCookieName = "csrfToken"    // Куки с CSRF-токеном, доступная JavaScript
+	csrfHeader     = "X-CSRF-Token" // Заголовок, в котором клиент повторяет значение куки
+	csrfFormField  = "csrf_token"   // Поле HTML-формы, в котором страница повторяет значение куки
+)
+
+// csrfProtection пропускает запрос с кукой refresh token, только если значение X-CSRF-Token совпадает
+// с кукой csrfToken (double submit) или Origin/Referer входит в auth.csrf.allowedOrigins
+func (h *Handler) csrfProtection(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Токен из тела запроса браузер не отправляет сам, подделать такой запрос нельзя
+		if inBody, err := h.refreshInBody(c); err == nil && inBody {
+			return next(c)
+		}
+
+		if h.validCSRFToken(c) || h.trustedOrigin(c.Request()) {
+			return next(c)
+		}
+		return newErrorResponse(c, http.StatusForbidden, "csrf token missing or invalid")
+	}
+}
+
+// validCSRFToken сравнивает CSRF-токен из заголовка с кукой
+func (h *Handler) validCSRFToken(c echo.Context) bool {
+	return h.matchesCSRFCookie(c, c.Request().Header.Get(csrfHeader))
+}
+
+// validCSRFForm сравнивает CSRF-токен из поля формы с кукой
+func (h *Handler) validCSRFForm(c echo.Context) bool {
+	return h.matchesCSRFCookie(c, c.FormValue(csrfFormField))
+}
+
+func (h *Handler) matchesCSRFCookie(c echo.Context, token string) bool {
+	if token == "" {
+		return false
+	}
+	cookie, err := c.Cookie(h.csrfCookieName())
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) == 1
+}
+
+// csrfFormToken возвращает CSRF-токен для HTML-формы: значение куки или, если ее нет, новый токен
+func (h *Handler) csrfFormToken(c echo.Context) (string, error) {
+	if cookie, err := c.Cookie(h.csrfCookieName()); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	return h.setCSRFCookie(c)
+}
+
+// trustedOrigin проверяет Origin запроса, а если его нет — Referer, по списку из конфигурации
+func (h *Handler) trustedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		referer, err := url.Parse(r.Header.Get("Referer"))
+		if err != nil || referer.Scheme == "" || referer.Host == "" {
+			return false
+		}
+		origin = referer.Scheme + "://" + referer.Host
+	}
+	return slices.Contains(h.cfg.CSRF.AllowedOrigins, strings.ToLower(origin))
+}
+
+// csrfCookieName возвращает имя куки с CSRF-токеном с учетом префикса __Host-
+func (h *Handler) csrfCookieName() string {
+	if h.cfg.RefreshToken.Cookie.HostPrefix {
+		return hostCookiePrefix + csrfCookieName
+	}
+	return csrfCookieName
+}
+
+// setCSRFCookie выдает новый CSRF-токен вместе с кукой refresh token и возвращает его
+func (h *Handler) setCSRFCookie(c echo.Context) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	cookie := h.newCSRFCookie(token)
+	cookie.MaxAge = int(service.RefreshTokenTTL.Seconds())
+	cookie.Expires = time.Now().Add(service.RefreshTokenTTL)
+	c.SetCookie(cookie)
+	return token, nil
+}
+
+// clearCSRFCookie удаляет куки с CSRF-токеном
+func (h *Handler) clearCSRFCookie(c echo.Context) {
+	cookie := h.newCSRFCookie("")
+	cookie.MaxAge = -1
+	c.SetCookie(cookie)
+}
+
+// newCSRFCookie собирает куки с CSRF-токеном. В отличие от refresh token она доступна JavaScript
+// и ставится на весь сайт, чтобы клиент мог прочитать ее и отправить в заголовке
+func (h *Handler) newCSRFCookie(value string) *http.Cookie {
+	cfg := h.cfg.RefreshToken.Cookie
+	return &http.Cookie{
+		Name:     h.csrfCookieName(),
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.Domain,
+		HttpOnly: false,
+		Secure:   cfg.Secure,
+		SameSite: sameSiteMode(cfg.SameSite),
+	}
+}
+
+// LegacyRefresh godoc
+//
+//	@Summary	Refresh access token (deprecated)
+//	@Description	Deprecated: use POST /auth/refresh. Available only with auth.csrf.legacyGetRefresh and not protected against CSRF.
+//	@Tags		auth
+//	@Produce	json
+//	@Success	200	{object}	map[string]interface{}
+//	@Failure	401	{object}	string	"No refresh token provided, invalid or reused refresh token"
+//	@Failure	500	{object}	string	"Internal server error"
+//	@Deprecated
+//	@Router		/auth/refresh [get]
+func (h *Handler) LegacyRefresh(c echo.Context) error {
+	c.Response().Header().Set("Deprecation", "true")
+	c.Response().Header().Set("Link", `</auth/refresh>; rel="successor-version"`)
+	logrus.Warnf("deprecated GET /auth/refresh called from %s", c.RealIP())
+	return h.Refresh(c)
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	cfg := &config.Auth{
+		CSRF:         config.CSRF{AllowedOrigins: []string{"https://app.example.com"}},
+		RefreshToken: config.RefreshToken{AllowBody: true},
+	}
+
+	tests := []struct {
+		name       string
+		csrfCookie string
+		csrfHeader string
+		origin     string
+		referer    string
+		inBody     bool
+		wantStatus int
+	}{
+		{name: "double submit", csrfCookie: "csrf", csrfHeader: "csrf", wantStatus: http.StatusOK},
+		{name: "header does not match cookie", csrfCookie: "csrf", csrfHeader: "other", wantStatus: http.StatusForbidden},
+		{name: "header without cookie", csrfHeader: "csrf", wantStatus: http.StatusForbidden},
+		{name: "cookie without header", csrfCookie: "csrf", wantStatus: http.StatusForbidden},
+		{name: "nothing", wantStatus: http.StatusForbidden},
+		{name: "trusted origin", origin: "https://app.example.com", wantStatus: http.StatusOK},
+		{name: "trusted origin in upper case", origin: "HTTPS://APP.EXAMPLE.COM", wantStatus: http.StatusOK},
+		{name: "untrusted origin", origin: "https://evil.example.com", wantStatus: http.StatusForbidden},
+		{name: "origin with another scheme", origin: "http://app.example.com", wantStatus: http.StatusForbidden},
+		{name: "untrusted origin with double submit", origin: "https://evil.example.com", csrfCookie: "csrf", csrfHeader: "csrf", wantStatus: http.StatusOK},
+		{name: "trusted referer", referer: "https://app.example.com/settings?tab=1", wantStatus: http.StatusOK},
+		{name: "untrusted referer", referer: "https://evil.example.com/app.example.com", wantStatus: http.StatusForbidden},
+		{name: "relative referer", referer: "/settings", wantStatus: http.StatusForbidden},
+		{name: "null origin with trusted referer", origin: "null", referer: "https://app.example.com/", wantStatus: http.StatusOK},
+		{name: "untrusted origin wins over referer", origin: "https://evil.example.com", referer: "https://app.example.com/", wantStatus: http.StatusForbidden},
+		{name: "token in body", inBody: true, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&service.Service{Authorization: &fakeAuthorization{}}, cfg)
+
+			body := ""
+			if tt.inBody {
+				body = `{"refreshToken":"refresh-token"}`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.inBody {
+				req.Header.Set(refreshTransportHeader, refreshTransportBody)
+			} else {
+				req.AddCookie(&http.Cookie{Name: defaultRefreshCookieName, Value: "refresh-token"})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(csrfHeader, tt.csrfHeader)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLegacyGetRefresh(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		wantStatus     int
+		wantDeprecated bool
+	}{
+		{name: "enabled", enabled: true, wantStatus: http.StatusOK, wantDeprecated: true},
+		{name: "disabled", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&service.Service{Authorization: &fakeAuthorization{}},
+				&config.Auth{CSRF: config.CSRF{LegacyGetRefresh: tt.enabled}})
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: defaultRefreshCookieName, Value: "refresh-token"})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if deprecated := rec.Header().Get("Deprecation") == "true"; deprecated != tt.wantDeprecated {
+				t.Errorf("Deprecation = %q, want deprecated %v", rec.Header().Get("Deprecation"), tt.wantDeprecated)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+// fakeAuthorization записывает вызовы сервиса. Методы, которые тестам не нужны,
+// достаются от пустого встроенного интерфейса и паникуют при вызове.
+type fakeAuthorization struct {
+	service.Authorization
+
+	magicLinkTokens []string                      // Токены, по которым выполнен вход
+	accessTokens    map[string]*auth.CustomClaims // Действующие access token
+	calls           []string                      // Вызванные действия с пользователями: "unlock 2"
+}
+
+func (s *fakeAuthorization) ValidateAccessToken(ctx context.Context, accessToken string) (*auth.CustomClaims, error) {
+	claims, ok := s.accessTokens[accessToken]
+	if !ok {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+func (s *fakeAuthorization) GetLoginLockState(ctx context.Context, userID int) (entities.LoginLockState, error) {
+	s.calls = append(s.calls, "lock state "+strconv.Itoa(userID))
+	return entities.LoginLockState{}, nil
+}
+
+func (s *fakeAuthorization) UnlockUser(ctx context.Context, userID int) error {
+	s.calls = append(s.calls, "unlock "+strconv.Itoa(userID))
+	return nil
+}
+
+func (s *fakeAuthorization) GetSessions(ctx context.Context, userID int) ([]entities.SessionInfo, error) {
+	s.calls = append(s.calls, "sessions "+strconv.Itoa(userID))
+	return []entities.SessionInfo{}, nil
+}
+
+func (s *fakeAuthorization) RevokeSession(ctx context.Context, userID int, sessionID int) error {
+	s.calls = append(s.calls, "revoke session "+strconv.Itoa(userID))
+	return nil
+}
+
+// Refresh принимает только refresh-token и выдает новую пару токенов
+func (s *fakeAuthorization) Refresh(ctx context.Context, refreshToken string, client entities.ClientInfo) (string, string, error) {
+	if refreshToken != "refresh-token" {
+		return "", "", service.ErrInvalidRefreshToken
+	}
+	return "access-token", "new-refresh-token", nil
+}
+
+func (s *fakeAuthorization) MagicLinkSignIn(ctx context.Context, token string, client entities.ClientInfo) (entities.SignInResult, error) {
+	s.magicLinkTokens = append(s.magicLinkTokens, token)
+	return entities.SignInResult{AccessToken: "access-token", RefreshToken: "refresh-token"}, nil
+}
+
+// fakeTwoFactor записывает сброс 2FA в вызовы fakeAuthorization
+type fakeTwoFactor struct {
+	service.TwoFactor
+
+	authorization *fakeAuthorization
+}
+
+func (s *fakeTwoFactor) Reset(ctx context.Context, userID int) error {
+	s.authorization.calls = append(s.authorization.calls, "reset 2fa "+strconv.Itoa(userID))
+	return nil
+}
+
+// fakeAPITokens знает один личный токен
+type fakeAPITokens struct {
+	service.APITokens
+
+	token     string
+	principal entities.APITokenPrincipal
+}
+
+func (s *fakeAPITokens) AuthenticateAPIToken(ctx context.Context, token string, clientIP string) (entities.APITokenPrincipal, error) {
+	if token != s.token {
+		return entities.APITokenPrincipal{}, service.ErrInvalidAPIToken
+	}
+	return s.principal, nil
+}
+
+// fakeRoles возвращает права ролей из карты
+type fakeRoles struct {
+	service.Roles
+
+	permissions map[string][]string
+}
+
+func (s *fakeRoles) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	return s.permissions[role], nil
+}
+
+// fakeUsers хранит организацию каждого пользователя и, как репозиторий, не видит пользователей
+// других организаций, если ctx ограничен организацией
+type fakeUsers struct {
+	service.Users
+
+	organizations map[int]int // ID пользователя — ID его организации
+}
+
+func (s *fakeUsers) GetUserByID(ctx context.Context, id int) (*bunEntities.User, error) {
+	organizationID, ok := s.organizations[id]
+	if !ok {
+		return nil, service.ErrUserNotFound
+	}
+	if scoped, ok := repository.OrganizationFromContext(ctx); ok && scoped != organizationID {
+		return nil, service.ErrUserNotFound
+	}
+	return &bunEntities.User{ID: id}, nil
+}
+
+// newTestRouter собирает маршруты с фейковыми сервисами
+func newTestRouter(services *service.Service, cfg *config.Auth) http.Handler {
+	return NewHandler(services, cfg, nil).InitRouter()
+}
@@ -1,13 +1,20 @@
 package v1
 
-import "github.com/kolibriee/users-rest-api/internal/service"
+import (
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/service"
+)
 
 type Handler struct {
 	services *service.Service
+	cfg      *config.Auth   // Настройки передачи refresh token и защиты от CSRF
+	server   *config.Server // Доверенные прокси для определения IP клиента
 }
 
-func NewHandler(services *service.Service) *Handler {
+func NewHandler(services *service.Service, cfg *config.Auth, server *config.Server) *Handler {
 	return &Handler{
 		services: services,
+		cfg:      cfg,
+		server:   server,
 	}
 }
@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// Impersonate godoc
+//
+//	@Summary		Impersonate a user
+//	@Description	Issue a short-lived access token for acting as the user (requires the users:impersonate permission). The token has an act claim with the admin,
+//	@Description	can't change the password, role or 2FA or issue other tokens, and every request made with it is written to the audit trail.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int							true	"User ID"
+//	@Param			input	body		entities.ImpersonationInput	true	"Reason for the audit trail"
+//	@Success		200		{object}	entities.ImpersonationToken
+//	@Failure		400		{object}	statusResponse	"invalid user id, invalid input or impersonating yourself"
+//	@Failure		403		{object}	statusResponse	"users with administrative permissions can't be impersonated or request made with an API token"
+//	@Failure		404		{object}	statusResponse	"user not found"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/admin/users/{id}/impersonate [post]
+func (h *Handler) Impersonate(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	adminId, err := getUserId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+	// В журнале должен быть конкретный администратор, а не ключ сервиса
+	if isAPITokenRequest(c) {
+		return newErrorResponse(c, http.StatusForbidden, "impersonation requires an admin access token")
+	}
+
+	var input entities.ImpersonationInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+	if err := input.ValidateImpersonationInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	token, err := h.services.Impersonation.Impersonate(c.Request().Context(), adminId, userId, input, c.RealIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImpersonationUserNotFound):
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrImpersonateSelf):
+			return newErrorResponse(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrImpersonateAdmin):
+			return newErrorResponse(c, http.StatusForbidden, err.Error())
+		default:
+			return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, token)
+}
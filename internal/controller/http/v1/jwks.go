@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// JWKS godoc
+//
+//	@Summary		Get token signing public keys
+//	@Description	Public keys (JWK Set) used to verify access tokens, selected by the kid header
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	auth.JWKSet
+//	@Router			/.well-known/jwks.json [get]
+func (h *Handler) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, auth.PublicJWKS())
+}
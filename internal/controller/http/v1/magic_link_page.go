@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// magicLinkPage — страница подтверждения входа по ссылке. Токен тратится только после отправки формы:
+// почтовые сканеры, которые открывают ссылки заранее, формы не отправляют
+var magicLinkPage = template.Must(template.New("magic-link").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Sign in</title>
+</head>
+<body>
+<h1>Sign in to your account</h1>
+<form method="post" action="/auth/magic-link/callback">
+<input type="hidden" name="token" value="{{.Token}}">
+<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+// renderMagicLinkPage отправляет страницу подтверждения; кэшировать ее и встраивать в чужие страницы запрещено
+func renderMagicLinkPage(c echo.Context, token string, csrfToken string) error {
+	var page bytes.Buffer
+	if err := magicLinkPage.Execute(&page, struct {
+		Token     string
+		CSRFToken string
+	}{token, csrfToken}); err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("X-Frame-Options", "DENY")
+	header.Set("Content-Security-Policy", "frame-ancestors 'none'")
+	return c.HTMLBlob(http.StatusOK, page.Bytes())
+}
+
+// isJSONRequest сообщает, передано ли тело запроса в JSON. Такой запрос с чужого сайта
+// браузер без разрешения CORS не отправит
+func isJSONRequest(c echo.Context) bool {
+	return strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMagicLinkCallback(t *testing.T) {
+	const token = "link-token"
+	form := url.Values{"token": {token}, "csrf_token": {"csrf"}}.Encode()
+
+	tests := []struct {
+		name         string
+		disabled     bool
+		method       string
+		target       string
+		contentType  string
+		body         string
+		csrfCookie   string
+		wantStatus   int
+		wantConsumed bool
+	}{
+		{name: "confirmation page", method: http.MethodGet, target: "/auth/magic-link/callback?token=" + token, wantStatus: http.StatusOK},
+		{name: "confirmation page without token", method: http.MethodGet, target: "/auth/magic-link/callback", wantStatus: http.StatusBadRequest},
+		{name: "confirmation page when disabled", disabled: true, method: http.MethodGet, target: "/auth/magic-link/callback?token=" + token, wantStatus: http.StatusNotFound},
+		{name: "form with csrf token", method: http.MethodPost, contentType: echo.MIMEApplicationForm, body: form, csrfCookie: "csrf", wantStatus: http.StatusOK, wantConsumed: true},
+		{name: "form without csrf cookie", method: http.MethodPost, contentType: echo.MIMEApplicationForm, body: form, wantStatus: http.StatusForbidden},
+		{name: "form with another csrf token", method: http.MethodPost, contentType: echo.MIMEApplicationForm, body: form, csrfCookie: "other", wantStatus: http.StatusForbidden},
+		{name: "json", method: http.MethodPost, contentType: echo.MIMEApplicationJSON, body: `{"token":"` + token + `"}`, wantStatus: http.StatusOK, wantConsumed: true},
+		{name: "json without token", method: http.MethodPost, contentType: echo.MIMEApplicationJSON, body: `{}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorization := &fakeAuthorization{}
+			router := newTestRouter(&service.Service{Authorization: authorization}, &config.Auth{
+				MagicLink: config.MagicLink{Enabled: !tt.disabled},
+			})
+
+			target := tt.target
+			if target == "" {
+				target = "/auth/magic-link/callback"
+			}
+			req := httptest.NewRequest(tt.method, target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(echo.HeaderContentType, tt.contentType)
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: tt.csrfCookie})
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if consumed := slices.Contains(authorization.magicLinkTokens, token); consumed != tt.wantConsumed {
+				t.Errorf("link used = %v, want %v", consumed, tt.wantConsumed)
+			}
+			// Страница подтверждения отправляет токен формой вместе с CSRF-токеном из куки
+			if tt.method == http.MethodGet && rec.Code == http.StatusOK {
+				body := rec.Body.String()
+				if !strings.Contains(body, `name="token" value="`+token+`"`) || !strings.Contains(body, `method="post"`) {
+					t.Errorf("page has no form with the token: %s", body)
+				}
+				if rec.Header().Get("Cache-Control") != "no-store" {
+					t.Errorf("Cache-Control = %q, want no-store", rec.Header().Get("Cache-Control"))
+				}
+				cookies := rec.Result().Cookies()
+				if len(cookies) != 1 || cookies[0].Name != csrfCookieName || !strings.Contains(body, `value="`+cookies[0].Value+`"`) {
+					t.Errorf("page csrf token doesn't match cookies %+v", cookies)
+				}
+			}
+		})
+	}
+}
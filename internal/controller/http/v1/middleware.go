@@ -3,10 +3,16 @@ package v1
 import (
 	"errors"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
 	"github.com/kolibriee/users-rest-api/pkg/auth"
 	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -14,6 +20,14 @@ const (
 	userCtx             = "userId"
 	adminCtx            = "adminId"
 	roleCtx             = "role"
+	apiTokenCtx         = "apiTokenId"
+	authTimeCtx         = "authTime"
+	clientCtx           = "clientId"
+	permissionsCtx      = "permissions"
+	organizationCtx     = "organizationId"
+	organizationRoleCtx = "organizationRole" // Права запроса дает роль участника организации
+	scopesCtx           = "scopes"           // Scope токена; без значения токен не ограничен
+	sessionCookieCtx    = "sessionCookie"    // Пользователь определен по куке refresh token, запросу нужна защита от CSRF
 )
 
 // UserIdentity middleware для проверки идентификации пользователя
@@ -27,13 +41,57 @@ func (h *Handler) userIdentity(next echo.HandlerFunc) echo.HandlerFunc {
 		if len(headerParts) != 2 {
 			return newErrorResponse(c, http.StatusUnauthorized, "invalid auth header") // Проверка формата заголовка
 		}
-		userId, role, err := auth.ParseToken(headerParts[1]) // Парсинг токена
+		if service.IsAPIToken(headerParts[1]) {
+			return h.apiTokenIdentity(c, headerParts[1], false, next) // Личный токен или ключ сервиса
+		}
+		principal, claims, err := h.parseAccessToken(c, headerParts[1]) // Парсинг и проверка отзыва токена
 		if err != nil {
 			return newErrorResponse(c, http.StatusUnauthorized, err.Error()) // Обработка ошибки парсинга
 		}
-		c.Set(userCtx, userId) // Установка идентификатора пользователя в контекст
-		c.Set(roleCtx, role)   // Установка роли в контекст
-		return next(c)         // Передача управления следующему обработчику
+		if claims.IsServicePrincipal() {
+			return newErrorResponse(c, http.StatusForbidden, "service clients can only call the admin API") // У сервисного клиента нет пользователя
+		}
+		userId := principal.UserID
+		c.Set(userCtx, userId)      // Установка идентификатора пользователя в контекст
+		c.Set(roleCtx, claims.Role) // Установка роли в контекст
+		if claims.Scope != "" {
+			c.Set(scopesCtx, strings.Fields(claims.Scope)) // Токен ограничен scope
+		}
+		if claims.AuthTime > 0 {
+			c.Set(authTimeCtx, time.Unix(claims.AuthTime, 0)) // Время последнего подтверждения пароля
+		}
+		if claims.Organization != 0 {
+			setOrganization(c, claims.Organization) // Запросы к пользователям ограничиваются активной организацией из токена
+		}
+		if actorId, ok := claims.ActorID(); ok {
+			return h.impersonatedRequest(c, userId, actorId, claims.Id, next) // Администратор действует от имени пользователя
+		}
+		return next(c) // Передача управления следующему обработчику
+	}
+}
+
+// browserIdentity определяет пользователя по access token, а без заголовка авторизации — по куке
+// refresh token браузера. Запрос без действующей сессии передается дальше без пользователя
+func (h *Handler) browserIdentity(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(authorizationHeader) != "" {
+			return h.userIdentity(next)(c)
+		}
+		cookie, err := c.Cookie(h.refreshCookieName())
+		if err != nil || cookie.Value == "" {
+			return next(c)
+		}
+		userId, role, err := h.services.Authorization.SessionUser(c.Request().Context(), cookie.Value)
+		if err != nil {
+			if !errors.Is(err, service.ErrInvalidRefreshToken) {
+				return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			}
+			return next(c)
+		}
+		c.Set(userCtx, userId)
+		c.Set(roleCtx, role)
+		c.Set(sessionCookieCtx, true)
+		return next(c)
 	}
 }
 
@@ -48,19 +106,378 @@ func (h *Handler) adminIdentity(next echo.HandlerFunc) echo.HandlerFunc {
 		if len(headerParts) != 2 {
 			return newErrorResponse(c, http.StatusUnauthorized, "invalid auth header") // Проверка формата заголовка
 		}
-		userId, role, err := auth.ParseToken(headerParts[1]) // Парсинг токена
+		if service.IsAPIToken(headerParts[1]) {
+			return h.apiTokenIdentity(c, headerParts[1], true, next) // Личный токен или ключ сервиса
+		}
+		principal, claims, err := h.parseAccessToken(c, headerParts[1]) // Парсинг и проверка отзыва токена
 		if err != nil {
 			return newErrorResponse(c, http.StatusUnauthorized, err.Error()) // Обработка ошибки парсинга
 		}
-		if role != "admin" { // Проверка роли
-			return newErrorResponse(c, http.StatusForbidden, "access denied") // Отказ в доступе
+		if claims.IsServicePrincipal() {
+			return h.serviceClientIdentity(c, principal.ClientID, claims.Scope, next) // Сервисный клиент (client_credentials)
+		}
+		userId := principal.UserID
+		role := claims.Role
+		if actorId, ok := claims.ActorID(); ok {
+			// Токен имперсонации не дает доступа к маршрутам администратора, но попытка попадает в журнал
+			c.Set(userCtx, userId)
+			c.Set(roleCtx, role)
+			return h.impersonatedRequest(c, userId, actorId, claims.Id, denyImpersonated)
 		}
 		c.Set(userCtx, userId) // Установка идентификатора пользователя в контекст
 		c.Set(roleCtx, role)   // Установка роли в контекст
-		return next(c)         // Передача управления следующему обработчику
+		if claims.Scope != "" {
+			c.Set(scopesCtx, strings.Fields(claims.Scope)) // Токен ограничен scope
+		}
+		if err := h.setAdminOrganization(c, claims.Organization); err != nil {
+			return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		return h.administrativeIdentity(c, next) // Проверка, что у роли есть права администратора
+	}
+}
+
+// setAdminOrganization ограничивает запрос администратора активной организацией (0 — не выбрана).
+// Роль с правом organizations:read видит все организации, поэтому ее запросы не ограничиваются
+func (h *Handler) setAdminOrganization(c echo.Context, organizationId int) error {
+	if organizationId == 0 {
+		return nil
+	}
+	permissions, err := h.permissions(c)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(permissions, entities.PermissionOrganizationsRead) {
+		setOrganization(c, organizationId) // Администратор видит только пользователей активной организации
+	}
+	return nil
+}
+
+// setOrganization ограничивает запрос активной организацией: ее ID попадает в контекст echo,
+// а запросы к пользователям с ctx запроса видят только ее участников
+func setOrganization(c echo.Context, organizationId int) {
+	c.Set(organizationCtx, organizationId)
+	c.SetRequest(c.Request().WithContext(service.WithOrganization(c.Request().Context(), organizationId)))
+}
+
+// parseAccessToken проверяет access token с учетом списка отозванных jti и версии токенов пользователя
+// и возвращает владельца токена: пользователя или сервисного клиента
+func (h *Handler) parseAccessToken(c echo.Context, accessToken string) (auth.Principal, *auth.CustomClaims, error) {
+	claims, err := h.services.Authorization.ValidateAccessToken(c.Request().Context(), accessToken)
+	if err != nil {
+		return auth.Principal{}, nil, err
+	}
+	principal, err := claims.Principal()
+	if err != nil {
+		return auth.Principal{}, nil, err
+	}
+	return principal, claims, nil
+}
+
+// serviceClientIdentity пускает сервисного клиента к маршрутам администратора с правами роли из его регистрации.
+// Токен сервисного клиента всегда ограничен своими scope: маршруты /admin требуют admin:read / admin:write
+// или admin вместе с read / write
+func (h *Handler) serviceClientIdentity(c echo.Context, clientId string, scope string, next echo.HandlerFunc) error {
+	role, err := h.services.OAuth.ServiceClientRole(c.Request().Context(), clientId)
+	if err != nil {
+		if errors.Is(err, service.ErrOAuthClientNotFound) {
+			return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+	c.Set(scopesCtx, strings.Fields(scope))
+	c.Set(clientCtx, clientId)
+	if role == "" {
+		c.Set(permissionsCtx, []string{}) // Клиент зарегистрирован без роли и прав не имеет
+	} else {
+		c.Set(roleCtx, role)
+	}
+	return next(c)
+}
+
+// administrativeIdentity пускает к маршрутам администратора только роли, у которых есть хотя бы одно право.
+// Конкретное право для маршрута проверяет requirePermission
+func (h *Handler) administrativeIdentity(c echo.Context, next echo.HandlerFunc) error {
+	permissions, err := h.permissions(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+	if len(permissions) == 0 {
+		return newErrorResponse(c, http.StatusForbidden, "access denied") // Отказ в доступе
+	}
+	return next(c)
+}
+
+// organizationIdentity middleware пускает к маршрутам администратора организации только участников
+// активной организации. Права на этих маршрутах дает роль участника в организации, а не глобальная роль
+func (h *Handler) organizationIdentity(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		organizationId, err := getOrganizationId(c)
+		if err != nil {
+			return newErrorResponse(c, http.StatusForbidden, "no active organization") // Токен выдан без организации
+		}
+		userId, err := getUserId(c)
+		if err != nil {
+			return newErrorResponse(c, http.StatusForbidden, "access denied")
+		}
+		role, err := h.services.Organizations.GetMembershipRole(c.Request().Context(), organizationId, userId)
+		if err != nil {
+			if errors.Is(err, service.ErrMemberNotFound) {
+				return newErrorResponse(c, http.StatusForbidden, err.Error())
+			}
+			return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		c.Set(roleCtx, role)
+		c.Set(organizationRoleCtx, true)
+		c.Set(permissionsCtx, nil) // Права пересчитываются по роли участника
+		return next(c)
+	}
+}
+
+// requirePermission middleware пропускает запрос, только если у роли есть указанное право
+func (h *Handler) requirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			permissions, err := h.permissions(c)
+			if err != nil {
+				return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			}
+			if !slices.Contains(permissions, permission) {
+				return newErrorResponse(c, http.StatusForbidden, "missing permission "+permission)
+			}
+			return next(c)
+		}
+	}
+}
+
+// requireVisibleUser middleware пропускает запрос администратора к пользователю из параметра id, только если
+// пользователь существует и виден запросу: с активной организацией видны только ее участники
+func (h *Handler) requireVisibleUser(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+		}
+		if _, err := h.services.Users.GetUserByID(c.Request().Context(), userId); err != nil {
+			if errors.Is(err, service.ErrUserNotFound) {
+				return newErrorResponse(c, http.StatusNotFound, err.Error()) // Пользователь другой организации не раскрывается
+			}
+			return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get user; ").Error()+err.Error())
+		}
+		return next(c)
+	}
+}
+
+// permissions возвращает права роли текущего запроса; они запрашиваются один раз за запрос
+func (h *Handler) permissions(c echo.Context) ([]string, error) {
+	if permissions, ok := c.Get(permissionsCtx).([]string); ok {
+		return permissions, nil
+	}
+	role, err := getRole(c)
+	if err != nil {
+		return nil, nil // Без роли прав нет
+	}
+	permissions, err := h.services.Roles.GetRolePermissions(c.Request().Context(), role)
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+	c.Set(permissionsCtx, permissions)
+	return permissions, nil
+}
+
+// hasPermission сообщает, есть ли у роли текущего запроса указанное право.
+// Ошибка получения прав записывается в журнал и считается отсутствием права
+func (h *Handler) hasPermission(c echo.Context, permission string) bool {
+	permissions, err := h.permissions(c)
+	if err != nil {
+		logrus.Errorf("can't get permissions: %s", err.Error())
+		return false
+	}
+	return slices.Contains(permissions, permission)
+}
+
+// canAccessUser проверяет, что запрос выполняет сам пользователь или роль с указанным правом
+func (h *Handler) canAccessUser(c echo.Context, userId int, permission string) bool {
+	if currentUserId, err := getUserId(c); err == nil && currentUserId == userId && !isServiceClientRequest(c) {
+		return true
+	}
+	return h.hasPermission(c, permission)
+}
+
+// accessSubject собирает атрибуты текущего запроса для политик доступа к пользователям
+func (h *Handler) accessSubject(c echo.Context) (entities.AccessSubject, error) {
+	permissions, err := h.permissions(c)
+	if err != nil {
+		return entities.AccessSubject{}, err
+	}
+	subject := entities.AccessSubject{Permissions: permissions}
+	if !isServiceClientRequest(c) {
+		subject.UserID, _ = getUserId(c)
+	}
+	subject.Role, _ = getRole(c)
+	subject.OrganizationID, _ = getOrganizationId(c)
+	return subject, nil
+}
+
+// authorizeUser проверяет доступ к пользователю по своей учетной записи, правам роли и политикам доступа
+func (h *Handler) authorizeUser(c echo.Context, userId int, action string) error {
+	subject, err := h.accessSubject(c)
+	if err != nil {
+		return err
+	}
+	return h.services.Users.AuthorizeUser(c.Request().Context(), subject, action, userId)
+}
+
+// accessErrorResponse преобразует ошибку проверки доступа к пользователю в HTTP-ответ
+func accessErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAccessDenied):
+		return newErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		return newErrorResponse(c, http.StatusNotFound, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't check access; ").Error()+err.Error())
+	}
+}
+
+// impersonatedRequest выполняет запрос администратора от имени пользователя и записывает его
+// в журнал безопасности вместе со статусом ответа
+func (h *Handler) impersonatedRequest(c echo.Context, userId int, actorId int, tokenId string, next echo.HandlerFunc) error {
+	c.Set(adminCtx, actorId) // Установка идентификатора администратора в контекст
+	err := next(c)
+
+	status := c.Response().Status
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		status = httpErr.Code
+	}
+	if recordErr := h.services.Impersonation.RecordImpersonatedRequest(c.Request().Context(), entities.ImpersonatedRequest{
+		UserID:  userId,
+		ActorID: actorId,
+		TokenID: tokenId,
+		Method:  c.Request().Method,
+		Path:    c.Request().URL.Path,
+		Status:  status,
+		IP:      c.RealIP(),
+	}); recordErr != nil {
+		logrus.Errorf("can't record impersonated request of admin %d as user %d: %s", actorId, userId, recordErr.Error())
+	}
+	return err
+}
+
+// forbidImpersonation middleware запрещает действие при работе администратора от имени пользователя
+func (h *Handler) forbidImpersonation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if isImpersonated(c) {
+			return denyImpersonated(c)
+		}
+		return next(c)
+	}
+}
+
+// denyImpersonated отвечает отказом на действие, недоступное токену имперсонации
+func denyImpersonated(c echo.Context) error {
+	return newErrorResponse(c, http.StatusForbidden, "not allowed while impersonating a user")
+}
+
+// isImpersonated сообщает, выполняет ли запрос администратор от имени пользователя
+func isImpersonated(c echo.Context) bool {
+	_, err := getAdminId(c)
+	return err == nil
+}
+
+// apiTokenIdentity проверяет API-токен; его scope проверяют маршруты.
+// На маршрутах администратора токен действует с правами роли владельца, только если у него есть scope для /admin.
+// Запросы токена ограничены первой организацией владельца так же, как access token после входа
+func (h *Handler) apiTokenIdentity(c echo.Context, token string, adminOnly bool, next echo.HandlerFunc) error {
+	principal, err := h.services.APITokens.AuthenticateAPIToken(c.Request().Context(), token, c.RealIP())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIToken) {
+			return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+	c.Set(userCtx, principal.UserID)
+	c.Set(roleCtx, principal.Role)
+	c.Set(apiTokenCtx, principal.TokenID)
+	c.Set(scopesCtx, principal.Scopes)
+	if adminOnly {
+		if principal.OrganizationID != nil {
+			if err := h.setAdminOrganization(c, *principal.OrganizationID); err != nil {
+				return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			}
+		}
+		return h.administrativeIdentity(c, next)
+	}
+	if principal.OrganizationID != nil {
+		setOrganization(c, *principal.OrganizationID) // Как у access token владельца
+	}
+	return next(c)
+}
+
+// requireScope middleware пропускает запрос, если токен не ограничен scope или его scope разрешают маршрут
+func (h *Handler) requireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !hasScope(c, scope) {
+				return newErrorResponse(c, http.StatusForbidden, "insufficient token scope: "+scope+" required")
+			}
+			return next(c)
+		}
 	}
 }
 
+// requireMethodScope middleware требует scope read для чтения и scope write для изменяющих запросов
+func (h *Handler) requireMethodScope(read string, write string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			scope := write
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				scope = read
+			}
+			return h.requireScope(scope)(next)(c)
+		}
+	}
+}
+
+// forbidScopedToken middleware запрещает действие токену, ограниченному scope:
+// иначе через него можно получить токен с более широкими правами
+func (h *Handler) forbidScopedToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := getScopes(c); ok {
+			return newErrorResponse(c, http.StatusForbidden, "not allowed for a token limited by scope")
+		}
+		return next(c)
+	}
+}
+
+// hasScope сообщает, разрешают ли scope токена действие; токен без scope разрешает все
+func hasScope(c echo.Context, scope string) bool {
+	scopes, ok := getScopes(c)
+	return !ok || entities.ScopeAllows(scopes, scope)
+}
+
+// getScopes извлекает из контекста scope токена; ok = false, если токен не ограничен
+func getScopes(c echo.Context) ([]string, bool) {
+	scopes, ok := c.Get(scopesCtx).([]string)
+	return scopes, ok
+}
+
+// isAPITokenRequest сообщает, выполнен ли запрос с API-токеном вместо access token
+func isAPITokenRequest(c echo.Context) bool {
+	_, ok := c.Get(apiTokenCtx).(int)
+	return ok
+}
+
+// isServiceClientRequest сообщает, выполнен ли запрос сервисным клиентом от своего имени
+func isServiceClientRequest(c echo.Context) bool {
+	_, ok := c.Get(clientCtx).(string)
+	return ok
+}
+
 // getUserId извлекает userId из контекста
 func getUserId(c echo.Context) (int, error) {
 	id, ok := c.Get(userCtx).(int) // Извлечение идентификатора пользователя
@@ -70,7 +487,16 @@ func getUserId(c echo.Context) (int, error) {
 	return id, nil
 }
 
-// getAdminId извлекает adminId из контекста
+// getOrganizationId извлекает из контекста активную организацию пользователя
+func getOrganizationId(c echo.Context) (int, error) {
+	id, ok := c.Get(organizationCtx).(int)
+	if !ok {
+		return 0, errors.New("organization id not found")
+	}
+	return id, nil
+}
+
+// getAdminId извлекает из контекста ID администратора, действующего от имени пользователя
 func getAdminId(c echo.Context) (int, error) {
 	id, ok := c.Get(adminCtx).(int) // Извлечение идентификатора администратора
 	if !ok {
@@ -79,6 +505,12 @@ func getAdminId(c echo.Context) (int, error) {
 	return id, nil
 }
 
+// getAuthTime извлекает из контекста время последнего подтверждения пароля; нулевое, если его нет
+func getAuthTime(c echo.Context) time.Time {
+	authTime, _ := c.Get(authTimeCtx).(time.Time)
+	return authTime
+}
+
 // getRole извлекает role из контекста
 func getRole(c echo.Context) (string, error) {
 	role, ok := c.Get(roleCtx).(string) // Извлечение роли
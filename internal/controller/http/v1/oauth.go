@@ -0,0 +1,285 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// Authorize godoc
+//
+//	@Summary		OAuth2 authorization endpoint
+//	@Description	Start the authorization code flow with PKCE S256. The user is identified by a Bearer access token or, in a browser, by the refresh token cookie.
+//	@Description	Without a session the browser is redirected to auth.oauth.loginUrl with return_to, or 401 is returned.
+//	@Description	Returns the consent details as JSON, or a consent page for a browser session; the decision is sent to POST /oauth/authorize.
+//	@Tags			oauth
+//	@Produce		json,html
+//	@Security		ApiKeyAuth
+//	@Param			response_type			query		string	true	"Must be code"
+//	@Param			client_id				query		string	true	"Client ID"
+//	@Param			redirect_uri			query		string	false	"Registered redirect URI"
+//	@Param			scope					query		string	false	"Space-separated scopes, all client scopes by default"
+//	@Param			state					query		string	false	"Opaque value returned to the client"
+//	@Param			code_challenge			query		string	true	"PKCE code challenge"
+//	@Param			code_challenge_method	query		string	true	"Must be S256"
+//	@Success		200						{object}	entities.OAuthConsent
+//	@Success		302						"redirect to the login page or to the client with an error"
+//	@Failure		400						{object}	oauthErrorResponse	"invalid client or redirect_uri"
+//	@Failure		401						{object}	statusResponse		"login required"
+//	@Failure		500						{object}	oauthErrorResponse	"server error"
+//	@Router			/oauth/authorize [get]
+func (h *Handler) Authorize(c echo.Context) error {
+	var request entities.OAuthAuthorizeRequest
+	if err := c.Bind(&request); err != nil {
+		return newOAuthErrorResponse(c, http.StatusBadRequest, service.OAuthInvalidRequest, "invalid request")
+	}
+	if _, err := getUserId(c); err != nil {
+		return h.authorizationLogin(c)
+	}
+
+	consent, errorRedirect, err := h.services.OAuth.Consent(c.Request().Context(), request)
+	if err != nil {
+		return oauthErrorResponseFor(c, err)
+	}
+	if errorRedirect != "" {
+		return authorizationRedirect(c, errorRedirect)
+	}
+
+	// Страница согласия нужна браузеру с кукой сессии, остальным клиентам достаточно JSON
+	if !isSessionCookieRequest(c) || acceptsJSON(c) {
+		return c.JSON(http.StatusOK, consent)
+	}
+	csrfToken, err := h.csrfFormToken(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+	return renderConsentPage(c, consent, request, csrfToken)
+}
+
+// AuthorizeConsent godoc
+//
+//	@Summary		OAuth2 authorization consent
+//	@Description	Approve or deny the authorization request and redirect to the client with a code or an access_denied error. With "Accept: application/json" the redirect URI is returned in the body instead.
+//	@Description	Requests authenticated by the refresh token cookie must carry the CSRF token in csrf_token or X-CSRF-Token.
+//	@Tags			oauth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			consent					formData	string	true	"approve or deny"
+//	@Param			response_type			formData	string	true	"Must be code"
+//	@Param			client_id				formData	string	true	"Client ID"
+//	@Param			redirect_uri			formData	string	false	"Registered redirect URI"
+//	@Param			scope					formData	string	false	"Space-separated scopes, all client scopes by default"
+//	@Param			state					formData	string	false	"Opaque value returned to the client"
+//	@Param			code_challenge			formData	string	true	"PKCE code challenge"
+//	@Param			code_challenge_method	formData	string	true	"Must be S256"
+//	@Param			csrf_token				formData	string	false	"CSRF token for the refresh token cookie session"
+//	@Success		200						{object}	entities.OAuthAuthorizeResponse
+//	@Success		302						"redirect to the client with code or error"
+//	@Failure		400						{object}	oauthErrorResponse	"invalid request, client or redirect_uri"
+//	@Failure		401						{object}	statusResponse		"unauthorized"
+//	@Failure		403						{object}	statusResponse		"csrf token missing or invalid"
+//	@Failure		500						{object}	oauthErrorResponse	"server error"
+//	@Router			/oauth/authorize [post]
+func (h *Handler) AuthorizeConsent(c echo.Context) error {
+	userId, err := getUserId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusUnauthorized, errors.New("unauthorized").Error())
+	}
+	// Форму с кукой сессии браузер может отправить и с чужого сайта
+	if isSessionCookieRequest(c) && !h.validCSRFToken(c) && !h.validCSRFForm(c) && !h.trustedOrigin(c.Request()) {
+		return newErrorResponse(c, http.StatusForbidden, "csrf token missing or invalid")
+	}
+
+	var request entities.OAuthAuthorizeRequest
+	if err := c.Bind(&request); err != nil {
+		return newOAuthErrorResponse(c, http.StatusBadRequest, service.OAuthInvalidRequest, "invalid request")
+	}
+	if request.Consent != entities.OAuthConsentApprove && request.Consent != entities.OAuthConsentDeny {
+		return newOAuthErrorResponse(c, http.StatusBadRequest, service.OAuthInvalidRequest, "consent must be approve or deny")
+	}
+
+	redirectURI, err := h.services.OAuth.Authorize(c.Request().Context(), userId, request, request.Consent == entities.OAuthConsentApprove)
+	if err != nil {
+		return oauthErrorResponseFor(c, err)
+	}
+	return authorizationRedirect(c, redirectURI)
+}
+
+// authorizationLogin отправляет браузер без сессии на страницу входа, которая вернет его по return_to
+func (h *Handler) authorizationLogin(c echo.Context) error {
+	if h.cfg.OAuth.LoginURL == "" || acceptsJSON(c) {
+		return newErrorResponse(c, http.StatusUnauthorized, "login required")
+	}
+	loginURL, err := url.Parse(h.cfg.OAuth.LoginURL)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, "invalid oauth login url")
+	}
+	query := loginURL.Query()
+	query.Set("return_to", c.Request().URL.RequestURI())
+	loginURL.RawQuery = query.Encode()
+	return c.Redirect(http.StatusFound, loginURL.String())
+}
+
+// authorizationRedirect перенаправляет браузер к клиенту. SPA не может прочитать Location ответа 302,
+// поэтому по запросу адрес возвращается в JSON
+func authorizationRedirect(c echo.Context, redirectURI string) error {
+	if acceptsJSON(c) {
+		return c.JSON(http.StatusOK, entities.OAuthAuthorizeResponse{
+			RedirectURI: redirectURI,
+		})
+	}
+	return c.Redirect(http.StatusFound, redirectURI)
+}
+
+// acceptsJSON сообщает, просит ли клиент ответ в JSON
+func acceptsJSON(c echo.Context) bool {
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON)
+}
+
+// isSessionCookieRequest сообщает, определен ли пользователь по куке refresh token
+func isSessionCookieRequest(c echo.Context) bool {
+	fromCookie, _ := c.Get(sessionCookieCtx).(bool)
+	return fromCookie
+}
+
+// Token godoc
+//
+//	@Summary		OAuth2 token endpoint
+//	@Description	Exchange an authorization code (with PKCE verifier) or a refresh token for tokens, or issue a service client an access token with its client_id as subject (client_credentials).
+//	@Description	Confidential clients authenticate with HTTP Basic or client_secret, clients registered with a public key with a signed JWT assertion (private_key_jwt).
+//	@Tags			oauth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			grant_type				formData	string	true	"authorization_code, refresh_token or client_credentials"
+//	@Param			code					formData	string	false	"Authorization code"
+//	@Param			redirect_uri			formData	string	false	"Redirect URI used in the authorization request"
+//	@Param			code_verifier			formData	string	false	"PKCE code verifier"
+//	@Param			refresh_token			formData	string	false	"Refresh token"
+//	@Param			scope					formData	string	false	"Narrower scope for the refresh_token grant or scope for the client_credentials grant"
+//	@Param			client_id				formData	string	false	"Client ID (if HTTP Basic is not used)"
+//	@Param			client_secret			formData	string	false	"Client secret (if HTTP Basic is not used)"
+//	@Param			client_assertion_type	formData	string	false	"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+//	@Param			client_assertion		formData	string	false	"JWT signed with the client key: iss and sub are the client ID, aud is the token endpoint URL, jti and exp are required"
+//	@Success		200						{object}	entities.OAuthTokenResponse
+//	@Failure		400						{object}	oauthErrorResponse	"invalid request or grant, grant not allowed for the client"
+//	@Failure		401						{object}	oauthErrorResponse	"invalid client"
+//	@Failure		500						{object}	oauthErrorResponse	"server error"
+//	@Router			/oauth/token [post]
+func (h *Handler) Token(c echo.Context) error {
+	var request entities.OAuthTokenRequest
+	if err := c.Bind(&request); err != nil {
+		return newOAuthErrorResponse(c, http.StatusBadRequest, service.OAuthInvalidRequest, "invalid request")
+	}
+	if clientId, clientSecret, ok := c.Request().BasicAuth(); ok {
+		request.ClientID, request.ClientSecret = clientId, clientSecret
+	}
+
+	// Ответы с токенами не должны кэшироваться (RFC 6749, раздел 5.1)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+
+	response, err := h.services.OAuth.Token(c.Request().Context(), request, clientInfo(c))
+	if err != nil {
+		return oauthErrorResponseFor(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// RevokeToken godoc
+//
+//	@Summary		OAuth2 token revocation
+//	@Description	Revoke a refresh token (with its session family) or an access token issued to the client (RFC 7009). Unknown tokens are ignored.
+//	@Tags			oauth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token					formData	string	true	"Token to revoke"
+//	@Param			token_type_hint			formData	string	false	"access_token or refresh_token"
+//	@Param			client_id				formData	string	false	"Client ID (if HTTP Basic is not used)"
+//	@Param			client_secret			formData	string	false	"Client secret (if HTTP Basic is not used)"
+//	@Param			client_assertion_type	formData	string	false	"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+//	@Param			client_assertion		formData	string	false	"JWT signed with the client key"
+//	@Success		200						{object}	statusResponse		"ok"
+//	@Failure		400						{object}	oauthErrorResponse	"invalid request"
+//	@Failure		401						{object}	oauthErrorResponse	"invalid client"
+//	@Failure		500						{object}	oauthErrorResponse	"server error"
+//	@Router			/oauth/revoke [post]
+func (h *Handler) RevokeToken(c echo.Context) error {
+	var request entities.OAuthRevokeRequest
+	if err := c.Bind(&request); err != nil {
+		return newOAuthErrorResponse(c, http.StatusBadRequest, service.OAuthInvalidRequest, "invalid request")
+	}
+	if clientId, clientSecret, ok := c.Request().BasicAuth(); ok {
+		request.ClientID, request.ClientSecret = clientId, clientSecret
+	}
+
+	if err := h.services.OAuth.Revoke(c.Request().Context(), request); err != nil {
+		return oauthErrorResponseFor(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// Introspect godoc
+//
+//	@Summary		OAuth2 token introspection
+//	@Description	Check an access token or API token for another service (RFC 7662). Only confidential clients may call it.
+//	@Description	Revoked tokens, tokens of deleted users and unknown tokens return {"active": false}; role is the owner's current role.
+//	@Description	Results are cached for auth.oauth.introspection.cacheTtl, so a revocation may take that long to be seen.
+//	@Tags			oauth
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Param			token					formData	string	true	"Access token or API token to check"
+//	@Param			token_type_hint			formData	string	false	"access_token or api_token"
+//	@Param			client_id				formData	string	false	"Client ID (if HTTP Basic is not used)"
+//	@Param			client_secret			formData	string	false	"Client secret (if HTTP Basic is not used)"
+//	@Param			client_assertion_type	formData	string	false	"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+//	@Param			client_assertion		formData	string	false	"JWT signed with the client key"
+//	@Success		200						{object}	entities.OAuthIntrospection
+//	@Failure		400						{object}	oauthErrorResponse	"invalid request"
+//	@Failure		401						{object}	oauthErrorResponse	"invalid or public client"
+//	@Failure		500						{object}	oauthErrorResponse	"server error"
+//	@Router			/oauth/introspect [post]
+func (h *Handler) Introspect(c echo.Context) error {
+	var request entities.OAuthIntrospectRequest
+	if err := c.Bind(&request); err != nil {
+		return newOAuthErrorResponse(c, http.StatusBadRequest, service.OAuthInvalidRequest, "invalid request")
+	}
+	if clientId, clientSecret, ok := c.Request().BasicAuth(); ok {
+		request.ClientID, request.ClientSecret = clientId, clientSecret
+	}
+
+	// Ответ зависит от текущего состояния токена и не должен кэшироваться прокси
+	c.Response().Header().Set("Cache-Control", "no-store")
+
+	response, err := h.services.OAuth.Introspect(c.Request().Context(), request)
+	if err != nil {
+		return oauthErrorResponseFor(c, err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// oauthErrorResponseFor преобразует ошибку OAuth-сервиса в ответ по RFC 6749
+func oauthErrorResponseFor(c echo.Context, err error) error {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		return newOAuthErrorResponse(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	if oauthErr.Code == service.OAuthInvalidClient {
+		if _, _, ok := c.Request().BasicAuth(); ok {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+		}
+		return newOAuthErrorResponse(c, http.StatusUnauthorized, oauthErr.Code, oauthErr.Description)
+	}
+	return newOAuthErrorResponse(c, http.StatusBadRequest, oauthErr.Code, oauthErr.Description)
+}
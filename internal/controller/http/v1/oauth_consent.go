@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/labstack/echo/v4"
+)
+
+// consentPage — страница согласия для браузера. Параметры запроса авторизации повторяются
+// в скрытых полях, чтобы POST /oauth/authorize проверил тот же запрос
+var consentPage = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Authorize {{.Consent.ClientName}}</title>
+</head>
+<body>
+<h1>{{.Consent.ClientName}} wants to access your account</h1>
+{{if .Consent.Scopes}}<ul>{{range .Consent.Scopes}}<li>{{.}}</li>{{end}}</ul>{{end}}
+<p>You will be redirected to {{.Consent.RedirectURI}}</p>
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+<button type="submit" name="consent" value="approve">Allow</button>
+<button type="submit" name="consent" value="deny">Deny</button>
+</form>
+</body>
+</html>
+`))
+
+// renderConsentPage отправляет страницу согласия; встраивать ее в чужие страницы запрещено
+func renderConsentPage(c echo.Context, consent entities.OAuthConsent, request entities.OAuthAuthorizeRequest, csrfToken string) error {
+	var page bytes.Buffer
+	if err := consentPage.Execute(&page, struct {
+		Consent   entities.OAuthConsent
+		Request   entities.OAuthAuthorizeRequest
+		CSRFToken string
+	}{consent, request, csrfToken}); err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("X-Frame-Options", "DENY")
+	header.Set("Content-Security-Policy", "frame-ancestors 'none'")
+	return c.HTMLBlob(http.StatusOK, page.Bytes())
+}
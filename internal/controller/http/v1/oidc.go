@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+const oidcStateCookie = "oidcState"
+
+// OIDCLogin godoc
+//
+//	@Summary		Start sign-in with an external identity provider
+//	@Description	Redirect to the OpenID Connect provider configured under auth.oidc.providers
+//	@Tags			auth
+//	@Param			provider	path	string	true	"Provider name"
+//	@Success		302			"redirect to the provider"
+//	@Failure		404			{object}	statusResponse	"identity provider not found"
+//	@Failure		401			{object}	statusResponse	"identity provider login failed"
+//	@Failure		500			{object}	statusResponse	"internal server error"
+//	@Router			/auth/oidc/{provider}/login [get]
+func (h *Handler) OIDCLogin(c echo.Context) error {
+	authURL, state, err := h.services.OIDC.Login(c.Request().Context(), c.Param("provider"))
+	if err != nil {
+		return oidcErrorResponse(c, err)
+	}
+
+	// state привязывается к браузеру, иначе чужой код можно подставить в callback (login CSRF)
+	c.SetCookie(h.newOIDCStateCookie(state))
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCLink godoc
+//
+//	@Summary		Link an external identity provider account
+//	@Description	Start sign-in with the provider for the current user; the callback links the provider account to them and returns tokens like /auth/sign-in.
+//	@Description	Open authorizationUrl in the same browser. Requires currentPassword in the body or a recent /auth/reauthenticate.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			provider	path		string					true	"Provider name"
+//	@Param			input		body		entities.StepUpInput	false	"Current password"
+//	@Success		200			{object}	entities.OIDCLinkResponse
+//	@Failure		401			{object}	statusResponse	"unauthorized or recent authentication required"
+//	@Failure		403			{object}	statusResponse	"wrong current password"
+//	@Failure		404			{object}	statusResponse	"identity provider not found"
+//	@Failure		500			{object}	statusResponse	"internal server error"
+//	@Router			/auth/oidc/{provider}/link [post]
+func (h *Handler) OIDCLink(c echo.Context) error {
+	userId, err := getUserId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusUnauthorized, errors.New("unauthorized").Error())
+	}
+
+	var input entities.StepUpInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	// Новый способ входа в учетную запись — важное изменение
+	if err := h.requireRecentAuth(c, input.CurrentPassword); err != nil {
+		return stepUpErrorResponse(c, err)
+	}
+
+	authURL, state, err := h.services.OIDC.Link(c.Request().Context(), c.Param("provider"), userId)
+	if err != nil {
+		return oidcErrorResponse(c, err)
+	}
+
+	c.SetCookie(h.newOIDCStateCookie(state))
+	return c.JSON(http.StatusOK, entities.OIDCLinkResponse{
+		AuthorizationURL: authURL,
+	})
+}
+
+// OIDCCallback godoc
+//
+//	@Summary		Finish sign-in with an external identity provider
+//	@Description	Exchange the provider's code for an ID token, link or create the user and return tokens like /auth/sign-in
+//	@Tags			auth
+//	@Produce		json
+//	@Param			provider	path		string	true	"Provider name"
+//	@Param			code		query		string	true	"Authorization code from the provider"
+//	@Param			state		query		string	true	"State from the login redirect"
+//	@Success		200			{object}	map[string]interface{}
+//	@Failure		400			{object}	statusResponse	"invalid or expired login state"
+//	@Failure		401			{object}	statusResponse	"identity provider login failed"
+//	@Failure		403			{object}	statusResponse	"no account is linked to this identity"
+//	@Failure		404			{object}	statusResponse	"identity provider not found"
+//	@Failure		409			{object}	statusResponse	"user with this username already exists or identity linked to another account"
+//	@Failure		500			{object}	statusResponse	"internal server error"
+//	@Router			/auth/oidc/{provider}/callback [get]
+func (h *Handler) OIDCCallback(c echo.Context) error {
+	// Пользователь отказался от входа или провайдер вернул ошибку
+	if providerError := c.QueryParam("error"); providerError != "" {
+		return newErrorResponse(c, http.StatusUnauthorized, errors.New("identity provider error: ").Error()+providerError)
+	}
+
+	state := c.QueryParam("state")
+	stateCookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(state)) != 1 {
+		return newErrorResponse(c, http.StatusBadRequest, service.ErrInvalidOIDCState.Error())
+	}
+	cookie := h.newOIDCStateCookie("")
+	cookie.MaxAge = -1
+	c.SetCookie(cookie)
+
+	result, err := h.services.OIDC.Callback(c.Request().Context(), c.Param("provider"), state, c.QueryParam("code"), clientInfo(c))
+	if err != nil {
+		return oidcErrorResponse(c, err)
+	}
+
+	return h.signInResultResponse(c, result, false)
+}
+
+// newOIDCStateCookie собирает куки со state входа через провайдера. Атрибут Secure берется из настроек
+// куки refresh token; SameSite всегда Lax, иначе браузер не отправит куку при возврате от провайдера
+func (h *Handler) newOIDCStateCookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/auth/oidc",
+		HttpOnly: true,
+		Secure:   h.cfg.RefreshToken.Cookie.Secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// oidcErrorResponse преобразует ошибку входа через провайдера в HTTP-ответ
+func oidcErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrOIDCProviderNotFound):
+		return newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrOIDCAccountNotLinked):
+		return newErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrOIDCAccountConflict), errors.Is(err, service.ErrOIDCIdentityInUse):
+		return newErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,382 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// GetUserOrganizations godoc
+//
+//	@Summary		Get my organizations
+//	@Description	Get organizations of the current user with their role in each; active marks the organization of the access token
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		entities.UserOrganization
+//	@Failure		403	{object}	statusResponse	"access denied"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/api/organizations [get]
+func (h *Handler) GetUserOrganizations(c echo.Context) error {
+	userId, err := getUserId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+	activeId, _ := getOrganizationId(c) // 0, если организация не выбрана
+
+	organizations, err := h.services.Organizations.GetUserOrganizations(c.Request().Context(), userId, activeId)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get organizations; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, organizations)
+}
+
+// SwitchOrganization godoc
+//
+//	@Summary	Switch the active organization
+//	@Description	Rotates the refresh token like /auth/refresh and returns an access token for the chosen organization.
+//	@Description	The choice is kept in the session, so later refreshes stay in this organization.
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		id	path	int	true	"Organization ID"
+//	@Param		X-Refresh-Token-Transport	header	string	false	"cookie (default) or body"
+//	@Param		X-CSRF-Token	header	string	false	"Value of the csrfToken cookie"
+//	@Param		input	body		entities.RefreshTokenInput	false	"Refresh token (body transport only)"
+//	@Success	200		{object}	map[string]interface{}
+//	@Failure	400		{object}	string	"Invalid organization id, unknown or disabled refresh token transport"
+//	@Failure	401		{object}	string	"No refresh token provided, invalid or reused refresh token"
+//	@Failure	403		{object}	string	"CSRF token missing or invalid, or not a member of the organization"
+//	@Failure	500		{object}	string	"Internal server error"
+//	@Router		/auth/organizations/{id}/switch [post]
+func (h *Handler) SwitchOrganization(c echo.Context) error {
+	organizationId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid organization id").Error())
+	}
+
+	inBody, err := h.refreshInBody(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	refreshToken, err := h.readRefreshToken(c, inBody) // Получение refreshToken из куки или тела запроса
+	if err != nil {
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	accessToken, newRefreshToken, err := h.services.Authorization.SwitchOrganization(c.Request().Context(), refreshToken, organizationId, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMemberNotFound):
+			return newErrorResponse(c, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrInvalidRefreshToken), errors.Is(err, service.ErrRefreshTokenReused):
+			if !inBody {
+				h.clearRefreshTokenCookie(c)
+			}
+			return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		default:
+			return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return h.tokensResponse(c, accessToken, newRefreshToken, inBody)
+}
+
+// OrganizationGetUsers godoc
+//
+//	@Summary		Get organization users
+//	@Description	Get users of the active organization (users:read in the member role)
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		bun_entities.User
+//	@Failure		403	{object}	statusResponse	"no active organization or missing permission"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/org/users [get]
+func (h *Handler) OrganizationGetUsers(c echo.Context) error {
+	// Запросы уже ограничены активной организацией из токена
+	return h.usersResponse(c, c.Request().Context())
+}
+
+// OrganizationGetUser godoc
+//
+//	@Summary		Get an organization user
+//	@Description	Get a user of the active organization by ID (users:read in the member role)
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	bun_entities.User
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		403	{object}	statusResponse	"no active organization or missing permission"
+//	@Failure		404	{object}	statusResponse	"user not found in the organization"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/org/users/{id} [get]
+func (h *Handler) OrganizationGetUser(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+	if err := h.authorizeUser(c, userId, entities.AccessRead); err != nil {
+		return accessErrorResponse(c, err)
+	}
+
+	user, err := h.services.GetUserByID(c.Request().Context(), userId)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get user; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// OrganizationCreateUser godoc
+//
+//	@Summary		Create an organization user
+//	@Description	Create a user who becomes a member of the active organization with the default role (users:write in the member role).
+//	@Description	The global role can't be chosen here.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			user	body		entities.CreateUserInput	true	"User data"
+//	@Success		201		{object}	map[string]interface{}		"user created"
+//	@Failure		400		{object}	passwordErrorResponse		"invalid request or password rejected by the policy"
+//	@Failure		403		{object}	statusResponse				"no active organization or missing permission"
+//	@Failure		500		{object}	statusResponse				"internal server error"
+//	@Router			/org/users [post]
+func (h *Handler) OrganizationCreateUser(c echo.Context) error {
+	var user entities.CreateUserInput
+	if err := c.Bind(&user); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if user.Role != "" {
+		return newErrorResponse(c, http.StatusBadRequest, "role can't be set by an organization administrator")
+	}
+	if err := user.ValidateCreateUserInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	// Пользователь сразу становится участником активной организации
+	id, err := h.services.CreateUser(c.Request().Context(), user)
+	if err != nil {
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return newPasswordErrorResponse(c, policyErr) // Пароль не соответствует политике
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't create user; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"id": id,
+	})
+}
+
+// OrganizationGetMembers godoc
+//
+//	@Summary		Get organization members
+//	@Description	Get members of the active organization with their roles in it (users:read in the member role)
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		entities.Member
+//	@Failure		403	{object}	statusResponse	"no active organization or missing permission"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/org/members [get]
+func (h *Handler) OrganizationGetMembers(c echo.Context) error {
+	organizationId, err := getOrganizationId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusForbidden, "no active organization")
+	}
+	return h.getMembers(c, organizationId)
+}
+
+// OrganizationAddMember godoc
+//
+//	@Summary		Add an organization member
+//	@Description	Add an existing user to the active organization (users:write in the member role). Choosing a role requires
+//	@Description	roles:assign, and the role can't have permissions the caller lacks.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			input	body		entities.MemberInput	true	"User and role"
+//	@Success		201		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid request or unknown role"
+//	@Failure		403		{object}	statusResponse	"no active organization or missing permission"
+//	@Failure		404		{object}	statusResponse	"user not found"
+//	@Failure		409		{object}	statusResponse	"user is already a member"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/org/members [post]
+func (h *Handler) OrganizationAddMember(c echo.Context) error {
+	organizationId, err := getOrganizationId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusForbidden, "no active organization")
+	}
+	return h.addMember(c, organizationId)
+}
+
+// OrganizationUpdateMember godoc
+//
+//	@Summary		Change a member role
+//	@Description	Change the role of a user in the active organization (roles:assign in the member role).
+//	@Description	The role can't have permissions the caller lacks.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			userId	path		int							true	"User ID"
+//	@Param			input	body		entities.MemberUpdateInput	true	"New role"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid request or unknown role"
+//	@Failure		403		{object}	statusResponse	"no active organization or missing permission"
+//	@Failure		404		{object}	statusResponse	"user is not a member"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/org/members/{userId} [put]
+func (h *Handler) OrganizationUpdateMember(c echo.Context) error {
+	organizationId, err := getOrganizationId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusForbidden, "no active organization")
+	}
+	return h.updateMember(c, organizationId)
+}
+
+// OrganizationRemoveMember godoc
+//
+//	@Summary		Remove an organization member
+//	@Description	Remove a user from the active organization; the user account stays (users:write in the member role)
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			userId	path		int				true	"User ID"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid user id"
+//	@Failure		403		{object}	statusResponse	"no active organization or missing permission"
+//	@Failure		404		{object}	statusResponse	"user is not a member"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/org/members/{userId} [delete]
+func (h *Handler) OrganizationRemoveMember(c echo.Context) error {
+	organizationId, err := getOrganizationId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusForbidden, "no active organization")
+	}
+	return h.removeMember(c, organizationId)
+}
+
+// getMembers отправляет список участников организации
+func (h *Handler) getMembers(c echo.Context, organizationId int) error {
+	members, err := h.services.Organizations.GetMembers(c.Request().Context(), organizationId)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, members)
+}
+
+// addMember добавляет пользователя в организацию. Администратор организации выбирает роль
+// только с правом roles:assign и не может выдать прав больше, чем есть у него самого
+func (h *Handler) addMember(c echo.Context, organizationId int) error {
+	var input entities.MemberInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateMemberInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+	if input.Role != "" && isOrganizationRequest(c) {
+		if err := h.checkMemberRole(c, input.Role); err != nil {
+			return newErrorResponse(c, http.StatusForbidden, err.Error())
+		}
+	}
+
+	if err := h.services.Organizations.AddMember(c.Request().Context(), organizationId, input); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, statusResponse{
+		Status: "ok",
+	})
+}
+
+// updateMember меняет роль участника организации
+func (h *Handler) updateMember(c echo.Context, organizationId int) error {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	var input entities.MemberUpdateInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateMemberUpdateInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+	if isOrganizationRequest(c) {
+		if err := h.checkMemberRole(c, input.Role); err != nil {
+			return newErrorResponse(c, http.StatusForbidden, err.Error())
+		}
+	}
+
+	if err := h.services.Organizations.UpdateMemberRole(c.Request().Context(), organizationId, userId, input); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// removeMember исключает пользователя из организации
+func (h *Handler) removeMember(c echo.Context, organizationId int) error {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	if err := h.services.Organizations.RemoveMember(c.Request().Context(), organizationId, userId); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// checkMemberRole проверяет, что администратор организации может выдать роль: у него есть право roles:assign
+// и все права этой роли, чтобы нельзя было выдать себе или другому больше прав, чем есть
+func (h *Handler) checkMemberRole(c echo.Context, role string) error {
+	if !h.hasPermission(c, entities.PermissionRolesAssign) {
+		return service.ErrRoleAssignmentDenied
+	}
+	own, err := h.permissions(c)
+	if err != nil {
+		return err
+	}
+	granted, err := h.services.Roles.GetRolePermissions(c.Request().Context(), role)
+	if err != nil {
+		return err
+	}
+	for _, permission := range granted {
+		if !slices.Contains(own, permission) {
+			return errors.New("the role has permission " + permission + " that you don't have")
+		}
+	}
+	return nil
+}
+
+// isOrganizationRequest сообщает, выполняется ли запрос на маршрутах администратора организации,
+// где права дает роль участника
+func isOrganizationRequest(c echo.Context) bool {
+	ok, _ := c.Get(organizationRoleCtx).(bool)
+	return ok
+}
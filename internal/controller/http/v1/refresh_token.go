@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	refreshTransportHeader   = "X-Refresh-Token-Transport" // Способ передачи refresh token, который выбрал клиент
+	refreshTransportCookie   = "cookie"                    // Refresh token в HttpOnly куке (по умолчанию)
+	refreshTransportBody     = "body"                      // Refresh token в JSON-ответе и в теле запроса
+	defaultRefreshCookieName = "refreshToken"
+	hostCookiePrefix         = "__Host-"
+)
+
+var (
+	errBodyTransportDisabled = errors.New("refresh token in response body is disabled")
+	errUnknownTransport      = errors.New("unknown refresh token transport, use cookie or body")
+	errNoRefreshToken        = errors.New("no refresh token provided")
+)
+
+// refreshInBody сообщает, запросил ли клиент передачу refresh token в теле вместо куки
+func (h *Handler) refreshInBody(c echo.Context) (bool, error) {
+	switch strings.ToLower(c.Request().Header.Get(refreshTransportHeader)) {
+	case "", refreshTransportCookie:
+		return false, nil
+	case refreshTransportBody:
+		if !h.cfg.RefreshToken.AllowBody {
+			return false, errBodyTransportDisabled
+		}
+		return true, nil
+	default:
+		return false, errUnknownTransport
+	}
+}
+
+// readRefreshToken возвращает refresh token из тела запроса или из куки
+func (h *Handler) readRefreshToken(c echo.Context, inBody bool) (string, error) {
+	if inBody {
+		var input entities.RefreshTokenInput
+		if err := c.Bind(&input); err != nil || input.RefreshToken == "" {
+			return "", errNoRefreshToken
+		}
+		return input.RefreshToken, nil
+	}
+
+	cookie, err := c.Cookie(h.refreshCookieName())
+	if err != nil || cookie.Value == "" {
+		return "", errNoRefreshToken
+	}
+	return cookie.Value, nil
+}
+
+// tokensResponse отправляет access token и refresh token: в куке или, если клиент выбрал body, в JSON-ответе
+func (h *Handler) tokensResponse(c echo.Context, accessToken string, refreshToken string, inBody bool) error {
+	if inBody {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"accessToken":  accessToken,
+			"refreshToken": refreshToken,
+		})
+	}
+
+	// Установка куки с refreshToken и нового CSRF-токена для следующих запросов с этой кукой
+	h.setRefreshTokenCookie(c, refreshToken)
+	csrfToken, err := h.setCSRFCookie(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"accessToken": accessToken,
+		"csrfToken":   csrfToken,
+	})
+}
+
+// refreshCookieName возвращает имя куки с учетом префикса __Host-
+func (h *Handler) refreshCookieName() string {
+	name := h.cfg.RefreshToken.Cookie.Name
+	if name == "" {
+		name = defaultRefreshCookieName
+	}
+	if h.cfg.RefreshToken.Cookie.HostPrefix {
+		name = hostCookiePrefix + name
+	}
+	return name
+}
+
+// newRefreshCookie собирает куки с refreshToken по настройкам из конфигурации
+func (h *Handler) newRefreshCookie(value string) *http.Cookie {
+	cfg := h.cfg.RefreshToken.Cookie
+	cookie := &http.Cookie{
+		Name:     h.refreshCookieName(),
+		Value:    value,
+		Path:     cfg.Path,
+		Domain:   cfg.Domain,
+		HttpOnly: true,
+		Secure:   cfg.Secure,
+		SameSite: sameSiteMode(cfg.SameSite),
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	return cookie
+}
+
+// setRefreshTokenCookie устанавливает куки с refreshToken на время жизни сессии
+func (h *Handler) setRefreshTokenCookie(c echo.Context, refreshToken string) {
+	cookie := h.newRefreshCookie(refreshToken)
+	cookie.MaxAge = int(service.RefreshTokenTTL.Seconds())
+	cookie.Expires = time.Now().Add(service.RefreshTokenTTL)
+	c.SetCookie(cookie)
+}
+
+// clearRefreshTokenCookie удаляет куки с refreshToken и CSRF-токеном
+func (h *Handler) clearRefreshTokenCookie(c echo.Context) {
+	cookie := h.newRefreshCookie("")
+	cookie.MaxAge = -1
+	c.SetCookie(cookie)
+	h.clearCSRFCookie(c)
+}
+
+// sameSiteMode преобразует значение из конфигурации в атрибут SameSite
+func sameSiteMode(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
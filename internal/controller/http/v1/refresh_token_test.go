@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// responseCookie возвращает куку с именем name из ответа
+func responseCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestRefreshCookieAttributes(t *testing.T) {
+	tests := []struct {
+		name         string
+		cookie       config.RefreshCookie
+		wantName     string
+		wantCSRFName string
+		wantPath     string
+		wantDomain   string
+		wantSecure   bool
+		wantSameSite http.SameSite
+	}{
+		{
+			name:         "defaults",
+			wantName:     "refreshToken",
+			wantCSRFName: "csrfToken",
+			wantPath:     "/",
+			wantSameSite: http.SameSiteLaxMode,
+		},
+		{
+			name:         "configured attributes",
+			cookie:       config.RefreshCookie{Name: "rt", Path: "/auth", Domain: "example.com", Secure: true, SameSite: "Strict"},
+			wantName:     "rt",
+			wantCSRFName: "csrfToken",
+			wantPath:     "/auth",
+			wantDomain:   "example.com",
+			wantSecure:   true,
+			wantSameSite: http.SameSiteStrictMode,
+		},
+		{
+			name:         "same site none",
+			cookie:       config.RefreshCookie{Secure: true, SameSite: "none"},
+			wantName:     "refreshToken",
+			wantCSRFName: "csrfToken",
+			wantPath:     "/",
+			wantSecure:   true,
+			wantSameSite: http.SameSiteNoneMode,
+		},
+		{
+			name:         "host prefix",
+			cookie:       config.RefreshCookie{Secure: true, HostPrefix: true},
+			wantName:     "__Host-refreshToken",
+			wantCSRFName: "__Host-csrfToken",
+			wantPath:     "/",
+			wantSecure:   true,
+			wantSameSite: http.SameSiteLaxMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&service.Service{Authorization: &fakeAuthorization{}},
+				&config.Auth{RefreshToken: config.RefreshToken{Cookie: tt.cookie}})
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: tt.wantName, Value: "refresh-token"})
+			req.AddCookie(&http.Cookie{Name: tt.wantCSRFName, Value: "csrf"})
+			req.Header.Set(csrfHeader, "csrf")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+
+			refresh := responseCookie(rec, tt.wantName)
+			if refresh == nil {
+				t.Fatalf("no %s cookie in %v", tt.wantName, rec.Header().Values("Set-Cookie"))
+			}
+			if refresh.Value != "new-refresh-token" || !refresh.HttpOnly || refresh.Secure != tt.wantSecure ||
+				refresh.SameSite != tt.wantSameSite || refresh.Path != tt.wantPath || refresh.Domain != tt.wantDomain {
+				t.Errorf("refresh cookie = %+v", refresh)
+			}
+			// Время жизни куки совпадает со временем жизни сессии
+			if refresh.MaxAge != int(service.RefreshTokenTTL.Seconds()) {
+				t.Errorf("refresh cookie max age = %d, want %d", refresh.MaxAge, int(service.RefreshTokenTTL.Seconds()))
+			}
+
+			// CSRF-токен нужен JavaScript, поэтому кука без HttpOnly и на весь сайт
+			csrf := responseCookie(rec, tt.wantCSRFName)
+			if csrf == nil {
+				t.Fatalf("no %s cookie in %v", tt.wantCSRFName, rec.Header().Values("Set-Cookie"))
+			}
+			if csrf.Value == "" || csrf.HttpOnly || csrf.Path != "/" || csrf.Secure != tt.wantSecure || csrf.SameSite != tt.wantSameSite {
+				t.Errorf("csrf cookie = %+v", csrf)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenTransport(t *testing.T) {
+	tests := []struct {
+		name            string
+		allowBody       bool
+		transport       string
+		body            string
+		cookie          string
+		wantStatus      int
+		wantBodyToken   bool
+		wantCookie      bool
+		wantCookieClear bool
+	}{
+		{name: "cookie", cookie: "refresh-token", wantStatus: http.StatusOK, wantCookie: true},
+		{name: "explicit cookie", transport: "Cookie", cookie: "refresh-token", wantStatus: http.StatusOK, wantCookie: true},
+		{name: "no cookie", wantStatus: http.StatusUnauthorized},
+		{name: "invalid cookie", cookie: "stolen", wantStatus: http.StatusUnauthorized, wantCookieClear: true},
+		{name: "body", allowBody: true, transport: "body", body: `{"refreshToken":"refresh-token"}`, wantStatus: http.StatusOK, wantBodyToken: true},
+		{name: "body ignores cookie", allowBody: true, transport: "body", body: `{}`, cookie: "refresh-token", wantStatus: http.StatusUnauthorized},
+		{name: "invalid body token keeps cookie", allowBody: true, transport: "body", body: `{"refreshToken":"stolen"}`, wantStatus: http.StatusUnauthorized},
+		{name: "body disabled", transport: "body", body: `{"refreshToken":"refresh-token"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown transport", allowBody: true, transport: "header", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&service.Service{Authorization: &fakeAuthorization{}},
+				&config.Auth{RefreshToken: config.RefreshToken{AllowBody: tt.allowBody}})
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.transport != "" {
+				req.Header.Set(refreshTransportHeader, tt.transport)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: defaultRefreshCookieName, Value: tt.cookie})
+			}
+			// Запросы с кукой проходят проверку CSRF
+			req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: "csrf"})
+			req.Header.Set(csrfHeader, "csrf")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := response["refreshToken"]; ok != tt.wantBodyToken {
+				t.Errorf("response = %v, want refresh token in body %v", response, tt.wantBodyToken)
+			}
+
+			cookie := responseCookie(rec, defaultRefreshCookieName)
+			switch {
+			case tt.wantCookie:
+				if cookie == nil || cookie.Value != "new-refresh-token" {
+					t.Errorf("refresh cookie = %+v, want new-refresh-token", cookie)
+				}
+			case tt.wantCookieClear:
+				if cookie == nil || cookie.MaxAge >= 0 {
+					t.Errorf("refresh cookie = %+v, want it cleared", cookie)
+				}
+			default:
+				if cookie != nil {
+					t.Errorf("refresh cookie = %+v, want none", cookie)
+				}
+			}
+		})
+	}
+}
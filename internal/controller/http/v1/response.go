@@ -1,6 +1,9 @@
 package v1
 
 import (
+	"net/http"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 )
@@ -13,7 +16,29 @@ type statusResponse struct {
 	Status string `json:"status"`
 }
 
+// oauthErrorResponse — ответ с ошибкой OAuth 2.0 (RFC 6749, раздел 5.2)
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// passwordErrorResponse — ответ с нарушенным правилом парольной политики
+type passwordErrorResponse struct {
+	Message string `json:"message"`
+	Rule    string `json:"rule"`
+}
+
 func newErrorResponse(c echo.Context, statusCode int, message string) error {
 	logrus.Error(message)
 	return c.JSON(statusCode, errorResponse{Message: message})
 }
+
+func newOAuthErrorResponse(c echo.Context, statusCode int, code string, description string) error {
+	logrus.Error(code + ": " + description)
+	return c.JSON(statusCode, oauthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+func newPasswordErrorResponse(c echo.Context, err *entities.PasswordPolicyError) error {
+	logrus.Error(err.Error())
+	return c.JSON(http.StatusBadRequest, passwordErrorResponse{Message: err.Message, Rule: err.Rule})
+}
@@ -1,43 +1,150 @@
 package v1
 
 import (
+	"net"
 	"net/http"
 
 	_ "github.com/kolibriee/users-rest-api/docs"
+	"github.com/kolibriee/users-rest-api/internal/entities"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
+// ipExtractor определяет IP клиента. X-Forwarded-For учитывается только от прокси из конфигурации,
+// без них IP берется из соединения.
+func (h *Handler) ipExtractor() echo.IPExtractor {
+	if h.server == nil || len(h.server.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range h.server.TrustedProxies {
+		_, network, _ := net.ParseCIDR(proxy)
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
 func (h *Handler) InitRouter() http.Handler {
 	router := echo.New()
+	router.IPExtractor = h.ipExtractor()
 	router.Use(middleware.Logger())
 	router.GET("/swagger*", echoSwagger.WrapHandler)
-	admin := router.Group("/admin", h.adminIdentity)
+	router.GET("/.well-known/jwks.json", h.JWKS)
+	admin := router.Group("/admin", h.adminIdentity, h.requireMethodScope(entities.ScopeAdminRead, entities.ScopeAdminWrite))
 	{
 		users := admin.Group("/users")
 		{
-			users.GET("", h.GetAllUsers)
-			users.GET("/:id", h.AdminGetUserByID)
-			users.POST("", h.CreateUser)
-			users.PUT("/:id", h.AdminUpdateUser)
-			users.DELETE("/:id", h.AdminDeleteUser)
+			users.GET("", h.GetAllUsers, h.requirePermission(entities.PermissionUsersRead))
+			users.GET("/:id", h.AdminGetUserByID, h.requirePermission(entities.PermissionUsersRead))
+			users.POST("", h.CreateUser, h.requirePermission(entities.PermissionUsersWrite))
+			users.PUT("/:id", h.AdminUpdateUser, h.requirePermission(entities.PermissionUsersWrite))
+			users.DELETE("/:id", h.AdminDeleteUser, h.requirePermission(entities.PermissionUsersWrite))
+			users.GET("/:id/lock", h.AdminGetUserLock, h.requirePermission(entities.PermissionUsersRead), h.requireVisibleUser)
+			users.DELETE("/:id/lock", h.AdminUnlockUser, h.requirePermission(entities.PermissionUsersWrite), h.requireVisibleUser)
+			users.DELETE("/:id/2fa", h.AdminResetTwoFactor, h.requirePermission(entities.PermissionUsersWrite), h.requireVisibleUser)
+			users.GET("/:id/sessions", h.AdminGetSessions, h.requirePermission(entities.PermissionSessionsRead), h.requireVisibleUser)
+			users.DELETE("/:id/sessions/:sid", h.AdminRevokeSession, h.requirePermission(entities.PermissionSessionsRevoke), h.requireVisibleUser)
+			users.POST("/:id/impersonate", h.Impersonate, h.requirePermission(entities.PermissionUsersImpersonate))
+		}
+		oauthClients := admin.Group("/oauth/clients")
+		{
+			oauthClients.GET("", h.GetOAuthClients, h.requirePermission(entities.PermissionOAuthClientsRead))
+			oauthClients.POST("", h.CreateOAuthClient, h.requirePermission(entities.PermissionOAuthClientsWrite))
+			oauthClients.DELETE("/:clientId", h.DeleteOAuthClient, h.requirePermission(entities.PermissionOAuthClientsWrite))
+		}
+		apiKeys := admin.Group("/api-keys")
+		{
+			apiKeys.GET("", h.GetAPIKeys, h.requirePermission(entities.PermissionAPIKeysRead))
+			apiKeys.POST("", h.CreateAPIKey, h.requirePermission(entities.PermissionAPIKeysWrite))
+			apiKeys.DELETE("/:keyId", h.DeleteAPIKey, h.requirePermission(entities.PermissionAPIKeysWrite))
+		}
+		roles := admin.Group("/roles")
+		{
+			roles.GET("", h.GetRoles, h.requirePermission(entities.PermissionRolesRead))
+			roles.POST("", h.CreateRole, h.requirePermission(entities.PermissionRolesWrite))
+			roles.GET("/:name", h.GetRoleByName, h.requirePermission(entities.PermissionRolesRead))
+			roles.PUT("/:name", h.UpdateRole, h.requirePermission(entities.PermissionRolesWrite))
+			roles.DELETE("/:name", h.DeleteRole, h.requirePermission(entities.PermissionRolesWrite))
+		}
+		admin.GET("/permissions", h.GetPermissions, h.requirePermission(entities.PermissionRolesRead))
+		organizations := admin.Group("/organizations")
+		{
+			organizations.GET("", h.GetOrganizations, h.requirePermission(entities.PermissionOrganizationsRead))
+			organizations.POST("", h.CreateOrganization, h.requirePermission(entities.PermissionOrganizationsWrite))
+			organizations.GET("/:id", h.GetOrganization, h.requirePermission(entities.PermissionOrganizationsRead))
+			organizations.DELETE("/:id", h.DeleteOrganization, h.requirePermission(entities.PermissionOrganizationsWrite))
+			organizations.GET("/:id/members", h.AdminGetMembers, h.requirePermission(entities.PermissionOrganizationsRead))
+			organizations.POST("/:id/members", h.AdminAddMember, h.requirePermission(entities.PermissionOrganizationsWrite))
+			organizations.PUT("/:id/members/:userId", h.AdminUpdateMember, h.requirePermission(entities.PermissionOrganizationsWrite))
+			organizations.DELETE("/:id/members/:userId", h.AdminRemoveMember, h.requirePermission(entities.PermissionOrganizationsWrite))
 		}
 	}
+	// Администрирование активной организации: права дает роль участника, запросы ограничены организацией
+	org := router.Group("/org", h.userIdentity, h.organizationIdentity)
+	{
+		org.GET("/users", h.OrganizationGetUsers, h.requireScope(entities.ScopeOrgRead), h.requirePermission(entities.PermissionUsersRead))
+		org.GET("/users/:id", h.OrganizationGetUser, h.requireScope(entities.ScopeOrgRead), h.requirePermission(entities.PermissionUsersRead))
+		org.POST("/users", h.OrganizationCreateUser, h.requireScope(entities.ScopeOrgWrite), h.requirePermission(entities.PermissionUsersWrite))
+		org.GET("/members", h.OrganizationGetMembers, h.requireScope(entities.ScopeOrgRead), h.requirePermission(entities.PermissionUsersRead))
+		org.POST("/members", h.OrganizationAddMember, h.requireScope(entities.ScopeOrgWrite), h.requirePermission(entities.PermissionUsersWrite))
+		org.PUT("/members/:userId", h.OrganizationUpdateMember, h.requireScope(entities.ScopeOrgWrite), h.requirePermission(entities.PermissionRolesAssign))
+		org.DELETE("/members/:userId", h.OrganizationRemoveMember, h.requireScope(entities.ScopeOrgWrite), h.requirePermission(entities.PermissionUsersWrite))
+	}
 	auth := router.Group("/auth")
 	{
 		auth.POST("/sign-up", h.SignUp)
 		auth.POST("/sign-in", h.SignIn)
-		auth.GET("/refresh", h.Refresh)
+		auth.POST("/sign-in/2fa", h.SignInTwoFactor)
+		auth.POST("/password/forgot", h.ForgotPassword)
+		auth.POST("/password/reset", h.ResetPassword)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/verify-email/resend", h.ResendEmailVerification)
+		auth.POST("/magic-link", h.RequestMagicLink)
+		auth.GET("/magic-link/callback", h.MagicLinkCallback)
+		auth.POST("/magic-link/callback", h.MagicLinkSignIn)
+		auth.GET("/oidc/:provider/login", h.OIDCLogin)
+		auth.GET("/oidc/:provider/callback", h.OIDCCallback)
+		auth.POST("/oidc/:provider/link", h.OIDCLink, h.userIdentity, h.requireScope(entities.ScopeProfileWrite), h.forbidImpersonation)
+		auth.POST("/refresh", h.Refresh, h.csrfProtection)
+		auth.POST("/logout", h.Logout, h.csrfProtection)
+		auth.POST("/organizations/:id/switch", h.SwitchOrganization, h.csrfProtection)
+		if h.cfg.CSRF.LegacyGetRefresh {
+			auth.GET("/refresh", h.LegacyRefresh) // Устаревший маршрут для старых клиентов
+		}
+		auth.POST("/logout-all", h.LogoutAll, h.userIdentity, h.requireScope(entities.ScopeSessionsWrite), h.forbidImpersonation)
+		auth.POST("/reauthenticate", h.Reauthenticate, h.userIdentity, h.requireScope(entities.ScopeProfileWrite), h.forbidImpersonation)
+	}
+	oauth := router.Group("/oauth")
+	{
+		oauth.GET("/authorize", h.Authorize, h.browserIdentity, h.forbidImpersonation, h.forbidScopedToken)
+		oauth.POST("/authorize", h.AuthorizeConsent, h.browserIdentity, h.forbidImpersonation, h.forbidScopedToken)
+		oauth.POST("/token", h.Token)
+		oauth.POST("/revoke", h.RevokeToken)
+		oauth.POST("/introspect", h.Introspect)
 	}
 	api := router.Group("/api")
 	{
 		users := api.Group("/users", h.userIdentity)
 		{
-			users.GET("/:id", h.GetUserByID)
-			users.PUT("/:id", h.UpdateUser)
-			users.DELETE("/:id", h.DeleteUser)
+			users.GET("", h.GetUsers, h.requireScope(entities.ScopeProfileRead))
+			users.GET("/:id", h.GetUserByID, h.requireScope(entities.ScopeProfileRead))
+			users.PUT("/:id", h.UpdateUser, h.requireScope(entities.ScopeProfileWrite))
+			users.DELETE("/:id", h.DeleteUser, h.requireScope(entities.ScopeProfileWrite))
+			users.POST("/:id/2fa", h.EnrollTwoFactor, h.requireScope(entities.ScopeProfileWrite), h.forbidImpersonation)
+			users.POST("/:id/2fa/confirm", h.ConfirmTwoFactor, h.requireScope(entities.ScopeProfileWrite), h.forbidImpersonation)
+			users.DELETE("/:id/2fa", h.DisableTwoFactor, h.requireScope(entities.ScopeProfileWrite), h.forbidImpersonation)
+			users.GET("/:id/sessions", h.GetSessions, h.requireScope(entities.ScopeSessionsRead))
+			users.DELETE("/:id/sessions/:sid", h.RevokeSession, h.requireScope(entities.ScopeSessionsWrite))
+			users.GET("/:id/tokens", h.GetPersonalAccessTokens, h.requireScope(entities.ScopeTokensRead))
+			users.POST("/:id/tokens", h.CreatePersonalAccessToken, h.requireScope(entities.ScopeTokensWrite), h.forbidImpersonation)
+			users.DELETE("/:id/tokens/:tokenId", h.DeletePersonalAccessToken, h.requireScope(entities.ScopeTokensWrite))
 		}
+		api.GET("/organizations", h.GetUserOrganizations, h.userIdentity, h.requireScope(entities.ScopeOrgRead))
 	}
 	return router
 }
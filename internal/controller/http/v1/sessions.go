@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+var _ = entities.SessionInfo{}
+
+// GetSessions godoc
+//
+//	@Summary		Get user sessions
+//	@Description	Get active sessions of a user (user themselves or the sessions:read permission)
+//	@Tags			users
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{array}		entities.SessionInfo
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		403	{object}	statusResponse	"access denied"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/sessions [get]
+func (h *Handler) GetSessions(c echo.Context) error {
+	// Извлечение ID пользователя из параметров запроса
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	// Извлечение текущего ID пользователя из контекста; у сервисного клиента его нет
+	currentUserId, err := getUserId(c)
+	if err != nil && !isServiceClientRequest(c) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	// Проверка прав доступа: со своей учетной записью пользователь работает без прав
+	if currentUserId != userId && !h.hasPermission(c, entities.PermissionSessionsRead) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	sessions, err := h.services.Authorization.GetSessions(c.Request().Context(), userId)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get sessions; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+//
+//	@Summary		Revoke a user session
+//	@Description	Revoke a single session of a user (user themselves or the sessions:revoke permission)
+//	@Tags			users
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int				true	"User ID"
+//	@Param			sid	path		int				true	"Session ID"
+//	@Success		200	{object}	statusResponse	"ok"
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		403	{object}	statusResponse	"access denied"
+//	@Failure		404	{object}	statusResponse	"session not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/sessions/{sid} [delete]
+func (h *Handler) RevokeSession(c echo.Context) error {
+	// Извлечение ID пользователя и сессии из параметров запроса
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+	sessionId, err := strconv.Atoi(c.Param("sid"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid session id").Error())
+	}
+
+	// Извлечение текущего ID пользователя из контекста; у сервисного клиента его нет
+	currentUserId, err := getUserId(c)
+	if err != nil && !isServiceClientRequest(c) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	// Проверка прав доступа: со своей учетной записью пользователь работает без прав
+	if currentUserId != userId && !h.hasPermission(c, entities.PermissionSessionsRevoke) {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	if err := h.services.Authorization.RevokeSession(c.Request().Context(), userId, sessionId); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't revoke session; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// GetSessions godoc
+//
+//	@Summary		Get user sessions
+//	@Description	Get active sessions of any user (requires the sessions:read permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{array}		entities.SessionInfo
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		403	{object}	statusResponse	"access denied"
+//	@Failure		404	{object}	statusResponse	"user not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/users/{id}/sessions [get]
+func (h *Handler) AdminGetSessions(c echo.Context) error {
+	return h.GetSessions(c)
+}
+
+// RevokeSession godoc
+//
+//	@Summary		Revoke a user session
+//	@Description	Revoke a single session of any user (requires the sessions:revoke permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int				true	"User ID"
+//	@Param			sid	path		int				true	"Session ID"
+//	@Success		200	{object}	statusResponse	"ok"
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		403	{object}	statusResponse	"access denied"
+//	@Failure		404	{object}	statusResponse	"user or session not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/users/{id}/sessions/{sid} [delete]
+func (h *Handler) AdminRevokeSession(c echo.Context) error {
+	return h.RevokeSession(c)
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// Reauthenticate godoc
+//
+//	@Summary		Confirm identity before sensitive changes
+//	@Description	Check the password (and the 2FA code if enabled) of the signed-in user and return an access token with an auth_time claim.
+//	@Description	For auth.stepUp.maxAge after that, changing the password, username, email or 2FA and deleting the account don't require currentPassword.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			input	body		entities.ReauthenticateInput	true	"Password and two-factor code"
+//	@Success		200		{object}	entities.Reauthentication
+//	@Failure		400		{object}	statusResponse	"invalid input body"
+//	@Failure		401		{object}	statusResponse	"unauthorized, wrong password or two-factor code"
+//	@Failure		403		{object}	statusResponse	"not allowed with an API token or while impersonating"
+//	@Failure		429		{object}	statusResponse	"too many failed attempts"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/auth/reauthenticate [post]
+func (h *Handler) Reauthenticate(c echo.Context) error {
+	userId, err := getUserId(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+	// API-токен нельзя обменять на access token
+	if isAPITokenRequest(c) {
+		return newErrorResponse(c, http.StatusForbidden, "reauthentication requires an access token")
+	}
+
+	var input entities.ReauthenticateInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, "invalid input body") // Обработка ошибки привязки
+	}
+	if err := input.ValidateReauthenticateInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error()) // Проверка на валидность
+	}
+
+	// Новый access token ограничен теми же scope, что и текущий
+	scopes, _ := getScopes(c)
+	result, err := h.services.Authorization.Reauthenticate(c.Request().Context(), userId, strings.Join(scopes, " "), input, c.RealIP())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCurrentPassword) ||
+			errors.Is(err, service.ErrInvalidTwoFactorCode) ||
+			errors.Is(err, service.ErrTwoFactorCodeRequired) {
+			return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		}
+		return stepUpErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// errServiceClientStepUp — сервисный клиент не может подтвердить личность пользователя
+var errServiceClientStepUp = errors.New("service clients can't change passwords, usernames or emails")
+
+// requireRecentAuth проверяет, что текущий пользователь недавно подтвердил личность
+// через /auth/reauthenticate или прислал текущий пароль
+func (h *Handler) requireRecentAuth(c echo.Context, currentPassword string) error {
+	// У сервисного клиента нет пароля, которым можно подтвердить такое изменение
+	if isServiceClientRequest(c) {
+		return errServiceClientStepUp
+	}
+	currentUserId, err := getUserId(c)
+	if err != nil {
+		return err
+	}
+	return h.services.Authorization.CheckRecentAuth(c.Request().Context(), currentUserId, getAuthTime(c), currentPassword, c.RealIP())
+}
+
+// stepUpErrorResponse преобразует ошибку проверки недавнего подтверждения личности в HTTP-ответ
+func stepUpErrorResponse(c echo.Context, err error) error {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+	}
+	switch {
+	case errors.Is(err, service.ErrReauthenticationRequired):
+		// RFC 9470: клиент должен заново подтвердить личность пользователя
+		c.Response().Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication"`)
+		return newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrInvalidCurrentPassword), errors.Is(err, errServiceClientStepUp):
+		return newErrorResponse(c, http.StatusForbidden, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
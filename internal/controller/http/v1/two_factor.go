@@ -0,0 +1,204 @@
+package v1
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// EnrollTwoFactor godoc
+//
+//	@Summary		Start two-factor enrollment
+//	@Description	Generate a new TOTP secret and otpauth URI (user themselves). 2FA is enabled after confirmation.
+//	@Description	Requires currentPassword in the body or a recent /auth/reauthenticate.
+//	@Tags			2fa
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int					true	"User ID"
+//	@Param			input	body		entities.StepUpInput	false	"Current password"
+//	@Success		200		{object}	entities.TwoFactorEnrollment
+//	@Failure		400		{object}	statusResponse	"invalid user id"
+//	@Failure		401		{object}	statusResponse	"recent authentication required"
+//	@Failure		403		{object}	statusResponse	"access denied or wrong current password"
+//	@Failure		409	{object}	statusResponse	"two-factor authentication is already enabled"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/2fa [post]
+func (h *Handler) EnrollTwoFactor(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	// Управлять 2FA может только сам пользователь
+	if currentUserId, err := getUserId(c); err != nil || currentUserId != userId {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	var input entities.StepUpInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := h.requireRecentAuth(c, input.CurrentPassword); err != nil {
+		return stepUpErrorResponse(c, err)
+	}
+
+	enrollment, err := h.services.TwoFactor.Enroll(c.Request().Context(), userId)
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTwoFactor godoc
+//
+//	@Summary		Confirm two-factor enrollment
+//	@Description	Enable 2FA with a code from the authenticator app and get one-time recovery codes (user themselves).
+//	@Description	Requires currentPassword in the body or a recent /auth/reauthenticate.
+//	@Tags			2fa
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int							true	"User ID"
+//	@Param			input	body		entities.TwoFactorCodeInput	true	"TOTP code"
+//	@Success		200		{object}	entities.RecoveryCodes
+//	@Failure		400		{object}	statusResponse	"invalid request"
+//	@Failure		401		{object}	statusResponse	"recent authentication required"
+//	@Failure		403		{object}	statusResponse	"access denied or wrong current password"
+//	@Failure		409		{object}	statusResponse	"two-factor authentication is already enabled"
+//	@Failure		429		{object}	statusResponse	"too many failed attempts"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactor(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	// Управлять 2FA может только сам пользователь
+	if currentUserId, err := getUserId(c); err != nil || currentUserId != userId {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	var input entities.TwoFactorCodeInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateTwoFactorCodeInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+	if err := h.requireRecentAuth(c, input.CurrentPassword); err != nil {
+		return stepUpErrorResponse(c, err)
+	}
+
+	codes, err := h.services.TwoFactor.Confirm(c.Request().Context(), userId, input.Code, c.RealIP())
+	if err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, entities.RecoveryCodes{
+		RecoveryCodes: codes,
+	})
+}
+
+// DisableTwoFactor godoc
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Disable 2FA with a current TOTP or recovery code (user themselves).
+//	@Description	Requires currentPassword in the body or a recent /auth/reauthenticate.
+//	@Tags			2fa
+//	@Accept			json
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int							true	"User ID"
+//	@Param			input	body		entities.TwoFactorCodeInput	true	"TOTP or recovery code"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid request"
+//	@Failure		401		{object}	statusResponse	"recent authentication required"
+//	@Failure		403		{object}	statusResponse	"access denied or wrong current password"
+//	@Failure		429		{object}	statusResponse	"too many failed attempts"
+//	@Failure		500		{object}	statusResponse	"internal server error"
+//	@Router			/api/users/{id}/2fa [delete]
+func (h *Handler) DisableTwoFactor(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	// Управлять 2FA может только сам пользователь
+	if currentUserId, err := getUserId(c); err != nil || currentUserId != userId {
+		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	}
+
+	var input entities.TwoFactorCodeInput
+	if err := c.Bind(&input); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+	}
+	if err := input.ValidateTwoFactorCodeInput(); err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+	if err := h.requireRecentAuth(c, input.CurrentPassword); err != nil {
+		return stepUpErrorResponse(c, err)
+	}
+
+	if err := h.services.TwoFactor.Disable(c.Request().Context(), userId, input.Code, c.RealIP()); err != nil {
+		return twoFactorErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// ResetTwoFactor godoc
+//
+//	@Summary		Reset two-factor authentication
+//	@Description	Disable 2FA and remove recovery codes of a user (requires the users:write permission)
+//	@Tags			admin
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int				true	"User ID"
+//	@Success		200	{object}	statusResponse	"ok"
+//	@Failure		400	{object}	statusResponse	"invalid user id"
+//	@Failure		404	{object}	statusResponse	"user not found"
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/admin/users/{id}/2fa [delete]
+func (h *Handler) AdminResetTwoFactor(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
+	}
+
+	if err := h.services.TwoFactor.Reset(c.Request().Context(), userId); err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't reset two-factor authentication; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, statusResponse{
+		Status: "ok",
+	})
+}
+
+// twoFactorErrorResponse преобразует ошибку 2FA в HTTP-ответ
+func twoFactorErrorResponse(c echo.Context, err error) error {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return newErrorResponse(c, http.StatusTooManyRequests, err.Error())
+	}
+	switch {
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		return newErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrTwoFactorNotEnrolled),
+		errors.Is(err, service.ErrTwoFactorNotEnabled):
+		return newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,21 +1,38 @@
 package v1
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/kolibriee/users-rest-api/internal/entities"
 	bun_entities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/service"
 	"github.com/labstack/echo/v4"
 )
 
 var _ = bun_entities.User{}
 
+// GetUsers godoc
+//
+//	@Summary		Get users
+//	@Description	Get users the caller may read: their own account, every user with the users:read permission,
+//	@Description	or users allowed by the access policy, for example users of the same city
+//	@Tags			users
+//	@Produce		json
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		bun_entities.User
+//	@Failure		500	{object}	statusResponse	"internal server error"
+//	@Router			/api/users [get]
+func (h *Handler) GetUsers(c echo.Context) error {
+	return h.usersResponse(c, c.Request().Context())
+}
+
 // GetUserByID godoc
 //
 //	@Summary		Get user by ID
-//	@Description	Get a user by their ID (admin or user themselves)
+//	@Description	Get a user by their ID (user themselves, the users:read permission or an access policy allow rule)
 //	@Tags			users
 //	@Produce		json
 //	@Security		ApiKeyAuth
@@ -23,6 +40,7 @@ var _ = bun_entities.User{}
 //	@Success		200	{object}	bun_entities.User
 //	@Failure		400	{object}	statusResponse	"invalid user id"
 //	@Failure		403	{object}	statusResponse	"access denied"
+//	@Failure		404	{object}	statusResponse	"user not found"
 //	@Failure		500	{object}	statusResponse	"internal server error"
 //	@Router			/api/users/{id} [get]
 func (h *Handler) GetUserByID(c echo.Context) error {
@@ -32,26 +50,22 @@ func (h *Handler) GetUserByID(c echo.Context) error {
 		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
 	}
 
-	// Извлечение текущего ID пользователя из контекста
-	currentUserId, err := getUserId(c)
-	if err != nil {
-		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
-	}
-
-	// Извлечение роли из контекста
-	role, err := getRole(c)
-	if err != nil {
+	// Запрос выполняет пользователь или сервисный клиент
+	if _, err := getUserId(c); err != nil && !isServiceClientRequest(c) {
 		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
 	}
 
-	// Проверка прав доступа
-	if role != "admin" && currentUserId != userId {
-		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	// Проверка прав доступа: своя учетная запись, право роли или политика доступа
+	if err := h.authorizeUser(c, userId, entities.AccessRead); err != nil {
+		return accessErrorResponse(c, err)
 	}
 
 	// Получение пользователя по ID
 	user, err := h.services.GetUserByID(c.Request().Context(), userId)
 	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
 		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get user; ").Error()+err.Error())
 	}
 
@@ -61,7 +75,9 @@ func (h *Handler) GetUserByID(c echo.Context) error {
 // UpdateUser godoc
 //
 //	@Summary		Update a user
-//	@Description	Update user information (admin or user themselves)
+//	@Description	Update user information (user themselves, the users:write permission or an access policy allow rule).
+//	@Description	Changing the role requires the roles:assign permission. Changing the password, username or email requires
+//	@Description	the caller's currentPassword in the body or a recent /auth/reauthenticate.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -69,8 +85,11 @@ func (h *Handler) GetUserByID(c echo.Context) error {
 //	@Param			id		path		int							true	"User ID"
 //	@Param			user	body		entities.UserUpdateInput	true	"Updated user data"
 //	@Success		200		{object}	statusResponse				"ok"
-//	@Failure		400		{object}	statusResponse				"invalid request"
-//	@Failure		403		{object}	statusResponse				"access denied"
+//	@Failure		400		{object}	passwordErrorResponse		"invalid request or password rejected by the policy"
+//	@Failure		401		{object}	statusResponse				"recent authentication required"
+//	@Failure		403		{object}	statusResponse				"access denied or wrong current password"
+//	@Failure		404		{object}	statusResponse				"user not found"
+//	@Failure		429		{object}	statusResponse				"too many wrong passwords"
 //	@Failure		500		{object}	statusResponse				"internal server error"
 //	@Router			/api/users/{id} [put]
 func (h *Handler) UpdateUser(c echo.Context) error {
@@ -80,21 +99,14 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
 	}
 
-	// Извлечение текущего ID пользователя из контекста
-	currentUserId, err := getUserId(c)
-	if err != nil {
+	// Запрос выполняет пользователь или сервисный клиент
+	if _, err := getUserId(c); err != nil && !isServiceClientRequest(c) {
 		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
 	}
 
-	// Извлечение роли из контекста
-	role, err := getRole(c)
-	if err != nil {
-		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
-	}
-
-	// Проверка прав доступа
-	if role != "admin" && currentUserId != userId {
-		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	// Проверка прав доступа: своя учетная запись, право роли или политика доступа
+	if err := h.authorizeUser(c, userId, entities.AccessUpdate); err != nil {
+		return accessErrorResponse(c, err)
 	}
 
 	var user entities.UserUpdateInput
@@ -103,11 +115,38 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 	}
 
 	// Валидация данных обновления пользователя
-	if err := user.ValidateUserUpdate(role); err != nil {
+	if err := user.ValidateUserUpdate(); err != nil {
 		return newErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
 
+	// Смена роли требует отдельного права, даже при праве на изменение пользователей
+	if user.Role != nil && !h.hasPermission(c, entities.PermissionRolesAssign) {
+		return newErrorResponse(c, http.StatusForbidden, service.ErrRoleAssignmentDenied.Error())
+	}
+
+	// Администратор от имени пользователя не может менять пароль и роль
+	if isImpersonated(c) && (user.Password != nil || user.Role != nil) {
+		return denyImpersonated(c)
+	}
+
+	// Смена пароля, username или email требует недавнего подтверждения личности
+	if user.Password != nil || user.Username != nil || user.Email != nil {
+		if err := h.requireRecentAuth(c, user.CurrentPassword); err != nil {
+			return stepUpErrorResponse(c, err)
+		}
+	}
+
 	if err := h.services.UpdateUser(c.Request().Context(), userId, user); err != nil {
+		var policyErr *entities.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			return newPasswordErrorResponse(c, policyErr) // Пароль не соответствует политике
+		}
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return newErrorResponse(c, http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, service.ErrUserNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
 		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't update user; ").Error()+err.Error())
 	}
 
@@ -119,14 +158,20 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 // DeleteUser godoc
 //
 //	@Summary		Delete a user
-//	@Description	Delete a user by their ID (admin or user themselves)
+//	@Description	Delete a user by their ID (user themselves, the users:write permission or an access policy allow rule).
+//	@Description	Deleting your own account requires currentPassword in the body or a recent /auth/reauthenticate.
 //	@Tags			users
+//	@Accept			json
 //	@Produce		json
 //	@Security		ApiKeyAuth
-//	@Param			id	path		int				true	"User ID"
-//	@Success		200	{object}	statusResponse	"ok"
-//	@Failure		400	{object}	statusResponse	"invalid user id"
-//	@Failure		403	{object}	statusResponse	"access denied"
+//	@Param			id		path		int					true	"User ID"
+//	@Param			input	body		entities.StepUpInput	false	"Current password"
+//	@Success		200		{object}	statusResponse	"ok"
+//	@Failure		400		{object}	statusResponse	"invalid user id"
+//	@Failure		401		{object}	statusResponse	"recent authentication required"
+//	@Failure		403		{object}	statusResponse	"access denied or wrong current password"
+//	@Failure		404		{object}	statusResponse	"user not found"
+//	@Failure		429		{object}	statusResponse	"too many wrong passwords"
 //	@Failure		500	{object}	statusResponse	"internal server error"
 //	@Router			/api/users/{id} [delete]
 func (h *Handler) DeleteUser(c echo.Context) error {
@@ -136,25 +181,33 @@ func (h *Handler) DeleteUser(c echo.Context) error {
 		return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid user id").Error())
 	}
 
-	// Извлечение текущего ID пользователя из контекста
+	// Извлечение текущего ID пользователя из контекста; у сервисного клиента его нет
 	currentUserId, err := getUserId(c)
-	if err != nil {
+	if err != nil && !isServiceClientRequest(c) {
 		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
 	}
 
-	// Извлечение роли из контекста
-	role, err := getRole(c)
-	if err != nil {
-		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	// Проверка прав доступа: своя учетная запись, право роли или политика доступа
+	if err := h.authorizeUser(c, userId, entities.AccessDelete); err != nil {
+		return accessErrorResponse(c, err)
 	}
 
-	// Проверка прав доступа
-	if role != "admin" && currentUserId != userId {
-		return newErrorResponse(c, http.StatusForbidden, errors.New("access denied").Error())
+	// Удаление своей учетной записи требует недавнего подтверждения личности
+	if currentUserId == userId {
+		var input entities.StepUpInput
+		if err := c.Bind(&input); err != nil {
+			return newErrorResponse(c, http.StatusBadRequest, errors.New("invalid request").Error())
+		}
+		if err := h.requireRecentAuth(c, input.CurrentPassword); err != nil {
+			return stepUpErrorResponse(c, err)
+		}
 	}
 
 	// Удаление пользователя из сервиса
 	if err := h.services.DeleteUser(c.Request().Context(), userId); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return newErrorResponse(c, http.StatusNotFound, err.Error())
+		}
 		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't delete user; ").Error()+err.Error())
 	}
 
@@ -162,3 +215,22 @@ func (h *Handler) DeleteUser(c echo.Context) error {
 		Status: "ok",
 	})
 }
+
+// usersResponse отвечает списком пользователей без тех, кого запрос не может читать по правам роли и политикам доступа
+func (h *Handler) usersResponse(c echo.Context, ctx context.Context) error {
+	users, err := h.services.GetAllUsers(ctx) // Получение всех пользователей
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't get users; ").Error()+err.Error())
+	}
+
+	subject, err := h.accessSubject(c)
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+	users, err = h.services.FilterUsers(ctx, subject, entities.AccessRead, users) // Фильтрация вместо отказа
+	if err != nil {
+		return newErrorResponse(c, http.StatusInternalServerError, errors.New("can't check access; ").Error()+err.Error())
+	}
+
+	return c.JSON(http.StatusOK, users)
+}
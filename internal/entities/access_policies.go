@@ -0,0 +1,61 @@
+package entities
+
+import "errors"
+
+// Действия с учетной записью пользователя, которые проверяют политики доступа.
+// Список пользователей фильтруется по правилам для чтения.
+const (
+	AccessRead   = "read"
+	AccessUpdate = "update"
+	AccessDelete = "delete"
+)
+
+// Результат правила политики доступа. Запрещающие правила важнее любых прав.
+const (
+	AccessAllow = "allow"
+	AccessDeny  = "deny"
+)
+
+// AccessRule — правило политики доступа из файла auth.accessPolicy.file.
+type AccessRule struct {
+	Name             string           `mapstructure:"name"`
+	Effect           string           `mapstructure:"effect"`
+	Actions          []string         `mapstructure:"actions"`
+	Subject          AccessAttributes `mapstructure:"subject"`          // Кто выполняет запрос
+	Target           AccessAttributes `mapstructure:"target"`           // С каким пользователем
+	SameCity         bool             `mapstructure:"sameCity"`         // Город пользователя совпадает с городом субъекта
+	SameOrganization bool             `mapstructure:"sameOrganization"` // Пользователь состоит в активной организации субъекта
+}
+
+// AccessAttributes ограничивает правило ролями, городами и организациями; пустой список подходит всем.
+type AccessAttributes struct {
+	Roles         []string `mapstructure:"roles"`
+	Cities        []string `mapstructure:"cities"`
+	Organizations []int    `mapstructure:"organizations"`
+}
+
+func (rule *AccessRule) ValidateAccessRule() error {
+	if rule.Name == "" {
+		return errors.New("access rule without name")
+	}
+	if rule.Effect != AccessAllow && rule.Effect != AccessDeny {
+		return errors.New("access rule " + rule.Name + ": effect must be allow or deny")
+	}
+	if len(rule.Actions) == 0 {
+		return errors.New("access rule " + rule.Name + ": no actions")
+	}
+	for _, action := range rule.Actions {
+		if action != AccessRead && action != AccessUpdate && action != AccessDelete {
+			return errors.New("access rule " + rule.Name + ": unknown action " + action)
+		}
+	}
+	return nil
+}
+
+// AccessSubject описывает того, кто выполняет запрос к пользователю.
+type AccessSubject struct {
+	UserID         int      // 0 у сервисного клиента
+	Role           string   // Роль запроса: глобальная или роль участника организации
+	OrganizationID int      // Активная организация из access token, 0 — без организации
+	Permissions    []string // Права роли запроса
+}
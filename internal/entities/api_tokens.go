@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// Виды API-токенов
+const (
+	APITokenKindPersonal = "personal" // Личный токен пользователя (PAT)
+	APITokenKindService  = "service"  // Ключ сервиса, выдается администратором
+)
+
+// Разрешения API-токенов
+const (
+	APIScopeRead  = "read"  // Чтение (GET-запросы)
+	APIScopeWrite = "write" // Изменение данных
+	APIScopeAdmin = "admin" // Маршруты /admin с правами роли владельца, только для ролей с правами
+)
+
+type APITokenInput struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"` // read, write, admin или scope вида ресурс:действие
+	ExpiresAt *time.Time `json:"expiresAt"`                        // Не задано — бессрочный токен
+}
+
+func (input *APITokenInput) ValidateAPITokenInput() error {
+	if err := validate.Struct(input); err != nil {
+		return err
+	}
+	if err := validateScopes(input.Scopes); err != nil {
+		return err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return errors.New("expiresAt must be in the future")
+	}
+	return nil
+}
+
+// APIToken описывает выданный API-токен без самого токена.
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"userId"`
+	Kind       string     `json:"kind"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// APITokenCreated возвращается один раз при создании токена.
+type APITokenCreated struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+// APITokenPrincipal — владелец API-токена, от имени которого выполняется запрос.
+type APITokenPrincipal struct {
+	TokenID        int
+	UserID         int
+	Role           string // Роль владельца; без scope для /admin — роль по умолчанию
+	Scopes         []string
+	OrganizationID *int // Первая организация владельца, как у access token после входа; nil — владелец вне организаций
+}
@@ -0,0 +1,99 @@
+package entities
+
+import "time"
+
+// LoginLockState описывает состояние блокировки входа для учетной записи.
+type LoginLockState struct {
+	UserID       int        `json:"userId"`
+	Username     string     `json:"username"`
+	Failures     int        `json:"failures"`
+	Locked       bool       `json:"locked"`
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty"`
+	LastFailedAt *time.Time `json:"lastFailedAt,omitempty"`
+}
+
+// SignInResult содержит результат входа: пару токенов либо,
+// если у пользователя включена 2FA, токен для второго шага входа.
+type SignInResult struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+}
+
+// RefreshTokenInput — refresh token в теле запроса, если клиент не использует куки.
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type TwoFactorSignInInput struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"` // Код TOTP или код восстановления
+}
+
+type TwoFactorCodeInput struct {
+	Code            string `json:"code" validate:"required"`
+	CurrentPassword string `json:"currentPassword,omitempty"` // Не нужен после недавнего /auth/reauthenticate
+}
+
+// StepUpInput — текущий пароль для важного действия без недавнего /auth/reauthenticate.
+type StepUpInput struct {
+	CurrentPassword string `json:"currentPassword"`
+}
+
+// OIDCLinkResponse — адрес провайдера, на который нужно перейти в браузере для привязки учетной записи.
+type OIDCLinkResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+type ReauthenticateInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code"` // Код TOTP или код восстановления, если включена 2FA
+}
+
+func (input *ReauthenticateInput) ValidateReauthenticateInput() error {
+	return validate.Struct(input)
+}
+
+// Reauthentication содержит access token с claim auth_time после повторного подтверждения личности.
+type Reauthentication struct {
+	AccessToken          string    `json:"accessToken"`
+	ReauthenticatedUntil time.Time `json:"reauthenticatedUntil"` // До какого момента важные изменения не требуют пароля
+}
+
+// TwoFactorEnrollment возвращается при подключении 2FA: секрет и URI для приложения-аутентификатора.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodes выдаются один раз после подтверждения 2FA.
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func (input *TwoFactorSignInInput) ValidateTwoFactorSignInInput() error {
+	return validate.Struct(input)
+}
+
+func (input *TwoFactorCodeInput) ValidateTwoFactorCodeInput() error {
+	return validate.Struct(input)
+}
+
+// SessionInfo описывает сессию пользователя без refresh token.
+type SessionInfo struct {
+	ID         int       `json:"id"`
+	ClientID   string    `json:"clientId,omitempty"` // OAuth-клиент, если сессия открыта через /oauth/token
+	Device     string    `json:"device"`             // Браузер и ОС, например "Chrome 126 on Windows"
+	UserAgent  string    `json:"userAgent,omitempty"`
+	CreatedIP  string    `json:"createdIp,omitempty"`
+	LastUsedIP string    `json:"lastUsedIp,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// ClientInfo — сведения о клиенте, который открывает или продлевает сессию.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
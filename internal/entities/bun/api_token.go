@@ -0,0 +1,23 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type APIToken struct {
+	bun.BaseModel `bun:"table:api_tokens,alias:at"`
+
+	ID          int        `bun:"id,pk,autoincrement"`
+	UserID      int        `bun:"user_id,notnull"`
+	Kind        string     `bun:"kind,notnull"` // personal — токен пользователя, service — ключ сервиса, выданный администратором
+	Name        string     `bun:"name,notnull"`
+	TokenHash   string     `bun:"token_hash,notnull"`
+	TokenPrefix string     `bun:"token_prefix,notnull"` // Начало токена, по которому его можно узнать в списке
+	Scopes      []string   `bun:"scopes,array"`
+	ExpiresAt   *time.Time `bun:"expires_at"` // NULL — бессрочный токен
+	LastUsedAt  *time.Time `bun:"last_used_at"`
+	LastUsedIP  string     `bun:"last_used_ip,nullzero"`
+	CreatedAt   time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+}
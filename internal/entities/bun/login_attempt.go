@@ -0,0 +1,16 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type LoginAttempt struct {
+	bun.BaseModel `bun:"table:login_attempts,alias:la"`
+
+	Key          string     `bun:"key,pk"`
+	Failures     int        `bun:"failures,notnull"`
+	LastFailedAt time.Time  `bun:"last_failed_at,notnull,default:current_timestamp"`
+	LockedUntil  *time.Time `bun:"locked_until"`
+}
@@ -0,0 +1,35 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type OAuthClient struct {
+	bun.BaseModel `bun:"table:oauth_clients,alias:oc"`
+
+	ID               int       `bun:"id,pk,autoincrement"`
+	ClientID         string    `bun:"client_id,notnull"`
+	ClientSecretHash string    `bun:"client_secret_hash,nullzero"` // Пусто у публичных клиентов (SPA, мобильные приложения)
+	Name             string    `bun:"name,notnull"`
+	RedirectURIs     []string  `bun:"redirect_uris,array"`
+	Scopes           []string  `bun:"scopes,array"`        // Scope, которые клиент может запросить
+	GrantTypes       []string  `bun:"grant_types,array"`   // Разрешенные клиенту grant_type
+	PublicKey        string    `bun:"public_key,nullzero"` // PEM открытого ключа для аутентификации подписанным JWT
+	Role             string    `bun:"role,nullzero"`       // Роль, права которой получает сервисный клиент
+	CreatedAt        time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+type OAuthCode struct {
+	bun.BaseModel `bun:"table:oauth_codes,alias:ocd"`
+
+	CodeHash            string    `bun:"code_hash,pk"`
+	ClientID            string    `bun:"client_id,notnull"`
+	UserID              int       `bun:"user_id,notnull"`
+	RedirectURI         string    `bun:"redirect_uri,notnull"`
+	RedirectURISupplied bool      `bun:"redirect_uri_supplied,notnull"` // redirect_uri был в запросе авторизации и обязателен при обмене кода
+	Scope               string    `bun:"scope,notnull"`
+	CodeChallenge       string    `bun:"code_challenge,notnull"` // PKCE, только метод S256
+	ExpiresAt           time.Time `bun:"expires_at,notnull"`
+}
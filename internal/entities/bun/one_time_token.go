@@ -0,0 +1,18 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// OneTimeToken — одноразовый токен из письма (сброс пароля и т. п.). Хранится только хэш.
+type OneTimeToken struct {
+	bun.BaseModel `bun:"table:one_time_tokens,alias:ott"`
+
+	TokenHash string    `bun:"token_hash,pk"`
+	UserID    int       `bun:"user_id,notnull"`
+	Purpose   string    `bun:"purpose,notnull"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
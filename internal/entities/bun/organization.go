@@ -0,0 +1,27 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Organization struct {
+	bun.BaseModel `bun:"table:organizations,alias:o"`
+
+	ID        int       `bun:"id,pk,autoincrement"`
+	Name      string    `bun:"name,notnull"`
+	Slug      string    `bun:"slug,notnull"` // Уникальное короткое имя для URL и интерфейса
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+type OrganizationMember struct {
+	bun.BaseModel `bun:"table:organization_members,alias:om"`
+
+	OrganizationID int           `bun:"organization_id,pk"`
+	UserID         int           `bun:"user_id,pk"`
+	Role           string        `bun:"role,notnull"` // Роль пользователя внутри организации, не глобальная роль
+	CreatedAt      time.Time     `bun:"created_at,notnull,default:current_timestamp"`
+	Organization   *Organization `bun:"rel:belongs-to,join:organization_id=id"`
+	User           *User         `bun:"rel:belongs-to,join:user_id=id"`
+}
@@ -0,0 +1,16 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PasswordHistory struct {
+	bun.BaseModel `bun:"table:password_history,alias:ph"`
+
+	ID           int       `bun:"id,pk,autoincrement"`
+	UserID       int       `bun:"user_id,notnull"`
+	PasswordHash string    `bun:"password_hash,notnull"`
+	CreatedAt    time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,15 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type RateLimit struct {
+	bun.BaseModel `bun:"table:rate_limits,alias:rl"`
+
+	Key             string    `bun:"key,pk"`
+	Requests        int       `bun:"requests,notnull"`
+	WindowStartedAt time.Time `bun:"window_started_at,notnull,default:current_timestamp"`
+}
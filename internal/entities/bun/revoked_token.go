@@ -0,0 +1,14 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type RevokedToken struct {
+	bun.BaseModel `bun:"table:revoked_tokens,alias:rt"`
+
+	JTI       string    `bun:"jti,pk"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+}
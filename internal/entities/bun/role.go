@@ -0,0 +1,31 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Role struct {
+	bun.BaseModel `bun:"table:roles,alias:r"`
+
+	Name        string    `bun:"name,pk"`
+	Description string    `bun:"description,notnull"`
+	Builtin     bool      `bun:"builtin,notnull"`    // Встроенные роли admin и user нельзя удалить
+	IsDefault   bool      `bun:"is_default,notnull"` // Роль новых пользователей, ровно одна
+	CreatedAt   time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+type Permission struct {
+	bun.BaseModel `bun:"table:permissions,alias:p"`
+
+	Name        string `bun:"name,pk"`
+	Description string `bun:"description,notnull"`
+}
+
+type RolePermission struct {
+	bun.BaseModel `bun:"table:role_permissions,alias:rp"`
+
+	Role       string `bun:"role,pk"`
+	Permission string `bun:"permission,pk"`
+}
@@ -0,0 +1,18 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type SecurityEvent struct {
+	bun.BaseModel `bun:"table:security_events,alias:se"`
+
+	ID        int                    `bun:"id,pk,autoincrement"`
+	UserID    *int                   `bun:"user_id"`
+	ActorID   *int                   `bun:"actor_id"` // Администратор, действовавший от имени пользователя
+	Type      string                 `bun:"type,notnull"`
+	Details   map[string]interface{} `bun:"details,type:jsonb"`
+	CreatedAt time.Time              `bun:"created_at,notnull,default:current_timestamp"`
+}
@@ -9,8 +9,19 @@ import (
 type Session struct {
 	bun.BaseModel `bun:"table:sessions,alias:s"`
 
-	ID           int       `bun:"id,pk,autoincrement"`
-	UserID       int       `bun:"user_id,notnull"`
-	RefreshToken string    `bun:"refresh_token,notnull"`
-	ExpiresAt    time.Time `bun:"expires_at,notnull"`
+	ID             int        `bun:"id,pk,autoincrement"`
+	UserID         int        `bun:"user_id,notnull"`
+	RefreshToken   string     `bun:"refresh_token,notnull"`
+	FamilyID       string     `bun:"family_id,notnull"` // Общий ID всех сессий, полученных ротацией из одного входа
+	ExpiresAt      time.Time  `bun:"expires_at,notnull"`
+	RotatedAt      *time.Time `bun:"rotated_at"`         // Заполнено у использованных refresh token
+	ClientID       string     `bun:"client_id,nullzero"` // OAuth-клиент, для которого открыта сессия
+	Scope          string     `bun:"scope,nullzero"`
+	OrganizationID *int       `bun:"organization_id"`                              // Активная организация, переносится при ротации
+	CreatedAt      time.Time  `bun:"created_at,notnull,default:current_timestamp"` // Время входа, переносится при ротации
+	LastUsedAt     time.Time  `bun:"last_used_at,notnull,default:current_timestamp"`
+	CreatedIP      string     `bun:"created_ip,nullzero"` // IP при входе
+	LastUsedIP     string     `bun:"last_used_ip,nullzero"`
+	UserAgent      string     `bun:"user_agent,nullzero"`
+	Device         string     `bun:"device,nullzero"` // Браузер и ОС, определенные по User-Agent
 }
@@ -0,0 +1,27 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type TwoFactor struct {
+	bun.BaseModel `bun:"table:two_factor,alias:tf"`
+
+	UserID       int        `bun:"user_id,pk"`
+	Secret       string     `bun:"secret,notnull"`
+	Enabled      bool       `bun:"enabled,notnull"`
+	LastUsedStep int64      `bun:"last_used_step,notnull"`
+	CreatedAt    time.Time  `bun:"created_at,notnull,default:current_timestamp"`
+	EnabledAt    *time.Time `bun:"enabled_at"`
+}
+
+type RecoveryCode struct {
+	bun.BaseModel `bun:"table:recovery_codes,alias:rc"`
+
+	ID       int        `bun:"id,pk,autoincrement"`
+	UserID   int        `bun:"user_id,notnull"`
+	CodeHash string     `bun:"code_hash,notnull"`
+	UsedAt   *time.Time `bun:"used_at"`
+}
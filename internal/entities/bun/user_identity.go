@@ -0,0 +1,30 @@
+package bun_entities
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// UserIdentity связывает пользователя с учетной записью у внешнего провайдера OpenID Connect.
+type UserIdentity struct {
+	bun.BaseModel `bun:"table:user_identities,alias:ui"`
+
+	ID        int       `bun:"id,pk,autoincrement"`
+	UserID    int       `bun:"user_id,notnull"`
+	Provider  string    `bun:"provider,notnull"`
+	Subject   string    `bun:"subject,notnull"` // Claim sub из ID token провайдера
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+// OIDCLoginState хранит параметры начатого входа через провайдера до возврата пользователя.
+type OIDCLoginState struct {
+	bun.BaseModel `bun:"table:oidc_login_states,alias:ols"`
+
+	StateHash    string    `bun:"state_hash,pk"`
+	Provider     string    `bun:"provider,notnull"`
+	Nonce        string    `bun:"nonce,notnull"`
+	CodeVerifier string    `bun:"code_verifier,notnull"`
+	LinkUserID   *int      `bun:"link_user_id"` // Вход начат вошедшим пользователем, чтобы привязать к нему учетную запись провайдера
+	ExpiresAt    time.Time `bun:"expires_at,notnull"`
+}
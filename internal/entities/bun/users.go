@@ -9,11 +9,15 @@ import (
 type User struct {
 	bun.BaseModel `bun:"table:users,alias:u"`
 
-	ID           int       `bun:"id,pk,autoincrement"`
-	Role         string    `bun:"role,notnull"`
-	Name         string    `bun:"name,notnull"`
-	Username     string    `bun:"username,notnull"`
-	PasswordHash string    `bun:"password_hash,notnull"`
-	City         string    `bun:"city,notnull"`
-	RegisteredAt time.Time `bun:"registered_at,notnull,default:current_timestamp"`
+	ID                int        `bun:"id,pk,autoincrement"`
+	Role              string     `bun:"role,notnull"`
+	Name              string     `bun:"name,notnull"`
+	Username          string     `bun:"username,notnull"`
+	Email             *string    `bun:"email" json:"email,omitempty"`
+	EmailVerifiedAt   *time.Time `bun:"email_verified_at" json:"emailVerifiedAt,omitempty"`
+	PasswordHash      string     `bun:"password_hash,notnull"`
+	PasswordChangedAt *time.Time `bun:"password_changed_at" json:"-"` // Время последней смены пароля (не пересчета хэша)
+	City              string     `bun:"city,notnull"`
+	RegisteredAt      time.Time  `bun:"registered_at,notnull,default:current_timestamp"`
+	TokenVersion      int        `bun:"token_version,notnull,default:0" json:"-"`
 }
@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+type ImpersonationInput struct {
+	Reason string `json:"reason" validate:"required,max=500"` // Зачем администратору нужен доступ, попадает в журнал
+}
+
+func (input *ImpersonationInput) ValidateImpersonationInput() error {
+	return validate.Struct(input)
+}
+
+// ImpersonationToken — короткоживущий access token для работы от имени пользователя.
+type ImpersonationToken struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	UserID      int       `json:"userId"`
+	ActorID     int       `json:"actorId"`
+}
+
+// ImpersonatedRequest описывает запрос, выполненный администратором от имени пользователя.
+type ImpersonatedRequest struct {
+	UserID  int
+	ActorID int
+	TokenID string // jti токена имперсонации
+	Method  string
+	Path    string
+	Status  int
+	IP      string
+}
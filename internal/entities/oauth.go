@@ -0,0 +1,180 @@
+package entities
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+// Типы grant, которые можно разрешить OAuth-клиенту
+const (
+	OAuthGrantAuthorizationCode = "authorization_code"
+	OAuthGrantRefreshToken      = "refresh_token"
+	OAuthGrantClientCredentials = "client_credentials" // Сервисный клиент получает токен от своего имени
+)
+
+// Способы аутентификации OAuth-клиента на token endpoint
+const (
+	OAuthAuthMethodNone          = "none"
+	OAuthAuthMethodClientSecret  = "client_secret"
+	OAuthAuthMethodPrivateKeyJWT = "private_key_jwt"
+)
+
+type OAuthClientInput struct {
+	Name         string   `json:"name" validate:"required"`
+	RedirectURIs []string `json:"redirectUris" validate:"omitempty,dive,url"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,required"`
+	GrantTypes   []string `json:"grantTypes" validate:"omitempty,dive,oneof=authorization_code refresh_token client_credentials"` // По умолчанию authorization_code и refresh_token
+	Public       bool     `json:"public"`                                                                                         // Публичный клиент (SPA, мобильное приложение) не получает секрет
+	PublicKey    string   `json:"publicKey"`                                                                                      // PEM открытого ключа: клиент подписывает им JWT вместо передачи секрета
+	Role         string   `json:"role"`                                                                                           // Роль, права которой получает сервисный клиент; без нее прав у клиента нет
+}
+
+func (input *OAuthClientInput) ValidateOAuthClientInput() error {
+	if err := validate.Struct(input); err != nil {
+		return err
+	}
+	if err := validateScopes(input.Scopes); err != nil {
+		return err
+	}
+
+	if len(input.GrantTypes) == 0 {
+		input.GrantTypes = []string{OAuthGrantAuthorizationCode, OAuthGrantRefreshToken}
+	}
+	if slices.Contains(input.GrantTypes, OAuthGrantAuthorizationCode) && len(input.RedirectURIs) == 0 {
+		return errors.New("redirectUris are required for the authorization_code grant")
+	}
+	if input.PublicKey != "" {
+		if input.Public {
+			return errors.New("public client can't have a public key")
+		}
+		if _, err := auth.ParsePublicKeyPEM([]byte(input.PublicKey)); err != nil {
+			return errors.New("invalid publicKey: " + err.Error())
+		}
+	}
+	// Сервисный клиент обязан подтверждать свою личность
+	if input.Public && slices.Contains(input.GrantTypes, OAuthGrantClientCredentials) {
+		return errors.New("public client can't use the client_credentials grant")
+	}
+	// Роль действует только на токены, которые клиент получает от своего имени
+	if input.Role != "" && !slices.Contains(input.GrantTypes, OAuthGrantClientCredentials) {
+		return errors.New("role requires the client_credentials grant")
+	}
+	return nil
+}
+
+// OAuthClient описывает зарегистрированного OAuth-клиента без секрета.
+type OAuthClient struct {
+	ClientID     string    `json:"clientId"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirectUris"`
+	Scopes       []string  `json:"scopes"`
+	GrantTypes   []string  `json:"grantTypes"`
+	AuthMethod   string    `json:"authMethod"` // none, client_secret или private_key_jwt
+	Public       bool      `json:"public"`
+	Role         string    `json:"role,omitempty"` // Роль сервисного клиента
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// OAuthClientCredentials возвращается один раз при регистрации клиента.
+type OAuthClientCredentials struct {
+	OAuthClient
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
+
+// OAuthAuthorizeRequest — параметры запроса авторизации (RFC 6749, раздел 4.1.1; RFC 7636).
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `query:"response_type" form:"response_type"`
+	ClientID            string `query:"client_id" form:"client_id"`
+	RedirectURI         string `query:"redirect_uri" form:"redirect_uri"`
+	Scope               string `query:"scope" form:"scope"`
+	State               string `query:"state" form:"state"`
+	CodeChallenge       string `query:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" form:"code_challenge_method"`
+	Consent             string `form:"consent"` // Решение пользователя: approve или deny
+}
+
+// Решение пользователя на шаге согласия
+const (
+	OAuthConsentApprove = "approve"
+	OAuthConsentDeny    = "deny"
+)
+
+// OAuthConsent описывает, какой доступ клиент просит у пользователя.
+type OAuthConsent struct {
+	ClientID    string   `json:"clientId"`
+	ClientName  string   `json:"clientName"`
+	Scopes      []string `json:"scopes"`
+	RedirectURI string   `json:"redirectUri"`
+}
+
+// OAuthAuthorizeResponse возвращается вместо перенаправления, если клиент запросил JSON.
+type OAuthAuthorizeResponse struct {
+	RedirectURI string `json:"redirectUri"`
+}
+
+// OAuthTokenRequest — параметры запроса к token endpoint (RFC 6749, разделы 4.1.3 и 6).
+type OAuthTokenRequest struct {
+	GrantType           string `form:"grant_type"`
+	Code                string `form:"code"`
+	RedirectURI         string `form:"redirect_uri"`
+	CodeVerifier        string `form:"code_verifier"`
+	RefreshToken        string `form:"refresh_token"`
+	Scope               string `form:"scope"`
+	ClientID            string `form:"client_id"`
+	ClientSecret        string `form:"client_secret"`
+	ClientAssertionType string `form:"client_assertion_type"`
+	ClientAssertion     string `form:"client_assertion"` // JWT, подписанный ключом клиента (RFC 7523)
+}
+
+// OAuthTokenResponse — успешный ответ token endpoint (RFC 6749, раздел 5.1).
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OAuthRevokeRequest — параметры запроса отзыва токена (RFC 7009).
+type OAuthRevokeRequest struct {
+	Token               string `form:"token"`
+	TokenTypeHint       string `form:"token_type_hint"`
+	ClientID            string `form:"client_id"`
+	ClientSecret        string `form:"client_secret"`
+	ClientAssertionType string `form:"client_assertion_type"`
+	ClientAssertion     string `form:"client_assertion"`
+}
+
+// Типы токенов в ответе introspection endpoint
+const (
+	OAuthTokenTypeAccessToken = "access_token"
+	OAuthTokenTypeAPIToken    = "api_token" // Личный токен пользователя или ключ сервиса
+)
+
+// OAuthIntrospectRequest — параметры запроса проверки токена (RFC 7662, раздел 2.1).
+type OAuthIntrospectRequest struct {
+	Token               string `form:"token"`
+	TokenTypeHint       string `form:"token_type_hint"`
+	ClientID            string `form:"client_id"`
+	ClientSecret        string `form:"client_secret"`
+	ClientAssertionType string `form:"client_assertion_type"`
+	ClientAssertion     string `form:"client_assertion"`
+}
+
+// OAuthIntrospection — ответ introspection endpoint (RFC 7662, раздел 2.2).
+// У недействительного токена заполнено только active.
+type OAuthIntrospection struct {
+	Active    bool        `json:"active"`
+	Sub       string      `json:"sub,omitempty"`  // ID пользователя или client_id сервисного клиента
+	Role      string      `json:"role,omitempty"` // Текущая роль владельца
+	Exp       int64       `json:"exp,omitempty"`  // Нет у бессрочного API-токена
+	Iat       int64       `json:"iat,omitempty"`
+	Scope     string      `json:"scope,omitempty"`
+	ClientID  string      `json:"client_id,omitempty"`
+	TokenType string      `json:"token_type,omitempty"` // access_token или api_token
+	Act       *auth.Actor `json:"act,omitempty"`        // Администратор, действующий от имени пользователя
+	Org       int         `json:"org,omitempty"`        // Активная организация пользователя
+}
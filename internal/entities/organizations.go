@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"errors"
+	"regexp"
+	"time"
+)
+
+// Права для управления организациями из /admin. Администраторы организаций получают права
+// из роли участника и управляют только своей организацией.
+const (
+	PermissionOrganizationsRead  = "organizations:read"
+	PermissionOrganizationsWrite = "organizations:write"
+)
+
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+type OrganizationInput struct {
+	Name string `json:"name" validate:"required,max=255"`
+	Slug string `json:"slug" validate:"required,max=64"`
+}
+
+func (input *OrganizationInput) ValidateOrganizationInput() error {
+	if err := validate.Struct(input); err != nil {
+		return err
+	}
+	if !organizationSlugPattern.MatchString(input.Slug) {
+		return errors.New("slug must contain only lowercase letters, digits and -")
+	}
+	return nil
+}
+
+// Organization описывает организацию (тенант).
+type Organization struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type MemberInput struct {
+	UserID int    `json:"userId" validate:"required,gt=0"`
+	Role   string `json:"role"` // Пустая роль — роль по умолчанию
+}
+
+func (input *MemberInput) ValidateMemberInput() error {
+	return validate.Struct(input)
+}
+
+type MemberUpdateInput struct {
+	Role string `json:"role" validate:"required"`
+}
+
+func (input *MemberUpdateInput) ValidateMemberUpdateInput() error {
+	return validate.Struct(input)
+}
+
+// Member описывает участника организации и его роль в ней.
+type Member struct {
+	UserID    int       `json:"userId"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserOrganization описывает организацию, в которой состоит пользователь.
+type UserOrganization struct {
+	Organization
+	Role   string `json:"role"`
+	Active bool   `json:"active"` // Организация выбрана в текущем access token
+}
@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Правила парольной политики, которые указываются в ошибке проверки
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+	PasswordRuleHistory      = "history"
+)
+
+// minPersonalInfoLength — личные данные короче этого не проверяются на вхождение в пароль.
+const minPersonalInfoLength = 4
+
+// PasswordPolicyError сообщает, какое правило парольной политики нарушено.
+type PasswordPolicyError struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password rejected (" + e.Rule + "): " + e.Message
+}
+
+func newPasswordPolicyError(rule string, message string) *PasswordPolicyError {
+	return &PasswordPolicyError{Rule: rule, Message: message}
+}
+
+// PasswordPolicy — требования к новому паролю. История паролей проверяется в сервисе.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	Breached      map[string]struct{} // Утекшие пароли в нижнем регистре
+}
+
+// Check проверяет пароль по правилам политики. personalInfo — имя пользователя, имя, город
+// и другие данные пользователя, которые нельзя использовать как пароль.
+// Возвращает *PasswordPolicyError с первым нарушенным правилом.
+func (p *PasswordPolicy) Check(password string, personalInfo ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return newPasswordPolicyError(PasswordRuleMinLength, "password must be at least "+strconv.Itoa(p.MinLength)+" characters long")
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return newPasswordPolicyError(PasswordRuleMaxLength, "password must be at most "+strconv.Itoa(p.MaxLength)+" characters long")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		return newPasswordPolicyError(PasswordRuleUppercase, "password must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		return newPasswordPolicyError(PasswordRuleLowercase, "password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		return newPasswordPolicyError(PasswordRuleDigit, "password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return newPasswordPolicyError(PasswordRuleSymbol, "password must contain a symbol")
+	}
+
+	normalized := strings.ToLower(password)
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if info == "" {
+			continue
+		}
+		if normalized == info || (utf8.RuneCountInString(info) >= minPersonalInfoLength && strings.Contains(normalized, info)) {
+			return newPasswordPolicyError(PasswordRulePersonalInfo, "password must not contain the username, name, city or email")
+		}
+	}
+
+	if _, ok := p.Breached[normalized]; ok {
+		return newPasswordPolicyError(PasswordRuleBreached, "password appears in a list of breached passwords")
+	}
+
+	return nil
+}
+
+// PasswordHistoryError возвращается, если пароль совпадает с одним из недавних паролей пользователя.
+func PasswordHistoryError(size int) error {
+	return newPasswordPolicyError(PasswordRuleHistory, "password must differ from the last "+strconv.Itoa(size)+" passwords")
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	strict := PasswordPolicy{
+		MinLength:     8,
+		MaxLength:     16,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		Breached:      map[string]struct{}{"p@ssw0rd!a": {}},
+	}
+
+	tests := []struct {
+		name         string
+		policy       PasswordPolicy
+		password     string
+		personalInfo []string
+		wantRule     string // Пусто — пароль принят
+	}{
+		{name: "valid", policy: strict, password: "Tr0ub4dor&3"},
+		{name: "too short", policy: strict, password: "Ab1!", wantRule: PasswordRuleMinLength},
+		{name: "length in characters", policy: PasswordPolicy{MinLength: 8}, password: "пароль12"},
+		{name: "too long", policy: strict, password: "Tr0ub4dor&3Tr0ub4dor&3", wantRule: PasswordRuleMaxLength},
+		{name: "no max length", policy: PasswordPolicy{MinLength: 8}, password: "correct horse battery staple"},
+		{name: "no uppercase", policy: strict, password: "tr0ub4dor&3", wantRule: PasswordRuleUppercase},
+		{name: "no lowercase", policy: strict, password: "TR0UB4DOR&3", wantRule: PasswordRuleLowercase},
+		{name: "no digit", policy: strict, password: "Troubador&x", wantRule: PasswordRuleDigit},
+		{name: "no symbol", policy: strict, password: "Tr0ub4dor33", wantRule: PasswordRuleSymbol},
+		{name: "space is a symbol", policy: strict, password: "Tr0ub4dor 3"},
+		{name: "contains username", policy: strict, password: "Alice-2024!x", personalInfo: []string{"alice"}, wantRule: PasswordRulePersonalInfo},
+		{name: "equals short info", policy: PasswordPolicy{MinLength: 3}, password: "Bob", personalInfo: []string{"bob"}, wantRule: PasswordRulePersonalInfo},
+		{name: "short info inside password", policy: strict, password: "Xbob-2024!x", personalInfo: []string{"bob"}},
+		{name: "empty info ignored", policy: strict, password: "Tr0ub4dor&3", personalInfo: []string{"", "  "}},
+		{name: "breached", policy: strict, password: "P@ssw0rd!A", wantRule: PasswordRuleBreached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.password, tt.personalInfo...)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("got %v, want the password accepted", err)
+				}
+				return
+			}
+			var policyErr *PasswordPolicyError
+			if !errors.As(err, &policyErr) || policyErr.Rule != tt.wantRule {
+				t.Errorf("got %v, want rule %s", err, tt.wantRule)
+			}
+		})
+	}
+}
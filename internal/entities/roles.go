@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+// Встроенные роли, которые создает миграция. Их нельзя удалить, а права admin нельзя изменить.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Права, которые проверяют маршруты. Список задается миграциями, роли получают права через API.
+// Свою учетную запись, сессии и токены пользователь может просматривать и менять без прав.
+const (
+	PermissionUsersRead         = "users:read"
+	PermissionUsersWrite        = "users:write"
+	PermissionUsersImpersonate  = "users:impersonate"
+	PermissionSessionsRead      = "sessions:read"
+	PermissionSessionsRevoke    = "sessions:revoke"
+	PermissionTokensRead        = "tokens:read"
+	PermissionTokensRevoke      = "tokens:revoke"
+	PermissionOAuthClientsRead  = "oauth_clients:read"
+	PermissionOAuthClientsWrite = "oauth_clients:write"
+	PermissionAPIKeysRead       = "api_keys:read"
+	PermissionAPIKeysWrite      = "api_keys:write"
+	PermissionRolesRead         = "roles:read"
+	PermissionRolesWrite        = "roles:write"
+	PermissionRolesAssign       = "roles:assign" // Смена роли пользователя
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// reservedRoleNames — имена, которые токены используют для владельцев, не являющихся пользователями.
+// Роль с таким именем выдавала бы себя за сервисного клиента; миграция запрещает их и в базе.
+var reservedRoleNames = []string{auth.RoleService, "system", "anonymous"}
+
+type RoleInput struct {
+	Name        string   `json:"name" validate:"required,max=55"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+	IsDefault   bool     `json:"isDefault"` // Назначать роль новым пользователям вместо текущей роли по умолчанию
+}
+
+func (input *RoleInput) ValidateRoleInput() error {
+	if err := validate.Struct(input); err != nil {
+		return err
+	}
+	if !roleNamePattern.MatchString(input.Name) {
+		return errors.New("role name must start with a letter and contain only lowercase letters, digits, _ and -")
+	}
+	if slices.Contains(reservedRoleNames, input.Name) {
+		return errors.New("role name " + input.Name + " is reserved")
+	}
+	return nil
+}
+
+type RoleUpdateInput struct {
+	Description *string   `json:"description" validate:"omitempty,max=255"`
+	Permissions *[]string `json:"permissions" validate:"omitempty,dive,required"` // Заменяет список прав целиком
+	IsDefault   *bool     `json:"isDefault"`                                      // Можно только назначить: у прежней роли по умолчанию признак снимается
+}
+
+func (input *RoleUpdateInput) ValidateRoleUpdateInput() error {
+	if input.Description == nil && input.Permissions == nil && input.IsDefault == nil {
+		return errors.New("update must have at least one of: description, permissions or isDefault")
+	}
+	if input.IsDefault != nil && !*input.IsDefault {
+		return errors.New("isDefault can only be set to true; make another role the default instead")
+	}
+	return validate.Struct(input)
+}
+
+// Role описывает роль вместе с ее правами.
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Builtin     bool      `json:"builtin"`
+	IsDefault   bool      `json:"isDefault"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Permission описывает право, которое можно выдать роли.
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
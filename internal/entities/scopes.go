@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Scope ограничивают токен частью прав пользователя. Маршрут требует scope вида ресурс:действие,
+// токен без scope действует со всеми правами пользователя. Права роли проверяются отдельно:
+// scope не дают больше, чем есть у пользователя.
+const (
+	ScopeProfileRead   = "profile:read"   // Своя учетная запись и список организаций
+	ScopeProfileWrite  = "profile:write"  // Изменение и удаление учетной записи, 2FA, повторный ввод пароля
+	ScopeSessionsRead  = "sessions:read"  // Список сессий
+	ScopeSessionsWrite = "sessions:write" // Завершение сессий
+	ScopeTokensRead    = "tokens:read"    // Список личных токенов
+	ScopeTokensWrite   = "tokens:write"   // Выдача и отзыв личных токенов
+	ScopeOrgRead       = "org:read"       // Чтение через /org
+	ScopeOrgWrite      = "org:write"      // Изменения через /org
+	ScopeAdminRead     = "admin:read"     // GET-запросы к /admin
+	ScopeAdminWrite    = "admin:write"    // Остальные запросы к /admin
+)
+
+// Scopes — все scope, которые можно запросить при входе и выдать токену. read и write подходят
+// к любому ресурсу, кроме admin: для него нужен еще scope admin.
+var Scopes = []string{
+	ScopeProfileRead, ScopeProfileWrite,
+	ScopeSessionsRead, ScopeSessionsWrite,
+	ScopeTokensRead, ScopeTokensWrite,
+	ScopeOrgRead, ScopeOrgWrite,
+	ScopeAdminRead, ScopeAdminWrite,
+	APIScopeRead, APIScopeWrite, APIScopeAdmin,
+}
+
+// legacyScopes — scope OAuth-клиентов, зарегистрированных до появления списка Scopes, и их замена
+var legacyScopes = map[string]string{
+	"openid":  ScopeProfileRead,
+	"profile": ScopeProfileRead,
+	"email":   ScopeProfileRead,
+}
+
+// NormalizeScopes переводит сохраненные scope в текущий список: устаревшие заменяются,
+// неизвестные отбрасываются. Если не осталось ни одного scope, выдается profile:read.
+func NormalizeScopes(scopes []string) []string {
+	var normalized []string
+	for _, scope := range scopes {
+		if replacement, ok := legacyScopes[scope]; ok {
+			scope = replacement
+		}
+		if slices.Contains(Scopes, scope) && !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return []string{ScopeProfileRead}
+	}
+	return normalized
+}
+
+// ScopeAllows сообщает, разрешают ли выданные scope действие, требующее scope required.
+func ScopeAllows(granted []string, required string) bool {
+	if slices.Contains(granted, required) {
+		return true
+	}
+	resource, action, ok := strings.Cut(required, ":")
+	if !ok || !slices.Contains(granted, action) {
+		return false
+	}
+	return resource != APIScopeAdmin || slices.Contains(granted, APIScopeAdmin)
+}
+
+// HasAdminScope сообщает, пускают ли scope к маршрутам /admin.
+func HasAdminScope(scopes []string) bool {
+	return slices.Contains(scopes, APIScopeAdmin) || slices.Contains(scopes, ScopeAdminRead) || slices.Contains(scopes, ScopeAdminWrite)
+}
+
+// validateScopes проверяет, что все scope известны.
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return errors.New("unknown scope " + scope)
+		}
+	}
+	return nil
+}
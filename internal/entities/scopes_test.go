@@ -0,0 +1,51 @@
+package entities
+
+import "testing"
+
+func TestScopeAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{name: "exact scope", granted: []string{ScopeProfileRead}, required: ScopeProfileRead, want: true},
+		{name: "other resource", granted: []string{ScopeProfileRead}, required: ScopeSessionsRead},
+		{name: "read does not allow write", granted: []string{ScopeProfileRead}, required: ScopeProfileWrite},
+		{name: "generic read", granted: []string{APIScopeRead}, required: ScopeSessionsRead, want: true},
+		{name: "generic read does not allow write", granted: []string{APIScopeRead}, required: ScopeSessionsWrite},
+		{name: "generic write", granted: []string{APIScopeWrite}, required: ScopeTokensWrite, want: true},
+		{name: "generic read without admin", granted: []string{APIScopeRead}, required: ScopeAdminRead},
+		{name: "generic read with admin", granted: []string{APIScopeRead, APIScopeAdmin}, required: ScopeAdminRead, want: true},
+		{name: "admin alone", granted: []string{APIScopeAdmin}, required: ScopeAdminRead},
+		{name: "admin read", granted: []string{ScopeAdminRead}, required: ScopeAdminRead, want: true},
+		{name: "no scopes", granted: nil, required: ScopeProfileRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopeAllows(tt.granted, tt.required); got != tt.want {
+				t.Errorf("ScopeAllows(%v, %s) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasAdminScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		want   bool
+	}{
+		{scopes: []string{APIScopeRead, APIScopeWrite}},
+		{scopes: []string{ScopeOrgWrite}},
+		{scopes: []string{APIScopeAdmin}, want: true},
+		{scopes: []string{ScopeAdminRead}, want: true},
+		{scopes: []string{ScopeProfileRead, ScopeAdminWrite}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := HasAdminScope(tt.scopes); got != tt.want {
+			t.Errorf("HasAdminScope(%v) = %v, want %v", tt.scopes, got, tt.want)
+		}
+	}
+}
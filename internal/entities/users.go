@@ -2,6 +2,7 @@ package entities
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -13,9 +14,10 @@ func init() {
 }
 
 type CreateUserInput struct {
-	Role     string `json:"role" validate:"required"`
+	Role     string `json:"role"` // Пустая роль — роль по умолчанию
 	Name     string `json:"name" validate:"required"`
 	Username string `json:"username" validate:"required"`
+	Email    string `json:"email" validate:"omitempty,email"`
 	Password string `json:"password" validate:"required"`
 	City     string `json:"city" validate:"required"`
 }
@@ -23,6 +25,7 @@ type CreateUserInput struct {
 type SignUpInput struct {
 	Name     string `json:"name" validate:"required"`
 	Username string `json:"username" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 	City     string `json:"city" validate:"required"`
 }
@@ -30,24 +33,22 @@ type SignUpInput struct {
 type SignInInput struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	Scope    string `json:"scope"` // Scope через пробел, например "profile:read"; пусто — все права пользователя
 }
 
 type UserUpdateInput struct {
 	Name     *string `json:"name"`
 	Username *string `json:"username"`
+	Email    *string `json:"email"` // Пустая строка удаляет адрес
 	Password *string `json:"password"`
 	City     *string `json:"city"`
 	Role     *string `json:"role"`
+
+	CurrentPassword string `json:"currentPassword,omitempty"` // Нужен для смены пароля, username и email без недавнего /auth/reauthenticate
 }
 
 func (input *CreateUserInput) ValidateCreateUserInput() error {
-	if err := validate.Struct(input); err != nil {
-		return err
-	}
-	if input.Role != "admin" && input.Role != "user" {
-		return errors.New("role must be admin or user")
-	}
-	return nil
+	return validate.Struct(input)
 }
 
 func (input *SignUpInput) ValidateSignUpInput() error {
@@ -55,13 +56,16 @@ func (input *SignUpInput) ValidateSignUpInput() error {
 }
 
 func (input *SignInInput) ValidateSignInInput() error {
-	return validate.Struct(input)
+	if err := validate.Struct(input); err != nil {
+		return err
+	}
+	return validateScopes(strings.Fields(input.Scope))
 }
 
-func (u UserUpdateInput) ValidateUserUpdate(role string) error {
+func (u UserUpdateInput) ValidateUserUpdate() error {
 	// Проверяем, что хотя бы одно поле для обновления не является nil
-	if u.Name == nil && u.Username == nil && u.Password == nil && u.City == nil && u.Role == nil {
-		return errors.New("update must have at least one of: name, username, password, city, or role")
+	if u.Name == nil && u.Username == nil && u.Email == nil && u.Password == nil && u.City == nil && u.Role == nil {
+		return errors.New("update must have at least one of: name, username, email, password, city, or role")
 	}
 
 	// Проверяем, что поля не пустые (если они не nil)
@@ -71,11 +75,62 @@ func (u UserUpdateInput) ValidateUserUpdate(role string) error {
 	if u.Username != nil && *u.Username == "" {
 		return errors.New("username must not be empty")
 	}
-
-	// Только админ может обновлять роль
-	if role != "admin" && u.Role != nil {
-		return errors.New("only admin can update role")
+	if u.Email != nil && *u.Email != "" && validate.Var(*u.Email, "email") != nil {
+		return errors.New("invalid email")
+	}
+	if u.Role != nil && *u.Role == "" {
+		return errors.New("role must not be empty")
 	}
 
 	return nil
 }
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"` // Токен из письма
+	Password string `json:"password" validate:"required"`
+}
+
+func (input *ForgotPasswordInput) ValidateForgotPasswordInput() error {
+	return validate.Struct(input)
+}
+
+func (input *ResetPasswordInput) ValidateResetPasswordInput() error {
+	return validate.Struct(input)
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"` // Токен из письма
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (input *VerifyEmailInput) ValidateVerifyEmailInput() error {
+	return validate.Struct(input)
+}
+
+func (input *ResendVerificationInput) ValidateResendVerificationInput() error {
+	return validate.Struct(input)
+}
+
+type MagicLinkInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (input *MagicLinkInput) ValidateMagicLinkInput() error {
+	return validate.Struct(input)
+}
+
+// MagicLinkSignInInput — токен из ссылки, которую страница подтверждения отправляет формой
+type MagicLinkSignInInput struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+func (input *MagicLinkSignInInput) ValidateMagicLinkSignInInput() error {
+	return validate.Struct(input)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+// apiTokenTouchInterval — как часто обновляется время последнего использования токена.
+const apiTokenTouchInterval = time.Minute
+
+type APITokenRepository struct {
+	db *bun.DB
+}
+
+func NewAPITokenRepository(db *bun.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// CreateAPIToken сохраняет токен и возвращает его ID.
+func (r *APITokenRepository) CreateAPIToken(ctx context.Context, token bunEntities.APIToken) (int, error) {
+	if _, err := r.db.NewInsert().Model(&token).Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return token.ID, nil
+}
+
+// GetAPITokens возвращает токены указанного вида. При userID = 0 возвращаются токены всех пользователей.
+func (r *APITokenRepository) GetAPITokens(ctx context.Context, userID int, kind string) ([]bunEntities.APIToken, error) {
+	var tokens []bunEntities.APIToken
+
+	query := r.db.NewSelect().
+		Model(&tokens).
+		Where("kind = ?", kind).
+		Order("id ASC")
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetAPITokenByHash возвращает токен по хэшу.
+func (r *APITokenRepository) GetAPITokenByHash(ctx context.Context, tokenHash string) (bunEntities.APIToken, error) {
+	var token bunEntities.APIToken
+
+	err := r.db.NewSelect().
+		Model(&token).
+		Where("token_hash = ?", tokenHash).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.APIToken{}, err
+	}
+
+	return token, nil
+}
+
+// DeleteAPIToken удаляет токен указанного вида. При userID = 0 владелец не проверяется.
+// Возвращает false, если токен не найден.
+func (r *APITokenRepository) DeleteAPIToken(ctx context.Context, tokenID int, userID int, kind string) (bool, error) {
+	query := r.db.NewDelete().
+		Model((*bunEntities.APIToken)(nil)).
+		Where("id = ?", tokenID).
+		Where("kind = ?", kind)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	res, err := query.Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// TouchAPIToken запоминает время и IP последнего использования токена.
+// Чтобы не писать в базу на каждый запрос, время обновляется не чаще раза в минуту.
+func (r *APITokenRepository) TouchAPIToken(ctx context.Context, tokenID int, clientIP string) error {
+	now := time.Now()
+
+	_, err := r.db.NewUpdate().
+		Model((*bunEntities.APIToken)(nil)).
+		Set("last_used_at = ?", now).
+		Set("last_used_ip = ?", clientIP).
+		Where("id = ?", tokenID).
+		Where("last_used_at IS NULL OR last_used_at < ?", now.Add(-apiTokenTouchInterval)).
+		Exec(ctx)
+
+	return err
+}
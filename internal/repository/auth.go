@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/uptrace/bun"
 
@@ -37,17 +38,34 @@ func (r *AuthRepository) CreateUser(ctx context.Context, user entities.SignUpInp
 		return 0, errors.New("user with this username already exists")
 	}
 
+	if user.Email != "" {
+		if err := checkEmailAvailable(ctx, r.db, user.Email, 0); err != nil {
+			return 0, err
+		}
+	}
+
+	role, err := getDefaultRole(ctx, r.db)
+	if err != nil {
+		return 0, errors.New("can't get default role: " + err.Error())
+	}
+
 	// Создаем нового пользователя
 	newUser := &bunEntities.User{
-		Role:         "user", // Роль по умолчанию
+		Role:         role, // Роль по умолчанию
 		Name:         user.Name,
 		Username:     user.Username,
+		Email:        optionalString(user.Email),
 		PasswordHash: user.Password,
 		City:         user.City,
 	}
 
-	// Сохраняем пользователя в БД
-	_, err = r.db.NewInsert().Model(newUser).Exec(ctx)
+	// Сохраняем пользователя в БД вместе с членством в организации default
+	err = r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(newUser).Exec(ctx); err != nil {
+			return err
+		}
+		return JoinDefaultOrganization(ctx, tx, newUser.ID, newUser.Role)
+	})
 	if err != nil {
 		return 0, err // Возвращаем ошибку, если не удалось сохранить пользователя
 	}
@@ -55,16 +73,15 @@ func (r *AuthRepository) CreateUser(ctx context.Context, user entities.SignUpInp
 	return newUser.ID, nil
 }
 
-// GetUser возвращает пользователя по его имени пользователя и паролю.
-func (r *AuthRepository) GetUser(ctx context.Context, signinuser entities.SignInInput) (bunEntities.User, error) {
+// GetUser возвращает пользователя по его имени пользователя вместе с хэшем пароля.
+func (r *AuthRepository) GetUser(ctx context.Context, username string) (bunEntities.User, error) {
 	var user bunEntities.User
 
-	// Выполняем выборку пользователя по username и password_hash
+	// Выполняем выборку пользователя по username, пароль проверяется в сервисе
 	err := r.db.NewSelect().
 		Model(&user).
-		Column("id", "role", "name", "username").
-		Where("username = ?", signinuser.Username).
-		Where("password_hash = ?", signinuser.Password).
+		Column("id", "role", "name", "username", "email", "email_verified_at", "password_hash").
+		Where("username = ?", username).
 		Scan(ctx)
 
 	if err != nil {
@@ -74,14 +91,21 @@ func (r *AuthRepository) GetUser(ctx context.Context, signinuser entities.SignIn
 	return user, nil
 }
 
+// UpdatePasswordHash заменяет хэш пароля пользователя (используется для пересчета устаревших хэшей).
+func (r *AuthRepository) UpdatePasswordHash(ctx context.Context, userID int, passwordHash string) error {
+	_, err := r.db.NewUpdate().
+		Model((*bunEntities.User)(nil)).
+		Set("password_hash = ?", passwordHash).
+		Where("id = ?", userID).
+		Exec(ctx)
+
+	return err
+}
+
 // CreateSession создает новую сессию и возвращает сгенерированный refresh_token.
 func (r *AuthRepository) CreateSession(ctx context.Context, session bunEntities.Session) (string, error) {
 	// Вставляем новую сессию в базу данных, исключая refresh_token (он будет сгенерирован базой)
-	_, err := r.db.NewInsert().
-		Model(&session).
-		ExcludeColumn("refresh_token"). // Исключаем refresh_token из запроса
-		Returning("refresh_token").     // Возвращаем сгенерированный refresh_token
-		Exec(ctx)
+	_, err := newSessionInsert(r.db, &session).Exec(ctx)
 
 	if err != nil {
 		return "", err // Возвращаем ошибку, если не удалось создать сессию
@@ -107,12 +131,15 @@ func (r *AuthRepository) GetSession(ctx context.Context, refreshToken string) (b
 	return session, nil
 }
 
-// DeleteSession удаляет сессию по refresh_token.
+// DeleteSession удаляет сессию по refresh_token вместе со всей цепочкой ее ротаций.
 func (r *AuthRepository) DeleteSession(ctx context.Context, refreshToken string) error {
-	// Удаляем сессию по refresh_token
+	// Удаляем все сессии семейства, к которому относится refresh_token
 	_, err := r.db.NewDelete().
 		Model((*bunEntities.Session)(nil)).
-		Where("refresh_token = ?", refreshToken).
+		Where("family_id IN (?)", r.db.NewSelect().
+			Model((*bunEntities.Session)(nil)).
+			Column("family_id").
+			Where("refresh_token = ?", refreshToken)).
 		Exec(ctx)
 
 	return err
@@ -134,3 +161,258 @@ func (r *AuthRepository) GetRole(ctx context.Context, userID int) (string, error
 
 	return user.Role, nil
 }
+
+// GetRolePermissions возвращает права роли.
+func (r *AuthRepository) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	var permissions []string
+
+	err := r.db.NewSelect().
+		Model((*bunEntities.RolePermission)(nil)).
+		Column("permission").
+		Where("role = ?", role).
+		Order("permission ASC").
+		Scan(ctx, &permissions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// GetDefaultRole возвращает роль, которая назначается новым пользователям.
+func (r *AuthRepository) GetDefaultRole(ctx context.Context) (string, error) {
+	return getDefaultRole(ctx, r.db)
+}
+
+func getDefaultRole(ctx context.Context, db bun.IDB) (string, error) {
+	var role bunEntities.Role
+
+	err := db.NewSelect().
+		Model(&role).
+		Column("name").
+		Where("is_default").
+		Scan(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return role.Name, nil
+}
+
+// GetMembershipRole возвращает роль пользователя в организации или sql.ErrNoRows, если он в ней не состоит.
+func (r *AuthRepository) GetMembershipRole(ctx context.Context, organizationID int, userID int) (string, error) {
+	var member bunEntities.OrganizationMember
+
+	err := r.db.NewSelect().
+		Model(&member).
+		Column("role").
+		Where("organization_id = ?", organizationID).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+// GetFirstOrganization возвращает организацию, в которую пользователь вступил раньше остальных,
+// или nil, если он не состоит ни в одной.
+func (r *AuthRepository) GetFirstOrganization(ctx context.Context, userID int) (*int, error) {
+	var member bunEntities.OrganizationMember
+
+	err := r.db.NewSelect().
+		Model(&member).
+		Column("organization_id").
+		Where("user_id = ?", userID).
+		Order("created_at ASC", "organization_id ASC").
+		Limit(1).
+		Scan(ctx)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &member.OrganizationID, nil
+}
+
+// GetUsername возвращает имя пользователя по его ID.
+func (r *AuthRepository) GetUsername(ctx context.Context, userID int) (string, error) {
+	var user bunEntities.User
+	err := r.db.NewSelect().
+		Model(&user).
+		Where("id = ?", userID).
+		Column("username").
+		Scan(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return user.Username, nil
+}
+
+// GetLoginAttempt возвращает счетчик неудачных попыток входа по ключу (username или IP).
+func (r *AuthRepository) GetLoginAttempt(ctx context.Context, key string) (bunEntities.LoginAttempt, error) {
+	var attempt bunEntities.LoginAttempt
+
+	err := r.db.NewSelect().
+		Model(&attempt).
+		Where("key = ?", key).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.LoginAttempt{}, err
+	}
+
+	return attempt, nil
+}
+
+// RegisterFailedLogin атомарно увеличивает счетчик неудачных попыток и возвращает его новое значение.
+// Если с последней ошибки прошло больше window, счетчик начинается заново.
+func (r *AuthRepository) RegisterFailedLogin(ctx context.Context, key string, window time.Duration) (int, error) {
+	now := time.Now()
+	attempt := &bunEntities.LoginAttempt{
+		Key:          key,
+		Failures:     1,
+		LastFailedAt: now,
+	}
+
+	_, err := r.db.NewInsert().
+		Model(attempt).
+		On("CONFLICT (key) DO UPDATE").
+		Set("failures = CASE WHEN la.last_failed_at < ? THEN 1 ELSE la.failures + 1 END", now.Add(-window)).
+		Set("last_failed_at = EXCLUDED.last_failed_at").
+		Returning("failures").
+		Exec(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return attempt.Failures, nil
+}
+
+// SetLoginLock запрещает попытки входа по ключу до указанного времени.
+func (r *AuthRepository) SetLoginLock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*bunEntities.LoginAttempt)(nil)).
+		Set("locked_until = ?", until).
+		Where("key = ?", key).
+		Exec(ctx)
+
+	return err
+}
+
+// ResetLoginAttempts сбрасывает счетчик неудачных попыток и блокировку по ключу.
+func (r *AuthRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.db.NewDelete().
+		Model((*bunEntities.LoginAttempt)(nil)).
+		Where("key = ?", key).
+		Exec(ctx)
+
+	return err
+}
+
+// RegisterRequest атомарно увеличивает счетчик запросов по ключу и возвращает его новое значение
+// вместе с началом текущего окна. Когда окно длиной window истекает, счетчик начинается заново.
+// Счетчики хранятся отдельно от попыток входа, поэтому блокировки входа их не затрагивают.
+func (r *AuthRepository) RegisterRequest(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+	limit := &bunEntities.RateLimit{
+		Key:             key,
+		Requests:        1,
+		WindowStartedAt: now,
+	}
+
+	_, err := r.db.NewInsert().
+		Model(limit).
+		On("CONFLICT (key) DO UPDATE").
+		Set("requests = CASE WHEN rl.window_started_at < ? THEN 1 ELSE rl.requests + 1 END", now.Add(-window)).
+		Set("window_started_at = CASE WHEN rl.window_started_at < ? THEN EXCLUDED.window_started_at ELSE rl.window_started_at END", now.Add(-window)).
+		Returning("requests, window_started_at").
+		Exec(ctx)
+
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return limit.Requests, limit.WindowStartedAt, nil
+}
+
+// GetTokenVersion возвращает текущую версию токенов пользователя.
+func (r *AuthRepository) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	var user bunEntities.User
+	err := r.db.NewSelect().
+		Model(&user).
+		Where("id = ?", userID).
+		Column("token_version").
+		Scan(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return user.TokenVersion, nil
+}
+
+// IncrementTokenVersion увеличивает версию токенов пользователя, делая недействительными все выданные access token.
+func (r *AuthRepository) IncrementTokenVersion(ctx context.Context, userID int) error {
+	_, err := r.db.NewUpdate().
+		Model((*bunEntities.User)(nil)).
+		Set("token_version = token_version + 1").
+		Where("id = ?", userID).
+		Exec(ctx)
+
+	return err
+}
+
+// RevokeToken добавляет jti access token в список отозванных до истечения срока его действия.
+func (r *AuthRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	// Заодно удаляем записи о токенах, срок которых уже истек
+	if _, err := r.db.NewDelete().
+		Model((*bunEntities.RevokedToken)(nil)).
+		Where("expires_at < ?", time.Now()).
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	_, err := r.db.NewInsert().
+		Model(&bunEntities.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).
+		On("CONFLICT (jti) DO NOTHING").
+		Exec(ctx)
+
+	return err
+}
+
+// ConsumeTokenID записывает jti одноразового токена в список использованных до истечения срока
+// его действия. Возвращает false, если этот jti уже был использован.
+func (r *AuthRepository) ConsumeTokenID(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	res, err := r.db.NewInsert().
+		Model(&bunEntities.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).
+		On("CONFLICT (jti) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// IsTokenRevoked проверяет, находится ли jti в списке отозванных.
+func (r *AuthRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return r.db.NewSelect().
+		Model((*bunEntities.RevokedToken)(nil)).
+		Where("jti = ?", jti).
+		Exists(ctx)
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// fakeRow — ответ фейковой базы на запрос, в тексте которого есть match
+type fakeRow struct {
+	match  string
+	column string
+	value  driver.Value
+}
+
+// fakeDB записывает SQL, который формирует bun. На запросы из rows отвечает одной строкой,
+// на остальные SELECT — пустым результатом, изменения затрагивают одну строку.
+type fakeDB struct {
+	queries []string
+	rows    []fakeRow
+}
+
+// newFakeDB возвращает bun.DB с диалектом PostgreSQL поверх записывающей базы
+func newFakeDB(t *testing.T, rows ...fakeRow) (*bun.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{rows: rows}
+	db := bun.NewDB(sql.OpenDB(fake), pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+// queriesWith возвращает записанные запросы, в которых есть substr
+func (f *fakeDB) queriesWith(substr string) []string {
+	var queries []string
+	for _, query := range f.queries {
+		if strings.Contains(query, substr) {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                            { return fakeDriver{f} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn(d), nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.queries = append(c.db.queries, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.queries = append(c.db.queries, query)
+	for _, row := range c.db.rows {
+		if strings.Contains(query, row.match) {
+			return &fakeRows{columns: []string{row.column}, values: []driver.Value{row.value}}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	values  []driver.Value
+	done    bool
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done || r.values == nil {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
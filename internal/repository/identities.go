@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+// GetUserIdentity возвращает привязку внешней учетной записи к пользователю.
+func (r *AuthRepository) GetUserIdentity(ctx context.Context, provider string, subject string) (bunEntities.UserIdentity, error) {
+	var identity bunEntities.UserIdentity
+
+	err := r.db.NewSelect().
+		Model(&identity).
+		Where("provider = ?", provider).
+		Where("subject = ?", subject).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.UserIdentity{}, err
+	}
+
+	return identity, nil
+}
+
+// CreateUserIdentity привязывает внешнюю учетную запись к существующему пользователю.
+func (r *AuthRepository) CreateUserIdentity(ctx context.Context, identity bunEntities.UserIdentity) error {
+	_, err := r.db.NewInsert().Model(&identity).Exec(ctx)
+
+	return err
+}
+
+// CreateFederatedUser создает пользователя без пароля вместе с привязкой внешней учетной записи
+// и членством в организации default.
+func (r *AuthRepository) CreateFederatedUser(ctx context.Context, user bunEntities.User, identity bunEntities.UserIdentity) (int, error) {
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(&user).Exec(ctx); err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+		if _, err := tx.NewInsert().Model(&identity).Exec(ctx); err != nil {
+			return err
+		}
+		return JoinDefaultOrganization(ctx, tx, user.ID, user.Role)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return user.ID, nil
+}
+
+// CreateOIDCLoginState сохраняет параметры начатого входа и удаляет просроченные.
+func (r *AuthRepository) CreateOIDCLoginState(ctx context.Context, state bunEntities.OIDCLoginState) error {
+	if _, err := r.db.NewDelete().
+		Model((*bunEntities.OIDCLoginState)(nil)).
+		Where("expires_at < ?", time.Now()).
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	_, err := r.db.NewInsert().Model(&state).Exec(ctx)
+
+	return err
+}
+
+// ConsumeOIDCLoginState удаляет параметры входа и возвращает их, поэтому state можно использовать только один раз.
+func (r *AuthRepository) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (bunEntities.OIDCLoginState, error) {
+	var state bunEntities.OIDCLoginState
+
+	err := r.db.NewDelete().
+		Model(&state).
+		Where("state_hash = ?", stateHash).
+		Returning("*").
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.OIDCLoginState{}, err
+	}
+
+	return state, nil
+}
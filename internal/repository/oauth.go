@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+type OAuthRepository struct {
+	db *bun.DB
+}
+
+func NewOAuthRepository(db *bun.DB) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+// CreateClient регистрирует OAuth-клиента.
+func (r *OAuthRepository) CreateClient(ctx context.Context, client bunEntities.OAuthClient) error {
+	_, err := r.db.NewInsert().Model(&client).Exec(ctx)
+
+	return err
+}
+
+// GetClient возвращает OAuth-клиента по client_id.
+func (r *OAuthRepository) GetClient(ctx context.Context, clientID string) (bunEntities.OAuthClient, error) {
+	var client bunEntities.OAuthClient
+
+	err := r.db.NewSelect().
+		Model(&client).
+		Where("client_id = ?", clientID).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.OAuthClient{}, err
+	}
+
+	return client, nil
+}
+
+// GetClients возвращает всех зарегистрированных OAuth-клиентов.
+func (r *OAuthRepository) GetClients(ctx context.Context) ([]bunEntities.OAuthClient, error) {
+	var clients []bunEntities.OAuthClient
+
+	err := r.db.NewSelect().
+		Model(&clients).
+		Order("id ASC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+// DeleteClient удаляет OAuth-клиента вместе с его кодами авторизации и сессиями.
+// Возвращает false, если клиента не существует.
+func (r *OAuthRepository) DeleteClient(ctx context.Context, clientID string) (bool, error) {
+	res, err := r.db.NewDelete().
+		Model((*bunEntities.OAuthClient)(nil)).
+		Where("client_id = ?", clientID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// CreateCode сохраняет код авторизации и удаляет просроченные коды.
+func (r *OAuthRepository) CreateCode(ctx context.Context, code bunEntities.OAuthCode) error {
+	if _, err := r.db.NewDelete().
+		Model((*bunEntities.OAuthCode)(nil)).
+		Where("expires_at < ?", time.Now()).
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	_, err := r.db.NewInsert().Model(&code).Exec(ctx)
+
+	return err
+}
+
+// ConsumeCode удаляет код авторизации и возвращает его данные, поэтому код можно обменять только один раз.
+func (r *OAuthRepository) ConsumeCode(ctx context.Context, codeHash string) (bunEntities.OAuthCode, error) {
+	var code bunEntities.OAuthCode
+
+	err := r.db.NewDelete().
+		Model(&code).
+		Where("code_hash = ?", codeHash).
+		Returning("*").
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.OAuthCode{}, err
+	}
+
+	return code, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+// GetUserByEmail возвращает пользователя по адресу электронной почты без учета регистра.
+func (r *AuthRepository) GetUserByEmail(ctx context.Context, email string) (bunEntities.User, error) {
+	var user bunEntities.User
+
+	err := r.db.NewSelect().
+		Model(&user).
+		Column("id", "role", "name", "username", "email", "email_verified_at").
+		Where("lower(email) = lower(?)", email).
+		Limit(1).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.User{}, err
+	}
+
+	return user, nil
+}
+
+// CreateOneTimeToken сохраняет одноразовый токен. Прежние токены пользователя с тем же назначением
+// и все просроченные токены удаляются, поэтому действует только последняя ссылка.
+func (r *AuthRepository) CreateOneTimeToken(ctx context.Context, token bunEntities.OneTimeToken) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*bunEntities.OneTimeToken)(nil)).
+			WhereOr("user_id = ? AND purpose = ?", token.UserID, token.Purpose).
+			WhereOr("expires_at < ?", time.Now()).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := tx.NewInsert().Model(&token).Exec(ctx)
+		return err
+	})
+}
+
+// GetOneTimeToken возвращает токен с указанным назначением, не удаляя его.
+func (r *AuthRepository) GetOneTimeToken(ctx context.Context, tokenHash string, purpose string) (bunEntities.OneTimeToken, error) {
+	var token bunEntities.OneTimeToken
+
+	err := r.db.NewSelect().
+		Model(&token).
+		Where("token_hash = ?", tokenHash).
+		Where("purpose = ?", purpose).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.OneTimeToken{}, err
+	}
+
+	return token, nil
+}
+
+// ConsumeOneTimeToken удаляет токен с указанным назначением и возвращает его, поэтому токен действует один раз.
+func (r *AuthRepository) ConsumeOneTimeToken(ctx context.Context, tokenHash string, purpose string) (bunEntities.OneTimeToken, error) {
+	var token bunEntities.OneTimeToken
+
+	err := r.db.NewDelete().
+		Model(&token).
+		Where("token_hash = ?", tokenHash).
+		Where("purpose = ?", purpose).
+		Returning("*").
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.OneTimeToken{}, err
+	}
+
+	return token, nil
+}
+
+// ResetPassword задает новый пароль и завершает все сессии пользователя:
+// удаляет refresh token, повышает версию токенов и удаляет оставшиеся токены сброса.
+func (r *AuthRepository) ResetPassword(ctx context.Context, userID int, passwordHash string, purpose string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().
+			Model((*bunEntities.User)(nil)).
+			Set("password_hash = ?", passwordHash).
+			Set("password_changed_at = now()").
+			Set("token_version = token_version + 1").
+			Where("id = ?", userID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewDelete().
+			Model((*bunEntities.Session)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := tx.NewDelete().
+			Model((*bunEntities.OneTimeToken)(nil)).
+			Where("user_id = ?", userID).
+			Where("purpose = ?", purpose).
+			Exec(ctx)
+		return err
+	})
+}
+
+// GetPasswordChangedAt возвращает время последней смены пароля пользователя (NULL, если пароль не менялся).
+func (r *AuthRepository) GetPasswordChangedAt(ctx context.Context, userID int) (*time.Time, error) {
+	var user bunEntities.User
+
+	err := r.db.NewSelect().
+		Model(&user).
+		Column("password_changed_at").
+		Where("id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user.PasswordChangedAt, nil
+}
+
+// MarkEmailVerified отмечает текущий адрес пользователя подтвержденным.
+func (r *AuthRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	_, err := r.db.NewUpdate().
+		Model((*bunEntities.User)(nil)).
+		Set("email_verified_at = ?", time.Now()).
+		Where("id = ?", userID).
+		Where("email IS NOT NULL").
+		Where("email_verified_at IS NULL").
+		Exec(ctx)
+	return err
+}
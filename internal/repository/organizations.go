@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+type OrganizationsRepository struct {
+	db *bun.DB
+}
+
+func NewOrganizationsRepository(db *bun.DB) *OrganizationsRepository {
+	return &OrganizationsRepository{db: db}
+}
+
+// GetOrganizations возвращает все организации.
+func (r *OrganizationsRepository) GetOrganizations(ctx context.Context) ([]bunEntities.Organization, error) {
+	var organizations []bunEntities.Organization
+
+	if err := r.db.NewSelect().
+		Model(&organizations).
+		Order("id ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return organizations, nil
+}
+
+// GetOrganization возвращает организацию по ID.
+func (r *OrganizationsRepository) GetOrganization(ctx context.Context, id int) (bunEntities.Organization, error) {
+	var organization bunEntities.Organization
+
+	if err := r.db.NewSelect().
+		Model(&organization).
+		Where("id = ?", id).
+		Scan(ctx); err != nil {
+		return bunEntities.Organization{}, err
+	}
+
+	return organization, nil
+}
+
+// GetOrganizationBySlug возвращает организацию по короткому имени.
+func (r *OrganizationsRepository) GetOrganizationBySlug(ctx context.Context, slug string) (bunEntities.Organization, error) {
+	var organization bunEntities.Organization
+
+	if err := r.db.NewSelect().
+		Model(&organization).
+		Where("slug = ?", slug).
+		Scan(ctx); err != nil {
+		return bunEntities.Organization{}, err
+	}
+
+	return organization, nil
+}
+
+// CreateOrganization создает организацию и возвращает ее ID.
+func (r *OrganizationsRepository) CreateOrganization(ctx context.Context, organization bunEntities.Organization) (int, error) {
+	if _, err := r.db.NewInsert().
+		Model(&organization).
+		Returning("id").
+		Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return organization.ID, nil
+}
+
+// DeleteOrganization удаляет организацию вместе с членством в ней. Access token ее участников
+// перестают действовать, чтобы в них не осталась удаленная организация. Возвращает false,
+// если организации не существует.
+func (r *OrganizationsRepository) DeleteOrganization(ctx context.Context, id int) (bool, error) {
+	deleted := false
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().
+			Model((*bunEntities.User)(nil)).
+			Set("token_version = token_version + 1").
+			Where("id IN (SELECT user_id FROM organization_members WHERE organization_id = ?)", id).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		res, err := tx.NewDelete().
+			Model((*bunEntities.Organization)(nil)).
+			Where("id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		deleted = affected > 0
+		return nil
+	})
+
+	return deleted, err
+}
+
+// GetMembers возвращает участников организации вместе с их учетными записями.
+func (r *OrganizationsRepository) GetMembers(ctx context.Context, organizationID int) ([]bunEntities.OrganizationMember, error) {
+	var members []bunEntities.OrganizationMember
+
+	if err := r.db.NewSelect().
+		Model(&members).
+		Relation("User").
+		Where("om.organization_id = ?", organizationID).
+		Order("om.created_at ASC", "om.user_id ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// GetUserOrganizations возвращает членство пользователя в организациях вместе с самими организациями.
+func (r *OrganizationsRepository) GetUserOrganizations(ctx context.Context, userID int) ([]bunEntities.OrganizationMember, error) {
+	var members []bunEntities.OrganizationMember
+
+	if err := r.db.NewSelect().
+		Model(&members).
+		Relation("Organization").
+		Where("om.user_id = ?", userID).
+		Order("om.created_at ASC", "om.organization_id ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// GetMemberships возвращает членство в организациях сразу для нескольких пользователей.
+func (r *OrganizationsRepository) GetMemberships(ctx context.Context, userIDs []int) ([]bunEntities.OrganizationMember, error) {
+	var members []bunEntities.OrganizationMember
+	if len(userIDs) == 0 {
+		return members, nil
+	}
+
+	if err := r.db.NewSelect().
+		Model(&members).
+		Where("om.user_id IN (?)", bun.In(userIDs)).
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// AddMember добавляет пользователя в организацию. Возвращает false, если он уже в ней состоит.
+func (r *OrganizationsRepository) AddMember(ctx context.Context, member bunEntities.OrganizationMember) (bool, error) {
+	res, err := r.db.NewInsert().
+		Model(&member).
+		On("CONFLICT DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateMemberRole меняет роль участника. Возвращает false, если пользователь не состоит в организации.
+func (r *OrganizationsRepository) UpdateMemberRole(ctx context.Context, organizationID int, userID int, role string) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*bunEntities.OrganizationMember)(nil)).
+		Set("role = ?", role).
+		Where("organization_id = ?", organizationID).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// RemoveMember исключает пользователя из организации; его access token перестают действовать.
+// Возвращает false, если пользователь не состоит в организации.
+func (r *OrganizationsRepository) RemoveMember(ctx context.Context, organizationID int, userID int) (bool, error) {
+	removed := false
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewDelete().
+			Model((*bunEntities.OrganizationMember)(nil)).
+			Where("organization_id = ?", organizationID).
+			Where("user_id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+		removed = true
+
+		_, err = tx.NewUpdate().
+			Model((*bunEntities.User)(nil)).
+			Set("token_version = token_version + 1").
+			Where("id = ?", userID).
+			Exec(ctx)
+		return err
+	})
+
+	return removed, err
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+// GetUserProfile возвращает данные пользователя, которые нельзя использовать в пароле.
+func (r *AuthRepository) GetUserProfile(ctx context.Context, userID int) (bunEntities.User, error) {
+	var user bunEntities.User
+
+	err := r.db.NewSelect().
+		Model(&user).
+		Column("id", "name", "username", "email", "city").
+		Where("id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.User{}, err
+	}
+
+	return user, nil
+}
+
+// GetPasswordHistory возвращает хэши последних limit паролей пользователя, начиная с текущего.
+func (r *AuthRepository) GetPasswordHistory(ctx context.Context, userID int, limit int) ([]string, error) {
+	var hashes []string
+
+	err := r.db.NewSelect().
+		Model((*bunEntities.PasswordHistory)(nil)).
+		Column("password_hash").
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Scan(ctx, &hashes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// AddPasswordHistory запоминает новый пароль пользователя и оставляет в истории только keep последних.
+func (r *AuthRepository) AddPasswordHistory(ctx context.Context, userID int, passwordHash string, keep int) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().
+			Model(&bunEntities.PasswordHistory{UserID: userID, PasswordHash: passwordHash}).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		recent := tx.NewSelect().
+			Model((*bunEntities.PasswordHistory)(nil)).
+			Column("id").
+			Where("user_id = ?", userID).
+			Order("id DESC").
+			Limit(keep)
+
+		_, err := tx.NewDelete().
+			Model((*bunEntities.PasswordHistory)(nil)).
+			Where("user_id = ?", userID).
+			Where("id NOT IN (?)", recent).
+			Exec(ctx)
+		return err
+	})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/kolibriee/users-rest-api/internal/entities"
 	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
@@ -10,11 +11,96 @@ import (
 
 type Authorization interface {
 	CreateUser(ctx context.Context, user entities.SignUpInput) (int, error)
-	GetUser(ctx context.Context, signinuser entities.SignInInput) (bunEntities.User, error)
+	GetUser(ctx context.Context, username string) (bunEntities.User, error)
+	UpdatePasswordHash(ctx context.Context, userID int, passwordHash string) error
 	CreateSession(ctx context.Context, session bunEntities.Session) (string, error)
 	GetSession(ctx context.Context, refreshToken string) (bunEntities.Session, error)
 	DeleteSession(ctx context.Context, refreshToken string) error
+	GetUserSessions(ctx context.Context, userID int) ([]bunEntities.Session, error)
+	DeleteUserSession(ctx context.Context, userID int, sessionID int) (bool, error)
+	DeleteUserSessions(ctx context.Context, userID int) error
+	RotateSession(ctx context.Context, sessionID int, next bunEntities.Session) (string, bool, error)
+	DeleteSessionFamily(ctx context.Context, familyID string) error
+	CreateSecurityEvent(ctx context.Context, event bunEntities.SecurityEvent) error
 	GetRole(ctx context.Context, userID int) (string, error)
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+	GetDefaultRole(ctx context.Context) (string, error)
+	GetMembershipRole(ctx context.Context, organizationID int, userID int) (string, error)
+	GetFirstOrganization(ctx context.Context, userID int) (*int, error)
+	GetTokenVersion(ctx context.Context, userID int) (int, error)
+	IncrementTokenVersion(ctx context.Context, userID int) error
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	ConsumeTokenID(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+	GetUsername(ctx context.Context, userID int) (string, error)
+	GetLoginAttempt(ctx context.Context, key string) (bunEntities.LoginAttempt, error)
+	RegisterFailedLogin(ctx context.Context, key string, window time.Duration) (int, error)
+	SetLoginLock(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	RegisterRequest(ctx context.Context, key string, window time.Duration) (int, time.Time, error)
+	GetTwoFactor(ctx context.Context, userID int) (bunEntities.TwoFactor, error)
+	SaveTwoFactorSecret(ctx context.Context, userID int, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error
+	UseTwoFactorStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	DeleteTwoFactor(ctx context.Context, userID int) error
+	GetUserIdentity(ctx context.Context, provider string, subject string) (bunEntities.UserIdentity, error)
+	CreateUserIdentity(ctx context.Context, identity bunEntities.UserIdentity) error
+	CreateFederatedUser(ctx context.Context, user bunEntities.User, identity bunEntities.UserIdentity) (int, error)
+	CreateOIDCLoginState(ctx context.Context, state bunEntities.OIDCLoginState) error
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (bunEntities.OIDCLoginState, error)
+	GetUserByEmail(ctx context.Context, email string) (bunEntities.User, error)
+	CreateOneTimeToken(ctx context.Context, token bunEntities.OneTimeToken) error
+	GetOneTimeToken(ctx context.Context, tokenHash string, purpose string) (bunEntities.OneTimeToken, error)
+	ConsumeOneTimeToken(ctx context.Context, tokenHash string, purpose string) (bunEntities.OneTimeToken, error)
+	ResetPassword(ctx context.Context, userID int, passwordHash string, purpose string) error
+	GetPasswordChangedAt(ctx context.Context, userID int) (*time.Time, error)
+	MarkEmailVerified(ctx context.Context, userID int) error
+	GetUserProfile(ctx context.Context, userID int) (bunEntities.User, error)
+	GetPasswordHistory(ctx context.Context, userID int, limit int) ([]string, error)
+	AddPasswordHistory(ctx context.Context, userID int, passwordHash string, keep int) error
+}
+
+type OAuth interface {
+	CreateClient(ctx context.Context, client bunEntities.OAuthClient) error
+	GetClient(ctx context.Context, clientID string) (bunEntities.OAuthClient, error)
+	GetClients(ctx context.Context) ([]bunEntities.OAuthClient, error)
+	DeleteClient(ctx context.Context, clientID string) (bool, error)
+	CreateCode(ctx context.Context, code bunEntities.OAuthCode) error
+	ConsumeCode(ctx context.Context, codeHash string) (bunEntities.OAuthCode, error)
+}
+
+type APITokens interface {
+	CreateAPIToken(ctx context.Context, token bunEntities.APIToken) (int, error)
+	GetAPITokens(ctx context.Context, userID int, kind string) ([]bunEntities.APIToken, error)
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (bunEntities.APIToken, error)
+	DeleteAPIToken(ctx context.Context, tokenID int, userID int, kind string) (bool, error)
+	TouchAPIToken(ctx context.Context, tokenID int, clientIP string) error
+}
+
+type Roles interface {
+	GetRoles(ctx context.Context) ([]bunEntities.Role, error)
+	GetRoleByName(ctx context.Context, name string) (bunEntities.Role, error)
+	GetRolePermissionsMap(ctx context.Context) (map[string][]string, error)
+	GetPermissions(ctx context.Context) ([]bunEntities.Permission, error)
+	CreateRole(ctx context.Context, role bunEntities.Role, permissions []string) error
+	UpdateRole(ctx context.Context, name string, description *string, permissions *[]string, makeDefault bool) (bool, error)
+	DeleteRole(ctx context.Context, name string) (bool, error)
+	CountRoleUsers(ctx context.Context, name string) (int, error)
+}
+
+type Organizations interface {
+	GetOrganizations(ctx context.Context) ([]bunEntities.Organization, error)
+	GetOrganization(ctx context.Context, id int) (bunEntities.Organization, error)
+	GetOrganizationBySlug(ctx context.Context, slug string) (bunEntities.Organization, error)
+	CreateOrganization(ctx context.Context, organization bunEntities.Organization) (int, error)
+	DeleteOrganization(ctx context.Context, id int) (bool, error)
+	GetMembers(ctx context.Context, organizationID int) ([]bunEntities.OrganizationMember, error)
+	GetUserOrganizations(ctx context.Context, userID int) ([]bunEntities.OrganizationMember, error)
+	GetMemberships(ctx context.Context, userIDs []int) ([]bunEntities.OrganizationMember, error)
+	AddMember(ctx context.Context, member bunEntities.OrganizationMember) (bool, error)
+	UpdateMemberRole(ctx context.Context, organizationID int, userID int, role string) (bool, error)
+	RemoveMember(ctx context.Context, organizationID int, userID int) (bool, error)
 }
 
 type Users interface {
@@ -27,12 +113,20 @@ type Users interface {
 
 type Repository struct {
 	Authorization
+	OAuth
+	APITokens
+	Roles
+	Organizations
 	Users
 }
 
 func NewRepository(db *bun.DB) *Repository {
 	return &Repository{
 		Authorization: NewAuthRepository(db),
+		OAuth:         NewOAuthRepository(db),
+		APITokens:     NewAPITokenRepository(db),
+		Roles:         NewRolesRepository(db),
+		Organizations: NewOrganizationsRepository(db),
 		Users:         NewUsersRepository(db),
 	}
 }
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+type RolesRepository struct {
+	db *bun.DB
+}
+
+func NewRolesRepository(db *bun.DB) *RolesRepository {
+	return &RolesRepository{db: db}
+}
+
+// GetRoles возвращает все роли.
+func (r *RolesRepository) GetRoles(ctx context.Context) ([]bunEntities.Role, error) {
+	var roles []bunEntities.Role
+
+	if err := r.db.NewSelect().
+		Model(&roles).
+		Order("builtin DESC", "name ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// GetRoleByName возвращает роль по имени.
+func (r *RolesRepository) GetRoleByName(ctx context.Context, name string) (bunEntities.Role, error) {
+	var role bunEntities.Role
+
+	if err := r.db.NewSelect().
+		Model(&role).
+		Where("name = ?", name).
+		Scan(ctx); err != nil {
+		return bunEntities.Role{}, err
+	}
+
+	return role, nil
+}
+
+// GetRolePermissionsMap возвращает права всех ролей по имени роли.
+func (r *RolesRepository) GetRolePermissionsMap(ctx context.Context) (map[string][]string, error) {
+	var rows []bunEntities.RolePermission
+
+	if err := r.db.NewSelect().
+		Model(&rows).
+		Order("role ASC", "permission ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[string][]string)
+	for _, row := range rows {
+		permissions[row.Role] = append(permissions[row.Role], row.Permission)
+	}
+	return permissions, nil
+}
+
+// GetPermissions возвращает все права, которые можно выдать роли.
+func (r *RolesRepository) GetPermissions(ctx context.Context) ([]bunEntities.Permission, error) {
+	var permissions []bunEntities.Permission
+
+	if err := r.db.NewSelect().
+		Model(&permissions).
+		Order("name ASC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// CreateRole создает роль с правами. Если роль назначается ролью по умолчанию, признак снимается с прежней.
+func (r *RolesRepository) CreateRole(ctx context.Context, role bunEntities.Role, permissions []string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if role.IsDefault {
+			if err := clearDefaultRole(ctx, tx); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.NewInsert().Model(&role).Exec(ctx); err != nil {
+			return err
+		}
+
+		return insertRolePermissions(ctx, tx, role.Name, permissions)
+	})
+}
+
+// UpdateRole меняет описание, список прав и признак роли по умолчанию; nil означает «не менять».
+// Возвращает false, если роли не существует.
+func (r *RolesRepository) UpdateRole(ctx context.Context, name string, description *string, permissions *[]string, makeDefault bool) (bool, error) {
+	updated := false
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		exists, err := tx.NewSelect().
+			Model((*bunEntities.Role)(nil)).
+			Where("name = ?", name).
+			For("UPDATE").
+			Exists(ctx)
+		if err != nil || !exists {
+			return err
+		}
+		updated = true
+
+		if description != nil {
+			if _, err := tx.NewUpdate().
+				Model((*bunEntities.Role)(nil)).
+				Set("description = ?", *description).
+				Where("name = ?", name).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		if makeDefault {
+			if err := clearDefaultRole(ctx, tx); err != nil {
+				return err
+			}
+			if _, err := tx.NewUpdate().
+				Model((*bunEntities.Role)(nil)).
+				Set("is_default = TRUE").
+				Where("name = ?", name).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		if permissions != nil {
+			if _, err := tx.NewDelete().
+				Model((*bunEntities.RolePermission)(nil)).
+				Where("role = ?", name).
+				Exec(ctx); err != nil {
+				return err
+			}
+			return insertRolePermissions(ctx, tx, name, *permissions)
+		}
+		return nil
+	})
+
+	return updated, err
+}
+
+// DeleteRole удаляет роль вместе с ее правами. Возвращает false, если роли не существует.
+func (r *RolesRepository) DeleteRole(ctx context.Context, name string) (bool, error) {
+	res, err := r.db.NewDelete().
+		Model((*bunEntities.Role)(nil)).
+		Where("name = ?", name).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// CountRoleUsers возвращает число пользователей, участников организаций и сервисных клиентов с указанной ролью.
+func (r *RolesRepository) CountRoleUsers(ctx context.Context, name string) (int, error) {
+	users, err := r.db.NewSelect().
+		Model((*bunEntities.User)(nil)).
+		Where("role = ?", name).
+		Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	members, err := r.db.NewSelect().
+		Model((*bunEntities.OrganizationMember)(nil)).
+		Where("role = ?", name).
+		Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	clients, err := r.db.NewSelect().
+		Model((*bunEntities.OAuthClient)(nil)).
+		Where("role = ?", name).
+		Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return users + members + clients, nil
+}
+
+func clearDefaultRole(ctx context.Context, tx bun.Tx) error {
+	_, err := tx.NewUpdate().
+		Model((*bunEntities.Role)(nil)).
+		Set("is_default = FALSE").
+		Where("is_default").
+		Exec(ctx)
+
+	return err
+}
+
+func insertRolePermissions(ctx context.Context, tx bun.Tx, role string, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	rows := make([]bunEntities.RolePermission, 0, len(permissions))
+	for _, permission := range permissions {
+		rows = append(rows, bunEntities.RolePermission{Role: role, Permission: permission})
+	}
+	_, err := tx.NewInsert().
+		Model(&rows).
+		On("CONFLICT DO NOTHING").
+		Exec(ctx)
+
+	return err
+}
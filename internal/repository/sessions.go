@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+// newSessionInsert готовит вставку сессии: refresh_token всегда генерируется базой,
+// family_id — только для первой сессии семейства.
+func newSessionInsert(db bun.IDB, session *bunEntities.Session) *bun.InsertQuery {
+	excluded := []string{"refresh_token"}
+	returning := "refresh_token"
+	if session.FamilyID == "" {
+		excluded = append(excluded, "family_id")
+		returning = "refresh_token, family_id"
+	}
+
+	return db.NewInsert().
+		Model(session).
+		ExcludeColumn(excluded...).
+		Returning(returning)
+}
+
+// RotateSession атомарно помечает сессию использованной и создает следующую сессию того же семейства.
+// Возвращает rotated == false, если refresh token уже был использован ранее.
+func (r *AuthRepository) RotateSession(ctx context.Context, sessionID int, next bunEntities.Session) (string, bool, error) {
+	var refreshToken string
+	rotated := false
+
+	err := r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+		res, err := tx.NewUpdate().
+			Model((*bunEntities.Session)(nil)).
+			Set("rotated_at = ?", now).
+			Where("id = ?", sessionID).
+			Where("rotated_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+			return err
+		}
+
+		// Использованные токены храним до истечения их срока, чтобы распознать повторное предъявление
+		if _, err := tx.NewDelete().
+			Model((*bunEntities.Session)(nil)).
+			Where("family_id = ?", next.FamilyID).
+			Where("rotated_at IS NOT NULL").
+			Where("expires_at < ?", now).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := newSessionInsert(tx, &next).Exec(ctx); err != nil {
+			return err
+		}
+		refreshToken = next.RefreshToken
+		rotated = true
+		return nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	return refreshToken, rotated, nil
+}
+
+// DeleteSessionFamily удаляет все сессии семейства, включая использованные токены.
+func (r *AuthRepository) DeleteSessionFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.NewDelete().
+		Model((*bunEntities.Session)(nil)).
+		Where("family_id = ?", familyID).
+		Exec(ctx)
+
+	return err
+}
+
+// GetUserSessions возвращает действующие сессии пользователя (без использованных токенов).
+func (r *AuthRepository) GetUserSessions(ctx context.Context, userID int) ([]bunEntities.Session, error) {
+	var sessions []bunEntities.Session
+
+	err := r.db.NewSelect().
+		Model(&sessions).
+		Where("user_id = ?", userID).
+		Where("rotated_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Order("id ASC").
+		Scan(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// DeleteUserSession удаляет сессию пользователя по ее ID вместе с ее семейством.
+// Возвращает false, если у пользователя нет такой сессии.
+func (r *AuthRepository) DeleteUserSession(ctx context.Context, userID int, sessionID int) (bool, error) {
+	res, err := r.db.NewDelete().
+		Model((*bunEntities.Session)(nil)).
+		Where("user_id = ?", userID).
+		Where("family_id IN (?)", r.db.NewSelect().
+			Model((*bunEntities.Session)(nil)).
+			Column("family_id").
+			Where("id = ?", sessionID).
+			Where("user_id = ?", userID).
+			Where("rotated_at IS NULL")).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// DeleteUserSessions удаляет все сессии пользователя.
+func (r *AuthRepository) DeleteUserSessions(ctx context.Context, userID int) error {
+	_, err := r.db.NewDelete().
+		Model((*bunEntities.Session)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+
+	return err
+}
+
+// CreateSecurityEvent сохраняет событие безопасности в журнал.
+func (r *AuthRepository) CreateSecurityEvent(ctx context.Context, event bunEntities.SecurityEvent) error {
+	_, err := r.db.NewInsert().Model(&event).Exec(ctx)
+
+	return err
+}
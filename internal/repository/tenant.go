@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/uptrace/bun"
+)
+
+// DefaultOrganizationSlug — организация, которую создает миграция организаций и в которую попадают
+// пользователи, созданные вне какой-либо организации.
+const DefaultOrganizationSlug = "default"
+
+type organizationKey struct{}
+
+// WithOrganization ограничивает запросы UsersRepository с этим ctx пользователями одной организации.
+func WithOrganization(ctx context.Context, organizationID int) context.Context {
+	return context.WithValue(ctx, organizationKey{}, organizationID)
+}
+
+// OrganizationFromContext возвращает организацию, которой ограничены запросы; ok = false — без ограничения.
+func OrganizationFromContext(ctx context.Context) (int, bool) {
+	organizationID, ok := ctx.Value(organizationKey{}).(int)
+	return organizationID, ok
+}
+
+// scopeToOrganization добавляет к запросу по таблице users условие членства в организации из ctx.
+func scopeToOrganization(ctx context.Context) func(bun.QueryBuilder) bun.QueryBuilder {
+	return func(q bun.QueryBuilder) bun.QueryBuilder {
+		if organizationID, ok := OrganizationFromContext(ctx); ok {
+			q = q.Where("EXISTS (SELECT 1 FROM organization_members AS om WHERE om.user_id = ?TableAlias.id AND om.organization_id = ?)", organizationID)
+		}
+		return q
+	}
+}
+
+// JoinDefaultOrganization добавляет пользователя в организацию default с его глобальной ролью, как миграция
+// организаций добавила существующих пользователей. Если организации default нет, ничего не делает.
+func JoinDefaultOrganization(ctx context.Context, db bun.IDB, userID int, role string) error {
+	var organization bunEntities.Organization
+	err := db.NewSelect().
+		Model(&organization).
+		Column("id").
+		Where("slug = ?", DefaultOrganizationSlug).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = db.NewInsert().
+		Model(&bunEntities.OrganizationMember{OrganizationID: organization.ID, UserID: userID, Role: role}).
+		On("CONFLICT DO NOTHING").
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+const organizationScope = `EXISTS (SELECT 1 FROM organization_members AS om WHERE om.user_id = "u".id AND om.organization_id = 7)`
+
+func TestScopeToOrganization(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantScope bool
+	}{
+		{name: "without organization", ctx: context.Background()},
+		{name: "with organization", ctx: WithOrganization(context.Background(), 7), wantScope: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := newFakeDB(t)
+			query := db.NewSelect().Model((*bunEntities.User)(nil)).Where("id = ?", 1).ApplyQueryBuilder(scopeToOrganization(tt.ctx)).String()
+			if strings.Contains(query, organizationScope) != tt.wantScope {
+				t.Errorf("query = %s, want scope %v", query, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestJoinDefaultOrganization(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       []fakeRow
+		wantInsert string // Пусто — пользователь никуда не добавлен
+	}{
+		{
+			name:       "default organization exists",
+			rows:       []fakeRow{{match: `FROM "organizations"`, column: "id", value: int64(5)}},
+			wantInsert: `INSERT INTO "organization_members" AS "om" ("organization_id", "user_id", "role", "created_at") VALUES (5, 3, 'admin', DEFAULT) ON CONFLICT DO NOTHING`,
+		},
+		{name: "no default organization"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t, tt.rows...)
+			if err := JoinDefaultOrganization(context.Background(), db, 3, entities.RoleAdmin); err != nil {
+				t.Fatal(err)
+			}
+			if selects := fake.queriesWith(`WHERE (slug = 'default')`); len(selects) != 1 {
+				t.Errorf("queries = %q, want a lookup of the default organization", fake.queries)
+			}
+			inserts := fake.queriesWith("INSERT")
+			switch {
+			case tt.wantInsert == "" && len(inserts) != 0:
+				t.Errorf("inserts = %q, want none", inserts)
+			case tt.wantInsert != "" && (len(inserts) != 1 || !strings.HasPrefix(inserts[0], tt.wantInsert)):
+				t.Errorf("inserts = %q, want %s", inserts, tt.wantInsert)
+			}
+		})
+	}
+}
+
+func TestSignUpJoinsDefaultOrganization(t *testing.T) {
+	db, fake := newFakeDB(t,
+		fakeRow{match: `FROM "roles"`, column: "name", value: "member"},
+		fakeRow{match: `FROM "organizations"`, column: "id", value: int64(5)},
+		fakeRow{match: `INSERT INTO "users"`, column: "id", value: int64(3)},
+	)
+
+	id, err := NewAuthRepository(db).CreateUser(context.Background(), entities.SignUpInput{Name: "Alice", Username: "alice", Password: "hash", City: "Paris"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 3 {
+		t.Errorf("id = %d, want 3", id)
+	}
+	// Новый пользователь попадает в организацию default с ролью по умолчанию
+	if inserts := fake.queriesWith(`INSERT INTO "organization_members"`); len(inserts) != 1 || !strings.Contains(inserts[0], "VALUES (5, 3, 'member', DEFAULT)") {
+		t.Errorf("membership inserts = %q, want one into organization 5", inserts)
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+// GetTwoFactor возвращает настройки двухфакторной аутентификации пользователя.
+func (r *AuthRepository) GetTwoFactor(ctx context.Context, userID int) (bunEntities.TwoFactor, error) {
+	var twoFactor bunEntities.TwoFactor
+
+	err := r.db.NewSelect().
+		Model(&twoFactor).
+		Where("user_id = ?", userID).
+		Scan(ctx)
+
+	if err != nil {
+		return bunEntities.TwoFactor{}, err
+	}
+
+	return twoFactor, nil
+}
+
+// SaveTwoFactorSecret сохраняет новый секрет TOTP, ожидающий подтверждения.
+// Секрет уже включенной 2FA не перезаписывается.
+func (r *AuthRepository) SaveTwoFactorSecret(ctx context.Context, userID int, secret string) error {
+	twoFactor := &bunEntities.TwoFactor{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+
+	res, err := r.db.NewInsert().
+		Model(twoFactor).
+		On("CONFLICT (user_id) DO UPDATE").
+		Set("secret = EXCLUDED.secret").
+		Set("created_at = EXCLUDED.created_at").
+		Set("last_used_step = 0").
+		Where("tf.enabled = FALSE").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return errors.New("two-factor authentication is already enabled")
+	}
+	return nil
+}
+
+// EnableTwoFactor включает 2FA и заменяет коды восстановления пользователя.
+func (r *AuthRepository) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model((*bunEntities.TwoFactor)(nil)).
+			Set("enabled = TRUE").
+			Set("enabled_at = ?", time.Now()).
+			Set("last_used_step = ?", step).
+			Where("user_id = ?", userID).
+			Where("enabled = FALSE").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+			return errors.New("two-factor enrollment not found")
+		}
+
+		return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+	})
+}
+
+// UseTwoFactorStep отмечает интервал TOTP как использованный.
+// Возвращает false, если код этого или более позднего интервала уже применялся.
+func (r *AuthRepository) UseTwoFactorStep(ctx context.Context, userID int, step int64) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*bunEntities.TwoFactor)(nil)).
+		Set("last_used_step = ?", step).
+		Where("user_id = ?", userID).
+		Where("last_used_step < ?", step).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	return rows == 1, err
+}
+
+// UseRecoveryCode погашает неиспользованный код восстановления.
+func (r *AuthRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := r.db.NewUpdate().
+		Model((*bunEntities.RecoveryCode)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("code_hash = ?", codeHash).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	return rows == 1, err
+}
+
+// DeleteTwoFactor отключает 2FA и удаляет коды восстановления пользователя.
+func (r *AuthRepository) DeleteTwoFactor(ctx context.Context, userID int) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*bunEntities.TwoFactor)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := tx.NewDelete().
+			Model((*bunEntities.RecoveryCode)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx)
+		return err
+	})
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx bun.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.NewDelete().
+		Model((*bunEntities.RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx); err != nil {
+		return err
+	}
+
+	codes := make([]bunEntities.RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, bunEntities.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewInsert().Model(&codes).Exec(ctx)
+	return err
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// UsersRepository работает с пользователями. Если ctx ограничен организацией (WithOrganization),
+// запросы видят только ее участников, а новые пользователи сразу становятся ее участниками.
 type UsersRepository struct {
 	db *bun.DB
 }
@@ -20,7 +22,7 @@ func NewUsersRepository(db *bun.DB) *UsersRepository {
 
 func (r *UsersRepository) GetAllUsers(ctx context.Context) ([]bunEntities.User, error) {
 	var users []bunEntities.User
-	if err := r.db.NewSelect().Model(&users).Scan(ctx); err != nil {
+	if err := r.db.NewSelect().Model(&users).ApplyQueryBuilder(scopeToOrganization(ctx)).Scan(ctx); err != nil {
 		return nil, err
 	}
 	return users, nil
@@ -28,7 +30,7 @@ func (r *UsersRepository) GetAllUsers(ctx context.Context) ([]bunEntities.User,
 
 func (r *UsersRepository) GetUserByID(ctx context.Context, id int) (*bunEntities.User, error) {
 	var user bunEntities.User
-	if err := r.db.NewSelect().Model(&user).Where("id = ?", id).Scan(ctx); err != nil {
+	if err := r.db.NewSelect().Model(&user).Where("id = ?", id).ApplyQueryBuilder(scopeToOrganization(ctx)).Scan(ctx); err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -51,17 +53,41 @@ func (r *UsersRepository) CreateUser(ctx context.Context, user entities.CreateUs
 		return 0, errors.New("user with this username already exists")
 	}
 
+	if user.Email != "" {
+		if err := checkEmailAvailable(ctx, r.db, user.Email, 0); err != nil {
+			return 0, err
+		}
+	}
+
 	// Создаем нового пользователя
 	newUser := &bunEntities.User{
 		Role:         user.Role,
 		Name:         user.Name,
 		Username:     user.Username,
+		Email:        optionalString(user.Email),
 		PasswordHash: user.Password,
 		City:         user.City,
 	}
 
-	// Сохраняем пользователя в БД
-	_, err = r.db.NewInsert().Model(newUser).Exec(ctx)
+	// Сохраняем пользователя в БД; в организации из ctx он получает роль по умолчанию,
+	// без организации в ctx попадает в организацию default со своей глобальной ролью
+	err = r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(newUser).Exec(ctx); err != nil {
+			return err
+		}
+		organizationID, ok := OrganizationFromContext(ctx)
+		if !ok {
+			return JoinDefaultOrganization(ctx, tx, newUser.ID, newUser.Role)
+		}
+		role, err := getDefaultRole(ctx, tx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewInsert().
+			Model(&bunEntities.OrganizationMember{OrganizationID: organizationID, UserID: newUser.ID, Role: role}).
+			Exec(ctx)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -87,6 +113,11 @@ func (r *UsersRepository) UpdateUser(ctx context.Context, userID int, user entit
 			return errors.New("user with this username already exists")
 		}
 	}
+	if user.Email != nil && *user.Email != "" {
+		if err := checkEmailAvailable(ctx, r.db, *user.Email, userID); err != nil {
+			return err
+		}
+	}
 
 	// Подготовка обновляемых полей
 	updatedUser := &bunEntities.User{}
@@ -104,6 +135,10 @@ func (r *UsersRepository) UpdateUser(ctx context.Context, userID int, user entit
 		updatedUser.Username = *user.Username
 		columnsToUpdate = append(columnsToUpdate, "username")
 	}
+	if user.Email != nil {
+		updatedUser.Email = optionalString(*user.Email)
+		columnsToUpdate = append(columnsToUpdate, "email")
+	}
 	if user.Password != nil {
 		updatedUser.PasswordHash = *user.Password
 		columnsToUpdate = append(columnsToUpdate, "password_hash")
@@ -113,17 +148,106 @@ func (r *UsersRepository) UpdateUser(ctx context.Context, userID int, user entit
 		columnsToUpdate = append(columnsToUpdate, "city")
 	}
 
-	// Выполняем обновление только тех полей, которые нужно изменить
-	_, err := r.db.NewUpdate().
-		Model(updatedUser).
-		Column(columnsToUpdate...).
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// Пользователь вне организации из ctx считается несуществующим
+		exists, err := tx.NewSelect().
+			Model((*bunEntities.User)(nil)).
+			Where("id = ?", userID).
+			ApplyQueryBuilder(scopeToOrganization(ctx)).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return sql.ErrNoRows
+		}
+
+		// Новый адрес нужно подтвердить заново
+		if user.Email != nil {
+			if _, err := tx.NewUpdate().
+				Model((*bunEntities.User)(nil)).
+				Set("email_verified_at = NULL").
+				Where("id = ?", userID).
+				Where("lower(coalesce(email, '')) != lower(?)", *user.Email).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		// Выполняем обновление только тех полей, которые нужно изменить
+		_, err = tx.NewUpdate().
+			Model(updatedUser).
+			Column(columnsToUpdate...).
+			Where("id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Смена пароля делает недействительными ранее отправленные ссылки для входа
+		if user.Password != nil {
+			if _, err := tx.NewUpdate().
+				Model((*bunEntities.User)(nil)).
+				Set("password_changed_at = now()").
+				Where("id = ?", userID).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		// Смена роли делает недействительными ранее выданные access token
+		if user.Role != nil {
+			_, err = tx.NewUpdate().
+				Model((*bunEntities.User)(nil)).
+				Set("token_version = token_version + 1").
+				Where("id = ?", userID).
+				Exec(ctx)
+		}
+		return err
+	})
+}
+
+// DeleteUser удаляет пользователя. Возвращает sql.ErrNoRows, если пользователя нет (или он вне организации из ctx).
+func (r *UsersRepository) DeleteUser(ctx context.Context, userID int) error {
+	res, err := r.db.NewDelete().
+		Model((*bunEntities.User)(nil)).
 		Where("id = ?", userID).
+		ApplyQueryBuilder(scopeToOrganization(ctx)).
 		Exec(ctx)
+	if err != nil {
+		return err
+	}
 
-	return err
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-func (r *UsersRepository) DeleteUser(ctx context.Context, userID int) error {
-	_, err := r.db.NewDelete().Model((*bunEntities.User)(nil)).Where("id = ?", userID).Exec(ctx)
-	return err
+// optionalString преобразует пустую строку в NULL.
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// checkEmailAvailable возвращает ошибку, если адрес без учета регистра уже занят другим пользователем.
+func checkEmailAvailable(ctx context.Context, db bun.IDB, email string, userID int) error {
+	exists, err := db.NewSelect().
+		Model((*bunEntities.User)(nil)).
+		Where("lower(email) = lower(?)", email).
+		Where("id != ?", userID).
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("user with this email already exists")
+	}
+	return nil
 }
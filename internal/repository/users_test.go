@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+)
+
+func TestUsersRepositoryOrganizationScope(t *testing.T) {
+	name := "Alice"
+	operations := []struct {
+		name  string
+		query string // Запрос к users, который должен учитывать организацию
+		run   func(ctx context.Context, r *UsersRepository)
+	}{
+		{name: "get all", query: `FROM "users"`, run: func(ctx context.Context, r *UsersRepository) { r.GetAllUsers(ctx) }},
+		{name: "get by id", query: `FROM "users"`, run: func(ctx context.Context, r *UsersRepository) { r.GetUserByID(ctx, 1) }},
+		{name: "update", query: `SELECT EXISTS`, run: func(ctx context.Context, r *UsersRepository) {
+			r.UpdateUser(ctx, 1, entities.UserUpdateInput{Name: &name})
+		}},
+		{name: "delete", query: `DELETE FROM "users"`, run: func(ctx context.Context, r *UsersRepository) { r.DeleteUser(ctx, 1) }},
+	}
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantScope bool
+	}{
+		{name: "without organization", ctx: context.Background()},
+		{name: "with organization", ctx: WithOrganization(context.Background(), 7), wantScope: true},
+	}
+
+	for _, tt := range tests {
+		for _, operation := range operations {
+			t.Run(tt.name+" "+operation.name, func(t *testing.T) {
+				db, fake := newFakeDB(t)
+				operation.run(tt.ctx, NewUsersRepository(db))
+
+				queries := fake.queriesWith(operation.query)
+				if len(queries) != 1 {
+					t.Fatalf("queries = %q, want one with %s", fake.queries, operation.query)
+				}
+				if strings.Contains(queries[0], organizationScope) != tt.wantScope {
+					t.Errorf("query = %s, want scope %v", queries[0], tt.wantScope)
+				}
+				// Без организации запросы не обращаются к участникам
+				if !tt.wantScope && len(fake.queriesWith("organization_members")) != 0 {
+					t.Errorf("queries = %q, want no organization members", fake.queries)
+				}
+			})
+		}
+	}
+}
+
+func TestUsersRepositoryCreateUserMembership(t *testing.T) {
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		wantMembership string // Значения вставки в organization_members
+	}{
+		{name: "active organization with default role", ctx: WithOrganization(context.Background(), 7), wantMembership: "VALUES (7, 3, 'member', DEFAULT)"},
+		{name: "default organization with global role", ctx: context.Background(), wantMembership: "VALUES (5, 3, 'editor', DEFAULT)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t,
+				fakeRow{match: `FROM "roles"`, column: "name", value: "member"},
+				fakeRow{match: `FROM "organizations"`, column: "id", value: int64(5)},
+				fakeRow{match: `INSERT INTO "users"`, column: "id", value: int64(3)},
+			)
+
+			id, err := NewUsersRepository(db).CreateUser(tt.ctx, entities.CreateUserInput{Role: "editor", Name: "Alice", Username: "alice", Password: "hash", City: "Paris"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != 3 {
+				t.Errorf("id = %d, want 3", id)
+			}
+			if inserts := fake.queriesWith(`INSERT INTO "organization_members"`); len(inserts) != 1 || !strings.Contains(inserts[0], tt.wantMembership) {
+				t.Errorf("membership inserts = %q, want %s", inserts, tt.wantMembership)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var ErrAccessDenied = errors.New("access denied")
+
+// AccessPolicy решает, можно ли работать с учетной записью пользователя, по атрибутам того,
+// кто выполняет запрос, и самого пользователя. Запрещающее правило важнее всего остального,
+// затем доступ дают своя учетная запись, право роли и разрешающие правила.
+type AccessPolicy struct {
+	rules []entities.AccessRule
+
+	subjectCity         bool // Правилам нужен город субъекта
+	targetOrganizations bool // Правилам нужны организации пользователя
+}
+
+// NewAccessPolicy загружает правила из файла. Без файла доступ определяют только права ролей.
+func NewAccessPolicy(cfg *config.AccessPolicy) (*AccessPolicy, error) {
+	policy := &AccessPolicy{}
+	if cfg.File == "" {
+		return policy, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(cfg.File)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.New("can't read access policy file: " + err.Error())
+	}
+	if err := v.UnmarshalKey("policies", &policy.rules); err != nil {
+		return nil, errors.New("can't parse access policy file: " + err.Error())
+	}
+
+	for _, rule := range policy.rules {
+		if err := rule.ValidateAccessRule(); err != nil {
+			return nil, err
+		}
+		if rule.SameCity || len(rule.Subject.Cities) > 0 {
+			policy.subjectCity = true
+		}
+		if rule.SameOrganization || len(rule.Target.Organizations) > 0 {
+			policy.targetOrganizations = true
+		}
+	}
+	logrus.Infof("%d access rules loaded from %s", len(policy.rules), cfg.File)
+	return policy, nil
+}
+
+// accessSubject — субъект запроса вместе с атрибутами из базы
+type accessSubject struct {
+	entities.AccessSubject
+	City string
+}
+
+// accessTarget — пользователь, к которому обращается запрос
+type accessTarget struct {
+	ID            int
+	Role          string
+	City          string
+	Organizations []int
+}
+
+// enabled сообщает, заданы ли правила
+func (p *AccessPolicy) enabled() bool {
+	return len(p.rules) > 0
+}
+
+// decide применяет правила к запросу; granted — доступ уже есть как к своей учетной записи или по праву роли
+func (p *AccessPolicy) decide(action string, subject accessSubject, target accessTarget, granted bool) bool {
+	allowed := granted
+	for _, rule := range p.rules {
+		if !ruleMatches(rule, action, subject, target) {
+			continue
+		}
+		if rule.Effect == entities.AccessDeny {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+func ruleMatches(rule entities.AccessRule, action string, subject accessSubject, target accessTarget) bool {
+	if !slices.Contains(rule.Actions, action) {
+		return false
+	}
+	var subjectOrganizations []int
+	if subject.OrganizationID != 0 {
+		subjectOrganizations = []int{subject.OrganizationID}
+	}
+	if !attributesMatch(rule.Subject, subject.Role, subject.City, subjectOrganizations) ||
+		!attributesMatch(rule.Target, target.Role, target.City, target.Organizations) {
+		return false
+	}
+	if rule.SameCity && (normalizeCity(subject.City) == "" || normalizeCity(subject.City) != normalizeCity(target.City)) {
+		return false
+	}
+	if rule.SameOrganization && (subject.OrganizationID == 0 || !slices.Contains(target.Organizations, subject.OrganizationID)) {
+		return false
+	}
+	return true
+}
+
+// attributesMatch проверяет атрибуты против условий правила; пустое условие подходит всем
+func attributesMatch(attributes entities.AccessAttributes, role string, city string, organizations []int) bool {
+	if len(attributes.Roles) > 0 && !slices.Contains(attributes.Roles, role) {
+		return false
+	}
+	if len(attributes.Cities) > 0 && !slices.ContainsFunc(attributes.Cities, func(c string) bool {
+		return normalizeCity(c) == normalizeCity(city)
+	}) {
+		return false
+	}
+	if len(attributes.Organizations) > 0 && !slices.ContainsFunc(attributes.Organizations, func(id int) bool {
+		return slices.Contains(organizations, id)
+	}) {
+		return false
+	}
+	return true
+}
+
+func normalizeCity(city string) string {
+	return strings.ToLower(strings.TrimSpace(city))
+}
+
+// accessPermission возвращает право роли, которое дает действие над любым пользователем
+func accessPermission(action string) string {
+	if action == entities.AccessRead {
+		return entities.PermissionUsersRead
+	}
+	return entities.PermissionUsersWrite
+}
+
+// accessGranted сообщает, есть ли доступ без правил: к своей учетной записи или по праву роли
+func accessGranted(subject entities.AccessSubject, action string, userID int) bool {
+	if subject.UserID != 0 && subject.UserID == userID {
+		return true
+	}
+	return slices.Contains(subject.Permissions, accessPermission(action))
+}
+
+// AuthorizeUser проверяет, может ли субъект выполнить действие с пользователем. Пользователь, к которому
+// нет доступа, для субъекта без права не существует: вместо ErrUserNotFound возвращается ErrAccessDenied.
+func (s *UsersService) AuthorizeUser(ctx context.Context, subject entities.AccessSubject, action string, userID int) error {
+	granted := accessGranted(subject, action, userID)
+	if !s.policy.enabled() {
+		if granted {
+			return nil
+		}
+		return ErrAccessDenied
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err = userNotFound(err); errors.Is(err, ErrUserNotFound) && !granted {
+			return ErrAccessDenied
+		}
+		return err
+	}
+	allowed, err := s.decideAccess(ctx, subject, action, []bunEntities.User{*user})
+	if err != nil {
+		return err
+	}
+	if !allowed[0] {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// FilterUsers оставляет в списке только пользователей, с которыми субъекту разрешено действие.
+func (s *UsersService) FilterUsers(ctx context.Context, subject entities.AccessSubject, action string, users []bunEntities.User) ([]bunEntities.User, error) {
+	if !s.policy.enabled() {
+		if slices.Contains(subject.Permissions, accessPermission(action)) {
+			return users, nil
+		}
+		return slices.DeleteFunc(users, func(user bunEntities.User) bool {
+			return !accessGranted(subject, action, user.ID)
+		}), nil
+	}
+
+	allowed, err := s.decideAccess(ctx, subject, action, users)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]bunEntities.User, 0, len(users))
+	for i, user := range users {
+		if allowed[i] {
+			filtered = append(filtered, user)
+		}
+	}
+	return filtered, nil
+}
+
+// decideAccess загружает атрибуты, которые нужны правилам, и решает доступ к каждому пользователю
+func (s *UsersService) decideAccess(ctx context.Context, subject entities.AccessSubject, action string, users []bunEntities.User) ([]bool, error) {
+	attributes := accessSubject{AccessSubject: subject}
+	if s.policy.subjectCity && subject.UserID != 0 {
+		profile, err := s.authRepo.GetUserProfile(ctx, subject.UserID)
+		if err != nil {
+			return nil, err
+		}
+		attributes.City = profile.City
+	}
+
+	organizations := make(map[int][]int)
+	if s.policy.targetOrganizations {
+		ids := make([]int, 0, len(users))
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		memberships, err := s.orgRepo.GetMemberships(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, membership := range memberships {
+			organizations[membership.UserID] = append(organizations[membership.UserID], membership.OrganizationID)
+		}
+	}
+
+	allowed := make([]bool, len(users))
+	for i, user := range users {
+		allowed[i] = s.policy.decide(action, attributes, accessTarget{
+			ID:            user.ID,
+			Role:          user.Role,
+			City:          user.City,
+			Organizations: organizations[user.ID],
+		}, accessGranted(subject, action, user.ID))
+	}
+	return allowed, nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+// Региональная поддержка работает с пользователями своего города, аудитор читает участников
+// своей организации, а администраторов нельзя менять и удалять никому
+const testAccessPolicy = `
+policies:
+  - name: support-own-city
+    effect: allow
+    actions: [read, update]
+    subject:
+      roles: [support]
+    target:
+      roles: [user]
+    sameCity: true
+  - name: auditor-own-organization
+    effect: allow
+    actions: [read]
+    subject:
+      roles: [auditor]
+    sameOrganization: true
+  - name: protect-admins
+    effect: deny
+    actions: [update, delete]
+    target:
+      roles: [admin]
+`
+
+const (
+	policyAdmin   = 1 // Администратор из Москвы с правами users:read и users:write
+	policySupport = 2 // Поддержка из Москвы
+	policyAlice   = 3 // Пользователь из Москвы, организация 1
+	policyBob     = 4 // Пользователь из Казани, организация 2
+	policyCarol   = 5 // Администратор из Москвы
+	policyAuditor = 6 // Аудитор, организация 1
+	policyMissing = 99
+)
+
+// writeAccessPolicy сохраняет правила во временный файл и загружает их
+func writeAccessPolicy(t *testing.T, rules string) *AccessPolicy {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "policies.yaml")
+	if err := os.WriteFile(file, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewAccessPolicy(&config.AccessPolicy{File: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy
+}
+
+// newPolicyUsersService создает сервис с пользователями из констант выше
+func newPolicyUsersService(policy *AccessPolicy) *UsersService {
+	repo := newFakeAuthRepository()
+	repo.addUser(bunEntities.User{ID: policyAdmin, Role: entities.RoleAdmin, Username: "admin", City: "Moscow"})
+	repo.addUser(bunEntities.User{ID: policySupport, Role: "support", Username: "support", City: " moscow "})
+	repo.addUser(bunEntities.User{ID: policyAlice, Role: entities.RoleUser, Username: "alice", City: "Moscow"})
+	repo.addUser(bunEntities.User{ID: policyBob, Role: entities.RoleUser, Username: "bob", City: "Kazan"})
+	repo.addUser(bunEntities.User{ID: policyCarol, Role: entities.RoleAdmin, Username: "carol", City: "Moscow"})
+	repo.addUser(bunEntities.User{ID: policyAuditor, Role: "auditor", Username: "auditor"})
+	repo.addMember(1, policyAlice, entities.RoleUser)
+	repo.addMember(1, policyAuditor, "auditor")
+	repo.addMember(2, policyBob, entities.RoleUser)
+	return NewUsersService(&fakeUsersRepository{auth: repo}, repo, nil, &fakeOrganizationsRepository{auth: repo}, nil, nil, nil, policy)
+}
+
+// policySubject возвращает субъект запроса пользователя userID
+func policySubject(userID int) entities.AccessSubject {
+	switch userID {
+	case policyAdmin:
+		return entities.AccessSubject{UserID: userID, Role: entities.RoleAdmin, Permissions: []string{entities.PermissionUsersRead, entities.PermissionUsersWrite}}
+	case policySupport:
+		return entities.AccessSubject{UserID: userID, Role: "support"}
+	case policyAuditor:
+		return entities.AccessSubject{UserID: userID, Role: "auditor", OrganizationID: 1}
+	default:
+		return entities.AccessSubject{UserID: userID, Role: entities.RoleUser}
+	}
+}
+
+func TestAuthorizeUser(t *testing.T) {
+	auditorWithoutOrganization := policySubject(policyAuditor)
+	auditorWithoutOrganization.OrganizationID = 0
+
+	tests := []struct {
+		name    string
+		subject entities.AccessSubject
+		action  string
+		userID  int
+		wantErr error
+	}{
+		{name: "support reads user of own city", subject: policySubject(policySupport), action: entities.AccessRead, userID: policyAlice},
+		{name: "support updates user of own city", subject: policySubject(policySupport), action: entities.AccessUpdate, userID: policyAlice},
+		{name: "support deletes user of own city", subject: policySubject(policySupport), action: entities.AccessDelete, userID: policyAlice, wantErr: ErrAccessDenied},
+		{name: "support reads user of another city", subject: policySubject(policySupport), action: entities.AccessRead, userID: policyBob, wantErr: ErrAccessDenied},
+		{name: "support reads admin of own city", subject: policySubject(policySupport), action: entities.AccessRead, userID: policyCarol, wantErr: ErrAccessDenied},
+		{name: "support reads nonexistent user", subject: policySubject(policySupport), action: entities.AccessRead, userID: policyMissing, wantErr: ErrAccessDenied},
+		{name: "support updates self", subject: policySubject(policySupport), action: entities.AccessUpdate, userID: policySupport},
+		{name: "auditor reads member of own organization", subject: policySubject(policyAuditor), action: entities.AccessRead, userID: policyAlice},
+		{name: "auditor reads member of another organization", subject: policySubject(policyAuditor), action: entities.AccessRead, userID: policyBob, wantErr: ErrAccessDenied},
+		{name: "auditor updates member of own organization", subject: policySubject(policyAuditor), action: entities.AccessUpdate, userID: policyAlice, wantErr: ErrAccessDenied},
+		{name: "auditor without active organization", subject: auditorWithoutOrganization, action: entities.AccessRead, userID: policyAlice, wantErr: ErrAccessDenied},
+		{name: "admin reads any user", subject: policySubject(policyAdmin), action: entities.AccessRead, userID: policyBob},
+		{name: "admin deletes user", subject: policySubject(policyAdmin), action: entities.AccessDelete, userID: policyBob},
+		{name: "deny rule wins over permission", subject: policySubject(policyAdmin), action: entities.AccessDelete, userID: policyCarol, wantErr: ErrAccessDenied},
+		{name: "deny rule wins over own account", subject: policySubject(policyCarol), action: entities.AccessUpdate, userID: policyCarol, wantErr: ErrAccessDenied},
+		{name: "admin reads nonexistent user", subject: policySubject(policyAdmin), action: entities.AccessRead, userID: policyMissing, wantErr: ErrUserNotFound},
+		{name: "user reads self", subject: policySubject(policyAlice), action: entities.AccessRead, userID: policyAlice},
+		{name: "user reads another user", subject: policySubject(policyAlice), action: entities.AccessRead, userID: policyBob, wantErr: ErrAccessDenied},
+	}
+
+	s := newPolicyUsersService(writeAccessPolicy(t, testAccessPolicy))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.AuthorizeUser(context.Background(), tt.subject, tt.action, tt.userID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserWithoutPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject entities.AccessSubject
+		action  string
+		userID  int
+		wantErr error
+	}{
+		{name: "own account", subject: policySubject(policyAlice), action: entities.AccessDelete, userID: policyAlice},
+		{name: "another user", subject: policySubject(policyAlice), action: entities.AccessRead, userID: policyBob, wantErr: ErrAccessDenied},
+		{name: "permission", subject: policySubject(policyAdmin), action: entities.AccessUpdate, userID: policyCarol},
+		{name: "support has no rules", subject: policySubject(policySupport), action: entities.AccessRead, userID: policyAlice, wantErr: ErrAccessDenied},
+	}
+
+	policy, err := NewAccessPolicy(&config.AccessPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newPolicyUsersService(policy)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.AuthorizeUser(context.Background(), tt.subject, tt.action, tt.userID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilterUsers(t *testing.T) {
+	all := []int{policyAdmin, policySupport, policyAlice, policyBob, policyCarol, policyAuditor}
+
+	tests := []struct {
+		name     string
+		rules    string // Пусто — без правил
+		subject  entities.AccessSubject
+		action   string
+		wantUser []int
+	}{
+		{name: "support", rules: testAccessPolicy, subject: policySubject(policySupport), action: entities.AccessRead, wantUser: []int{policySupport, policyAlice}},
+		{name: "auditor", rules: testAccessPolicy, subject: policySubject(policyAuditor), action: entities.AccessRead, wantUser: []int{policyAlice, policyAuditor}},
+		{name: "admin", rules: testAccessPolicy, subject: policySubject(policyAdmin), action: entities.AccessRead, wantUser: all},
+		{name: "admin with deny rule", rules: testAccessPolicy, subject: policySubject(policyAdmin), action: entities.AccessDelete, wantUser: []int{policySupport, policyAlice, policyBob, policyAuditor}},
+		{name: "user", rules: testAccessPolicy, subject: policySubject(policyBob), action: entities.AccessRead, wantUser: []int{policyBob}},
+		{name: "user without policy", subject: policySubject(policyBob), action: entities.AccessRead, wantUser: []int{policyBob}},
+		{name: "admin without policy", subject: policySubject(policyAdmin), action: entities.AccessRead, wantUser: all},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &AccessPolicy{}
+			if tt.rules != "" {
+				policy = writeAccessPolicy(t, tt.rules)
+			}
+			s := newPolicyUsersService(policy)
+
+			users := make([]bunEntities.User, 0, len(all))
+			for _, id := range all {
+				user, err := s.repo.GetUserByID(context.Background(), id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				users = append(users, *user)
+			}
+
+			filtered, err := s.FilterUsers(context.Background(), tt.subject, tt.action, users)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int, 0, len(filtered))
+			for _, user := range filtered {
+				ids = append(ids, user.ID)
+			}
+			if !slices.Equal(ids, tt.wantUser) {
+				t.Errorf("got %v, want %v", ids, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestNewAccessPolicyInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{name: "unknown effect", rules: "policies:\n  - name: r\n    effect: maybe\n    actions: [read]\n"},
+		{name: "unknown action", rules: "policies:\n  - name: r\n    effect: allow\n    actions: [create]\n"},
+		{name: "no actions", rules: "policies:\n  - name: r\n    effect: allow\n"},
+		{name: "no name", rules: "policies:\n  - effect: allow\n    actions: [read]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "policies.yaml")
+			if err := os.WriteFile(file, []byte(tt.rules), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewAccessPolicy(&config.AccessPolicy{File: file}); err == nil {
+				t.Error("invalid policy loaded")
+			}
+		})
+	}
+
+	if _, err := NewAccessPolicy(&config.AccessPolicy{File: filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("missing policy file loaded")
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Префиксы отличают API-токены от JWT и друг от друга
+const (
+	personalAccessTokenPrefix = "pat_"
+	serviceAPIKeyPrefix       = "sak_"
+
+	apiTokenDisplayLength = 8 // Сколько символов токена после префикса показывается в списке
+)
+
+var (
+	ErrInvalidAPIToken     = errors.New("invalid api token")
+	ErrAPITokenNotFound    = errors.New("api token not found")
+	ErrAPITokenAdminScope  = errors.New("admin scope requires a role with administrative permissions")
+	ErrUnknownAPITokenKind = errors.New("unknown api token kind")
+)
+
+type APITokenService struct {
+	repo     repository.APITokens
+	authRepo repository.Authorization
+}
+
+func NewAPITokenService(repo repository.APITokens, authRepo repository.Authorization) *APITokenService {
+	return &APITokenService{repo: repo, authRepo: authRepo}
+}
+
+// IsAPIToken сообщает, похожа ли строка на API-токен, а не на JWT.
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, personalAccessTokenPrefix) || strings.HasPrefix(token, serviceAPIKeyPrefix)
+}
+
+// CreateAPIToken выдает токен пользователю. Токен хранится только в виде хэша и возвращается один раз.
+func (s *APITokenService) CreateAPIToken(ctx context.Context, userID int, kind string, input entities.APITokenInput) (entities.APITokenCreated, error) {
+	var prefix string
+	switch kind {
+	case entities.APITokenKindPersonal:
+		prefix = personalAccessTokenPrefix
+	case entities.APITokenKindService:
+		prefix = serviceAPIKeyPrefix
+	default:
+		return entities.APITokenCreated{}, ErrUnknownAPITokenKind
+	}
+
+	if entities.HasAdminScope(input.Scopes) {
+		role, err := s.authRepo.GetRole(ctx, userID)
+		if err != nil {
+			return entities.APITokenCreated{}, err
+		}
+		administrative, err := isAdministrativeRole(ctx, s.authRepo, role)
+		if err != nil {
+			return entities.APITokenCreated{}, err
+		}
+		if !administrative {
+			return entities.APITokenCreated{}, ErrAPITokenAdminScope
+		}
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return entities.APITokenCreated{}, err
+	}
+	token := prefix + secret
+
+	stored := bunEntities.APIToken{
+		UserID:      userID,
+		Kind:        kind,
+		Name:        input.Name,
+		TokenHash:   hashToken(token),
+		TokenPrefix: prefix + secret[:apiTokenDisplayLength],
+		Scopes:      slices.Compact(slices.Sorted(slices.Values(input.Scopes))),
+		ExpiresAt:   input.ExpiresAt,
+		CreatedAt:   time.Now(),
+	}
+	stored.ID, err = s.repo.CreateAPIToken(ctx, stored)
+	if err != nil {
+		return entities.APITokenCreated{}, err
+	}
+
+	logrus.Infof("api token %d (%s, %q) created for user %d", stored.ID, kind, input.Name, userID)
+	return entities.APITokenCreated{APIToken: apiTokenInfo(stored), Token: token}, nil
+}
+
+// GetAPITokens возвращает токены указанного вида; при userID = 0 — токены всех пользователей.
+func (s *APITokenService) GetAPITokens(ctx context.Context, userID int, kind string) ([]entities.APIToken, error) {
+	tokens, err := s.repo.GetAPITokens(ctx, userID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.APIToken, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, apiTokenInfo(token))
+	}
+	return result, nil
+}
+
+// DeleteAPIToken отзывает токен; при userID = 0 владелец не проверяется.
+func (s *APITokenService) DeleteAPIToken(ctx context.Context, userID int, kind string, tokenID int) error {
+	deleted, err := s.repo.DeleteAPIToken(ctx, tokenID, userID, kind)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPITokenNotFound
+	}
+
+	logrus.Infof("api token %d (%s) revoked", tokenID, kind)
+	return nil
+}
+
+// AuthenticateAPIToken проверяет API-токен и возвращает его владельца.
+// Роль владельца проверяется при каждом запросе, поэтому понижение роли сразу ограничивает его токены.
+func (s *APITokenService) AuthenticateAPIToken(ctx context.Context, token string, clientIP string) (entities.APITokenPrincipal, error) {
+	if !IsAPIToken(token) {
+		return entities.APITokenPrincipal{}, ErrInvalidAPIToken
+	}
+
+	stored, err := s.repo.GetAPITokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.APITokenPrincipal{}, ErrInvalidAPIToken
+		}
+		return entities.APITokenPrincipal{}, err
+	}
+	if stored.ExpiresAt != nil && stored.ExpiresAt.Before(time.Now()) {
+		return entities.APITokenPrincipal{}, ErrInvalidAPIToken
+	}
+
+	role, err := s.authRepo.GetRole(ctx, stored.UserID)
+	if err != nil {
+		return entities.APITokenPrincipal{}, err
+	}
+	role, err = apiTokenRole(ctx, s.authRepo, role, stored.Scopes)
+	if err != nil {
+		return entities.APITokenPrincipal{}, err
+	}
+
+	// Токен ограничен той же организацией, что и access token владельца после входа
+	organizationID, err := s.authRepo.GetFirstOrganization(ctx, stored.UserID)
+	if err != nil {
+		return entities.APITokenPrincipal{}, err
+	}
+
+	if err := s.repo.TouchAPIToken(ctx, stored.ID, clientIP); err != nil {
+		logrus.Errorf("can't update last use of api token %d: %s", stored.ID, err.Error())
+	}
+
+	return entities.APITokenPrincipal{
+		TokenID:        stored.ID,
+		UserID:         stored.UserID,
+		Role:           role,
+		Scopes:         stored.Scopes,
+		OrganizationID: organizationID,
+	}, nil
+}
+
+// apiTokenRole возвращает роль, с которой действует API-токен: права роли владельца
+// достаются только токену со scope для /admin, остальные действуют с ролью по умолчанию,
+// как обычный пользователь.
+func apiTokenRole(ctx context.Context, repo repository.Authorization, ownerRole string, scopes []string) (string, error) {
+	if entities.HasAdminScope(scopes) {
+		return ownerRole, nil
+	}
+	return repo.GetDefaultRole(ctx)
+}
+
+// apiTokenInfo преобразует токен из базы в описание для API.
+func apiTokenInfo(token bunEntities.APIToken) entities.APIToken {
+	return entities.APIToken{
+		ID:         token.ID,
+		UserID:     token.UserID,
+		Kind:       token.Kind,
+		Name:       token.Name,
+		Prefix:     token.TokenPrefix,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		CreatedAt:  token.CreatedAt,
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+func TestAPITokenRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		wantRole string
+	}{
+		{name: "read scope", scopes: []string{entities.APIScopeRead}, wantRole: "member"},
+		{name: "resource scope", scopes: []string{entities.ScopeProfileWrite}, wantRole: "member"},
+		{name: "admin scope", scopes: []string{entities.APIScopeRead, entities.APIScopeAdmin}, wantRole: entities.RoleAdmin},
+		{name: "admin read scope", scopes: []string{entities.ScopeAdminRead}, wantRole: entities.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			authRepo := newFakeAuthRepository()
+			authRepo.defaultRole = "member"
+			authRepo.addUser(bunEntities.User{ID: 1, Role: entities.RoleAdmin, Username: "admin"})
+			authRepo.rolePermissions[entities.RoleAdmin] = []string{entities.PermissionUsersRead}
+			repo := newFakeAPITokenRepository()
+			s := NewAPITokenService(repo, authRepo)
+
+			created, err := s.CreateAPIToken(ctx, 1, entities.APITokenKindPersonal, entities.APITokenInput{Name: "ci", Scopes: tt.scopes})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			principal, err := s.AuthenticateAPIToken(ctx, created.Token, "192.0.2.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", principal.Role, tt.wantRole)
+			}
+
+			// Интроспекция сообщает ту же роль, с которой токен действует
+			introspection, err := NewOAuthService(newFakeOAuthRepository(), authRepo, nil, repo, &config.OAuth{}).introspectAPIToken(ctx, hashToken(created.Token))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !introspection.Active || introspection.Role != tt.wantRole {
+				t.Errorf("introspection = %+v, want active with role %q", introspection, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPITokenOrganization(t *testing.T) {
+	tests := []struct {
+		name        string
+		memberships []int // Организации владельца
+		wantOrg     int   // 0 — без организации
+	}{
+		{name: "no organizations"},
+		{name: "first organization", memberships: []int{4, 2}, wantOrg: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			authRepo := newFakeAuthRepository()
+			authRepo.addUser(bunEntities.User{ID: 1, Role: entities.RoleAdmin, Username: "admin"})
+			authRepo.rolePermissions[entities.RoleAdmin] = []string{entities.PermissionUsersRead}
+			for _, organizationID := range tt.memberships {
+				authRepo.addMember(organizationID, 1, entities.RoleAdmin)
+			}
+			s := NewAPITokenService(newFakeAPITokenRepository(), authRepo)
+
+			created, err := s.CreateAPIToken(ctx, 1, entities.APITokenKindPersonal, entities.APITokenInput{Name: "ci", Scopes: []string{entities.ScopeAdminRead}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			principal, err := s.AuthenticateAPIToken(ctx, created.Token, "192.0.2.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Токен ограничен той же организацией, что и access token владельца после входа
+			organizationID := 0
+			if principal.OrganizationID != nil {
+				organizationID = *principal.OrganizationID
+			}
+			if organizationID != tt.wantOrg {
+				t.Errorf("organization = %d, want %d", organizationID, tt.wantOrg)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		input   entities.APITokenInput
+		prepare func(s *APITokenService, repo *fakeAPITokenRepository, created entities.APITokenCreated) string // Возвращает предъявляемый токен
+		wantErr error
+	}{
+		{name: "personal token", kind: entities.APITokenKindPersonal, input: entities.APITokenInput{Name: "ci", Scopes: []string{entities.APIScopeRead}}},
+		{name: "service key", kind: entities.APITokenKindService, input: entities.APITokenInput{Name: "billing", Scopes: []string{entities.APIScopeRead}}},
+		{name: "unknown token", kind: entities.APITokenKindPersonal, input: entities.APITokenInput{Name: "ci", Scopes: []string{entities.APIScopeRead}},
+			prepare: func(s *APITokenService, repo *fakeAPITokenRepository, created entities.APITokenCreated) string {
+				return created.Token + "x"
+			}, wantErr: ErrInvalidAPIToken},
+		{name: "not an api token", kind: entities.APITokenKindPersonal, input: entities.APITokenInput{Name: "ci", Scopes: []string{entities.APIScopeRead}},
+			prepare: func(s *APITokenService, repo *fakeAPITokenRepository, created entities.APITokenCreated) string {
+				return strings.TrimPrefix(created.Token, personalAccessTokenPrefix)
+			}, wantErr: ErrInvalidAPIToken},
+		{name: "expired token", kind: entities.APITokenKindPersonal, input: entities.APITokenInput{Name: "ci", Scopes: []string{entities.APIScopeRead}},
+			prepare: func(s *APITokenService, repo *fakeAPITokenRepository, created entities.APITokenCreated) string {
+				token := repo.tokens[created.ID]
+				expiresAt := time.Now().Add(-time.Second)
+				token.ExpiresAt = &expiresAt
+				repo.tokens[created.ID] = token
+				return created.Token
+			}, wantErr: ErrInvalidAPIToken},
+		{name: "revoked token", kind: entities.APITokenKindPersonal, input: entities.APITokenInput{Name: "ci", Scopes: []string{entities.APIScopeRead}},
+			prepare: func(s *APITokenService, repo *fakeAPITokenRepository, created entities.APITokenCreated) string {
+				if err := s.DeleteAPIToken(context.Background(), 1, entities.APITokenKindPersonal, created.ID); err != nil {
+					panic(err)
+				}
+				return created.Token
+			}, wantErr: ErrInvalidAPIToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			authRepo := newFakeAuthRepository()
+			authRepo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice"})
+			repo := newFakeAPITokenRepository()
+			s := NewAPITokenService(repo, authRepo)
+
+			created, err := s.CreateAPIToken(ctx, 1, tt.kind, tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// В базе хранится только хэш, в списке видно лишь начало токена
+			stored := repo.tokens[created.ID]
+			if stored.TokenHash == created.Token || !strings.HasPrefix(created.Token, stored.TokenPrefix) || len(stored.TokenPrefix) >= len(created.Token) {
+				t.Errorf("stored token %+v exposes the secret %q", stored, created.Token)
+			}
+
+			token := created.Token
+			if tt.prepare != nil {
+				token = tt.prepare(s, repo, created)
+			}
+			principal, err := s.AuthenticateAPIToken(ctx, token, "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if principal.TokenID != created.ID || principal.UserID != 1 || !slices.Equal(principal.Scopes, tt.input.Scopes) {
+				t.Errorf("principal = %+v, want token %d of user 1 with scopes %v", principal, created.ID, tt.input.Scopes)
+			}
+			if used := repo.tokens[created.ID]; used.LastUsedAt == nil || used.LastUsedIP != "192.0.2.1" {
+				t.Errorf("last use = %v from %q, want now from 192.0.2.1", used.LastUsedAt, used.LastUsedIP)
+			}
+		})
+	}
+}
+
+func TestCreateAPITokenScopes(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		kind       string
+		scopes     []string
+		wantScopes []string
+		wantErr    error
+	}{
+		{name: "scopes sorted and deduplicated", role: entities.RoleUser, kind: entities.APITokenKindPersonal, scopes: []string{entities.ScopeProfileWrite, entities.APIScopeRead, entities.ScopeProfileWrite}, wantScopes: []string{entities.ScopeProfileWrite, entities.APIScopeRead}},
+		{name: "admin scope for regular user", role: entities.RoleUser, kind: entities.APITokenKindPersonal, scopes: []string{entities.APIScopeAdmin}, wantErr: ErrAPITokenAdminScope},
+		{name: "admin read scope for regular user", role: entities.RoleUser, kind: entities.APITokenKindPersonal, scopes: []string{entities.ScopeAdminRead}, wantErr: ErrAPITokenAdminScope},
+		{name: "admin scope for administrator", role: entities.RoleAdmin, kind: entities.APITokenKindService, scopes: []string{entities.APIScopeAdmin, entities.APIScopeRead}, wantScopes: []string{entities.APIScopeAdmin, entities.APIScopeRead}},
+		{name: "unknown kind", role: entities.RoleUser, kind: "oauth", scopes: []string{entities.APIScopeRead}, wantErr: ErrUnknownAPITokenKind},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authRepo := newFakeAuthRepository()
+			authRepo.addUser(bunEntities.User{ID: 1, Role: tt.role, Username: "alice"})
+			authRepo.rolePermissions[entities.RoleAdmin] = []string{entities.PermissionUsersRead}
+			repo := newFakeAPITokenRepository()
+			s := NewAPITokenService(repo, authRepo)
+
+			created, err := s.CreateAPIToken(context.Background(), 1, tt.kind, entities.APITokenInput{Name: "ci", Scopes: tt.scopes})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.tokens) != 0 {
+					t.Errorf("tokens = %+v, want none", repo.tokens)
+				}
+				return
+			}
+			if !slices.Equal(created.Scopes, tt.wantScopes) {
+				t.Errorf("scopes = %v, want %v", created.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
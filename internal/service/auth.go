@@ -2,53 +2,230 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/kolibriee/users-rest-api/internal/config"
 	"github.com/kolibriee/users-rest-api/internal/entities"
 	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
 	"github.com/kolibriee/users-rest-api/internal/repository"
 	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+	"github.com/kolibriee/users-rest-api/pkg/useragent"
+	"github.com/sirupsen/logrus"
 )
 
 const (
 	accessTokenTTL  = 30 * time.Minute    // Время жизни access token
-	refreshTokenTTL = 30 * 24 * time.Hour // Время жизни refresh token
+	RefreshTokenTTL = 30 * 24 * time.Hour // Время жизни refresh token
+
+	maxUserAgentLength = 512 // Размер колонки user_agent в таблице sessions
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrInvalidAccessToken  = errors.New("invalid token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, all sessions of this login were revoked")
+	ErrScopeNotAllowed     = errors.New("requested scope is not allowed for this user")
+)
+
+// Типы событий в журнале безопасности
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+// dummyPasswordHash используется для проверки пароля несуществующего пользователя,
+// чтобы время ответа не выдавало наличие username в базе.
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
 )
 
 type AuthorizationService struct {
-	repo repository.Authorization
+	repo      repository.Authorization
+	oauthRepo repository.OAuth
+	cfg       *config.Auth
+	mailer    mailer.Mailer
+	passwords *PasswordPolicy
 }
 
-// NewAuthorizationService создает новый экземпляр AuthorizationService с переданным репозиторием.
-func NewAuthorizationService(repo repository.Authorization) *AuthorizationService {
-	return &AuthorizationService{repo: repo}
+// NewAuthorizationService создает новый экземпляр AuthorizationService с переданным репозиторием, конфигурацией,
+// отправкой писем и парольной политикой.
+func NewAuthorizationService(repo repository.Authorization, oauthRepo repository.OAuth, cfg *config.Auth, mailer mailer.Mailer, passwords *PasswordPolicy) *AuthorizationService {
+	return &AuthorizationService{repo: repo, oauthRepo: oauthRepo, cfg: cfg, mailer: mailer, passwords: passwords}
 }
 
-// SignUp регистрирует нового пользователя и возвращает его ID.
+// SignUp регистрирует нового пользователя и возвращает его ID. Пароль проверяется по парольной политике.
 func (s *AuthorizationService) SignUp(ctx context.Context, user entities.SignUpInput) (int, error) {
-	user.Password = auth.GeneratePasswordHash(user.Password) // Хешируем пароль
-	return s.repo.CreateUser(ctx, user)
+	if err := s.passwords.check(ctx, s.repo, 0, user.Password,
+		personalInfo(user.Username, user.Name, user.City, user.Email)...); err != nil {
+		return 0, err
+	}
+
+	passwordHash, err := auth.GeneratePasswordHash(user.Password) // Хешируем пароль
+	if err != nil {
+		return 0, err
+	}
+	user.Password = passwordHash
+	id, err := s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+	s.passwords.remember(ctx, s.repo, id, passwordHash)
+
+	// Пользователь уже создан, поэтому ошибка отправки письма не прерывает регистрацию:
+	// ссылку можно запросить повторно
+	if err := sendEmailVerification(ctx, s.repo, s.mailer, &s.cfg.EmailVerification, bunEntities.User{
+		ID:       id,
+		Name:     user.Name,
+		Username: user.Username,
+		Email:    &user.Email,
+	}); err != nil {
+		logrus.Errorf("can't send email verification to user %d: %s", id, err.Error())
+	}
+	return id, nil
 }
 
 // SignIn выполняет вход пользователя, возвращая access token и refresh token.
-func (s *AuthorizationService) SignIn(ctx context.Context, signInUser entities.SignInInput) (string, string, error) {
-	signInUser.Password = auth.GeneratePasswordHash(signInUser.Password) // Хешируем пароль
-	user, err := s.repo.GetUser(ctx, signInUser)                         // Получаем пользователя из репозитория
+// Если у пользователя включена 2FA, вместо токенов возвращается токен второго шага входа.
+// Неудачные попытки учитываются по username и IP клиента. Если в конфигурации требуется
+// подтверждение адреса, вход с неподтвержденным адресом запрещен.
+func (s *AuthorizationService) SignIn(ctx context.Context, signInUser entities.SignInInput, client entities.ClientInfo) (entities.SignInResult, error) {
+	if err := checkLoginThrottle(ctx, s.repo, signInUser.Username, client.IP); err != nil {
+		return entities.SignInResult{}, err
+	}
+
+	user, err := s.authenticate(ctx, signInUser.Username, signInUser.Password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			registerFailedLogin(ctx, s.repo, &s.cfg.LoginThrottle, signInUser.Username, client.IP)
+		}
+		return entities.SignInResult{}, err
+	}
+
+	// Успешный вход сбрасывает счетчик ошибок для username
+	if err := s.repo.ResetLoginAttempts(ctx, usernameThrottleKey(user.Username)); err != nil {
+		logrus.Errorf("can't reset login attempts for user %d: %s", user.ID, err.Error())
+	}
+
+	// Пользователи без адреса (созданные администратором) под ограничение не попадают
+	if s.cfg.EmailVerification.Required && user.Email != nil && user.EmailVerifiedAt == nil {
+		return entities.SignInResult{}, ErrEmailNotVerified
+	}
+
+	// Без scope токены действуют со всеми правами пользователя
+	var scope string
+	if strings.TrimSpace(signInUser.Scope) != "" {
+		allowed, err := userScopes(ctx, s.repo, user.Role)
+		if err != nil {
+			return entities.SignInResult{}, err
+		}
+		var ok bool
+		if scope, ok = resolveScope(signInUser.Scope, allowed); !ok {
+			return entities.SignInResult{}, ErrScopeNotAllowed
+		}
+	}
+	return completeSignIn(ctx, s.repo, user.ID, user.Role, scope, client)
+}
+
+// completeSignIn завершает вход пользователя, личность которого уже подтверждена:
+// при включенной 2FA выдает токен второго шага, иначе — access token и refresh token.
+// Непустой scope ограничивает токены и сохраняется в сессии для следующих обновлений.
+func completeSignIn(ctx context.Context, repo repository.Authorization, userID int, role string, scope string, client entities.ClientInfo) (entities.SignInResult, error) {
+	// Проверяем, требуется ли второй фактор
+	enabled, err := twoFactorEnabled(ctx, repo, userID)
+	if err != nil {
+		return entities.SignInResult{}, err
+	}
+	if enabled {
+		challengeToken, err := auth.GenerateTwoFactorToken(twoFactorTokenTTL, userID, auth.WithScope(scope))
+		if err != nil {
+			return entities.SignInResult{}, err
+		}
+		return entities.SignInResult{ChallengeToken: challengeToken}, nil
+	}
+
+	accessToken, refreshToken, err := issueTokens(ctx, repo, userID, role, "", scope, client)
+	if err != nil {
+		return entities.SignInResult{}, err
+	}
+	return entities.SignInResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// SignInTwoFactor завершает вход с 2FA: обменивает токен второго шага и код на access token и refresh token.
+func (s *AuthorizationService) SignInTwoFactor(ctx context.Context, input entities.TwoFactorSignInInput, client entities.ClientInfo) (string, string, error) {
+	userID, scope, err := auth.ParseTwoFactorToken(input.ChallengeToken)
+	if err != nil {
+		return "", "", ErrInvalidCredentials
+	}
+
+	// Пользователь мог быть удален после первого шага входа
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrInvalidCredentials
+		}
+		return "", "", err
+	}
+
+	// Подбор кодов ограничивается так же, как подбор паролей
+	if err := checkLoginThrottle(ctx, s.repo, username, client.IP); err != nil {
+		return "", "", err
+	}
+	if err := verifySecondFactor(ctx, s.repo, userID, input.Code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			registerFailedLogin(ctx, s.repo, &s.cfg.LoginThrottle, username, client.IP)
+		}
+		return "", "", err
+	}
+
+	// Успешный второй шаг сбрасывает ошибки ввода кода для username
+	if err := s.repo.ResetLoginAttempts(ctx, usernameThrottleKey(username)); err != nil {
+		logrus.Errorf("can't reset login attempts for user %d: %s", userID, err.Error())
+	}
+
+	role, err := s.repo.GetRole(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	return issueTokens(ctx, s.repo, userID, role, "", scope, client)
+}
+
+// issueTokens выдает access token и создает новую сессию с refresh token.
+// Для OAuth-клиента в сессии сохраняются его ID и выданный scope, для любой сессии — IP и устройство клиента.
+func issueTokens(ctx context.Context, repo repository.Authorization, userID int, role string, clientID string, scope string, client entities.ClientInfo) (string, string, error) {
+	// Активной становится первая организация пользователя, ее можно сменить позже
+	organizationID, err := repo.GetFirstOrganization(ctx, userID)
 	if err != nil {
-		return "", "", err // Возвращаем ошибку, если пользователь не найден
+		return "", "", err
 	}
 
 	// Генерируем access token
-	accessToken, err := auth.GenerateAccessToken(accessTokenTTL, user.ID, user.Role)
+	accessToken, err := generateAccessToken(ctx, repo, userID, role, clientID, scope, organizationID)
 	if err != nil {
 		return "", "", err // Возвращаем ошибку, если не удалось сгенерировать token
 	}
 
 	// Создаем новую сессию для пользователя
-	refreshToken, err := s.repo.CreateSession(ctx, bunEntities.Session{
-		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(refreshTokenTTL), // Устанавливаем время истечения сессии
+	now := time.Now()
+	userAgent := truncateUserAgent(client.UserAgent)
+	refreshToken, err := repo.CreateSession(ctx, bunEntities.Session{
+		UserID:         userID,
+		ClientID:       clientID,
+		Scope:          scope,
+		OrganizationID: organizationID,
+		ExpiresAt:      now.Add(RefreshTokenTTL), // Устанавливаем время истечения сессии
+		CreatedAt:      now,
+		LastUsedAt:     now,
+		CreatedIP:      client.IP,
+		LastUsedIP:     client.IP,
+		UserAgent:      userAgent,
+		Device:         useragent.Parse(userAgent),
 	})
 	if err != nil {
 		return "", "", errors.New("can't create refresh token" + err.Error())
@@ -57,34 +234,277 @@ func (s *AuthorizationService) SignIn(ctx context.Context, signInUser entities.S
 }
 
 // Refresh обновляет access token и refresh token, используя refresh token.
-func (s *AuthorizationService) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
+// Использованный refresh token остается в базе как отметка: его повторное предъявление
+// означает утечку, и тогда отзывается все семейство сессий, включая новые токены.
+func (s *AuthorizationService) Refresh(ctx context.Context, refreshToken string, client entities.ClientInfo) (string, string, error) {
+	if !isRefreshToken(refreshToken) {
+		return "", "", ErrInvalidRefreshToken
+	}
 	session, err := s.repo.GetSession(ctx, refreshToken) // Получаем сессию по refresh token
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrInvalidRefreshToken
+		}
 		return "", "", errors.New("invalid refresh token" + err.Error())
 	}
 
+	// Сессии OAuth-клиентов обновляются только через /oauth/token, чтобы не расширить их scope
+	if session.ClientID != "" {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	return rotateSession(ctx, s.repo, session, session.Scope, client)
+}
+
+// rotateSession выдает новую пару токенов по сессии: старая сессия помечается использованной,
+// новая создается в том же семействе для того же клиента с указанным scope. Время и IP входа
+// переносятся в новую сессию, время, IP и устройство последнего использования обновляются.
+func rotateSession(ctx context.Context, repo repository.Authorization, session bunEntities.Session, scope string, client entities.ClientInfo) (string, string, error) {
+	if session.RotatedAt != nil {
+		return "", "", revokeReusedSessionFamily(ctx, repo, session)
+	}
+
 	if session.ExpiresAt.Before(time.Now()) {
 		return "", "", errors.New("session expired") // Проверяем, истекла ли сессия
 	}
 
-	role, err := s.repo.GetRole(ctx, session.UserID) // Получаем роль пользователя
-	accessToken, err := auth.GenerateAccessToken(accessTokenTTL, session.UserID, role)
+	role, err := repo.GetRole(ctx, session.UserID) // Получаем роль пользователя
+	if err != nil {
+		return "", "", errors.New("can't get user role" + err.Error())
+	}
+	organizationID, err := activeOrganization(ctx, repo, session.UserID, session.OrganizationID)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err := generateAccessToken(ctx, repo, session.UserID, role, session.ClientID, scope, organizationID)
 	if err != nil {
 		return "", "", errors.New("can't generate access token" + err.Error())
 	}
 
-	// Создаем новую сессию с новым refresh token
-	newRefreshToken, err := s.repo.CreateSession(ctx, bunEntities.Session{
-		UserID:    session.UserID,
-		ExpiresAt: time.Now().Add(refreshTokenTTL),
-	})
+	// Помечаем старую сессию использованной и создаем новую в том же семействе
+	now := time.Now()
+	next := bunEntities.Session{
+		UserID:         session.UserID,
+		FamilyID:       session.FamilyID,
+		ClientID:       session.ClientID,
+		Scope:          scope,
+		OrganizationID: organizationID,
+		ExpiresAt:      now.Add(RefreshTokenTTL),
+		CreatedAt:      session.CreatedAt,
+		LastUsedAt:     now,
+		CreatedIP:      session.CreatedIP,
+		LastUsedIP:     client.IP,
+		UserAgent:      session.UserAgent,
+		Device:         session.Device,
+	}
+	if client.UserAgent != "" {
+		next.UserAgent = truncateUserAgent(client.UserAgent)
+		next.Device = useragent.Parse(next.UserAgent)
+	}
+	newRefreshToken, rotated, err := repo.RotateSession(ctx, session.ID, next)
 	if err != nil {
 		return "", "", errors.New("can't create refresh token" + err.Error())
 	}
-
-	// Удаляем старую сессию
-	if err := s.repo.DeleteSession(ctx, refreshToken); err != nil {
-		return "", "", errors.New("can't delete old refresh token" + err.Error())
+	if !rotated {
+		// Токен успели использовать параллельно с этим запросом
+		return "", "", revokeReusedSessionFamily(ctx, repo, session)
 	}
 	return accessToken, newRefreshToken, nil // Возвращаем новые токены
 }
+
+// generateAccessToken выдает access token с актуальной версией токенов пользователя
+// и активной организацией, если она выбрана.
+func generateAccessToken(ctx context.Context, repo repository.Authorization, userID int, role string, clientID string, scope string, organizationID *int) (string, error) {
+	tokenVersion, err := repo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	opts := []auth.TokenOption{auth.WithClientID(clientID), auth.WithScope(scope)}
+	if organizationID != nil {
+		opts = append(opts, auth.WithOrganization(*organizationID))
+	}
+	return auth.GenerateAccessToken(accessTokenTTL, userID, role, tokenVersion, opts...)
+}
+
+// truncateUserAgent обрезает User-Agent до размера колонки в базе.
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= maxUserAgentLength {
+		return userAgent
+	}
+	return strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+}
+
+// isRefreshToken проверяет формат refresh token (UUID), чтобы не отправлять в базу произвольные строки.
+func isRefreshToken(token string) bool {
+	if len(token) != 36 {
+		return false
+	}
+	for i, c := range token {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case !strings.ContainsRune("0123456789abcdefABCDEF", c):
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateAccessToken проверяет access token: подпись, срок действия, отсутствие jti
+// в списке отозванных, совпадение версии токенов с текущей версией пользователя
+// и, для токена имперсонации, право на имперсонацию у администратора из claim act. Токен сервисного клиента
+// действует, пока клиент зарегистрирован и ему разрешен grant client_credentials.
+func (s *AuthorizationService) ValidateAccessToken(ctx context.Context, accessToken string) (*auth.CustomClaims, error) {
+	claims, err := auth.ParseAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccessTokenClaims(ctx, s.repo, s.oauthRepo, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkAccessTokenClaims проверяет claims access token с подписью, уже проверенной при разборе,
+// по состоянию в базе. Если токен больше не действует, возвращает ErrInvalidAccessToken.
+func checkAccessTokenClaims(ctx context.Context, repo repository.Authorization, oauthRepo repository.OAuth, claims *auth.CustomClaims) error {
+	principal, err := claims.Principal()
+	if err != nil {
+		return ErrInvalidAccessToken
+	}
+
+	if claims.Id != "" {
+		revoked, err := repo.IsTokenRevoked(ctx, claims.Id)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return ErrInvalidAccessToken
+		}
+	}
+
+	if claims.IsServicePrincipal() {
+		client, err := oauthRepo.GetClient(ctx, principal.ClientID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrInvalidAccessToken
+			}
+			return err
+		}
+		if !slices.Contains(client.GrantTypes, entities.OAuthGrantClientCredentials) {
+			return ErrInvalidAccessToken
+		}
+		return nil
+	}
+
+	// Удаленный пользователь или устаревшая версия (смена роли, выход со всех устройств)
+	tokenVersion, err := repo.GetTokenVersion(ctx, principal.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidAccessToken
+		}
+		return err
+	}
+	if claims.TokenVersion != tokenVersion {
+		return ErrInvalidAccessToken
+	}
+
+	// Токен имперсонации действует, пока у выдавшего его администратора есть право на имперсонацию
+	if claims.Act != nil {
+		actorID, ok := claims.ActorID()
+		if !ok {
+			return ErrInvalidAccessToken
+		}
+		actorRole, err := repo.GetRole(ctx, actorID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrInvalidAccessToken
+			}
+			return err
+		}
+		allowed, err := hasPermission(ctx, repo, actorRole, entities.PermissionUsersImpersonate)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrInvalidAccessToken
+		}
+	}
+
+	return nil
+}
+
+// RevokeAccessToken отзывает access token до истечения срока его действия.
+func (s *AuthorizationService) RevokeAccessToken(ctx context.Context, accessToken string) error {
+	claims, err := auth.ParseAccessToken(accessToken)
+	if err != nil {
+		return ErrInvalidAccessToken
+	}
+	if claims.Id == "" {
+		return nil
+	}
+	return s.repo.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+// revokeReusedSessionFamily отзывает все сессии семейства после повторного
+// использования refresh token и записывает событие безопасности.
+func revokeReusedSessionFamily(ctx context.Context, repo repository.Authorization, session bunEntities.Session) error {
+	if err := repo.DeleteSessionFamily(ctx, session.FamilyID); err != nil {
+		return errors.New("can't revoke session family" + err.Error())
+	}
+
+	logrus.Warnf("refresh token reuse detected for user %d, session family %s revoked", session.UserID, session.FamilyID)
+	if err := repo.CreateSecurityEvent(ctx, bunEntities.SecurityEvent{
+		UserID: &session.UserID,
+		Type:   SecurityEventRefreshTokenReuse,
+		Details: map[string]interface{}{
+			"familyId":  session.FamilyID,
+			"sessionId": session.ID,
+			"rotatedAt": session.RotatedAt,
+		},
+	}); err != nil {
+		logrus.Errorf("can't record security event: %s", err.Error())
+	}
+
+	return ErrRefreshTokenReused
+}
+
+// authenticate проверяет имя пользователя и пароль. Устаревший хэш пароля
+// (SHA-1 или argon2id со старыми параметрами) пересчитывается после успешной проверки.
+func (s *AuthorizationService) authenticate(ctx context.Context, username string, password string) (bunEntities.User, error) {
+	user, err := s.repo.GetUser(ctx, username) // Получаем пользователя из репозитория
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return bunEntities.User{}, err
+	}
+	// Неизвестный пользователь или пользователь без пароля (вход только через провайдера OIDC)
+	if err != nil || user.PasswordHash == "" {
+		dummyPasswordHashOnce.Do(func() {
+			dummyPasswordHash, _ = auth.GeneratePasswordHash("dummy-password")
+		})
+		auth.ComparePasswordHash(password, dummyPasswordHash)
+		return bunEntities.User{}, ErrInvalidCredentials
+	}
+
+	match, needsRehash, err := auth.ComparePasswordHash(password, user.PasswordHash)
+	if err != nil {
+		return bunEntities.User{}, err
+	}
+	if !match {
+		return bunEntities.User{}, ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		passwordHash, err := auth.GeneratePasswordHash(password)
+		if err == nil {
+			err = s.repo.UpdatePasswordHash(ctx, user.ID, passwordHash)
+		}
+		if err != nil {
+			// Вход не блокируем: хэш будет пересчитан при следующей попытке
+			logrus.Warnf("can't rehash password for user %d: %s", user.ID, err.Error())
+		}
+	}
+
+	return user, nil
+}
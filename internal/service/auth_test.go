@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	setupTestKeyring(t)
+
+	tests := []struct {
+		name      string
+		rotations int // Сколько раз токен обновляется до повторного предъявления
+		replay    int // Какой по счету токен предъявляется повторно
+	}{
+		{name: "first token after one rotation", rotations: 1, replay: 0},
+		{name: "first token after several rotations", rotations: 3, replay: 0},
+		{name: "middle token after several rotations", rotations: 3, replay: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			repo.roles[1] = entities.RoleUser
+			s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+			client := entities.ClientInfo{IP: "192.0.2.1"}
+
+			_, refreshToken, err := issueTokens(ctx, repo, 1, entities.RoleUser, "", "", client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			family := repo.sessions[refreshToken].FamilyID
+
+			tokens := []string{refreshToken}
+			for i := 0; i < tt.rotations; i++ {
+				_, refreshToken, err = s.Refresh(ctx, refreshToken, client)
+				if err != nil {
+					t.Fatalf("rotation %d: %v", i+1, err)
+				}
+				tokens = append(tokens, refreshToken)
+			}
+			latest := tokens[len(tokens)-1]
+
+			if _, _, err := s.Refresh(ctx, tokens[tt.replay], client); !errors.Is(err, ErrRefreshTokenReused) {
+				t.Fatalf("replay: got %v, want %v", err, ErrRefreshTokenReused)
+			}
+			if n := repo.familySessions(family); n != 0 {
+				t.Errorf("family has %d sessions after reuse, want 0", n)
+			}
+			// Новейший токен семейства тоже отозван
+			if _, _, err := s.Refresh(ctx, latest, client); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Errorf("latest token: got %v, want %v", err, ErrInvalidRefreshToken)
+			}
+			if len(repo.securityEvents) != 1 || repo.securityEvents[0].Type != SecurityEventRefreshTokenReuse {
+				t.Errorf("security events: got %+v, want one %s", repo.securityEvents, SecurityEventRefreshTokenReuse)
+			}
+		})
+	}
+}
+
+func TestSignInScope(t *testing.T) {
+	setupTestKeyring(t)
+	passwordHash, err := auth.GeneratePasswordHash("correct-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		role      string
+		scope     string
+		wantScope string
+		wantErr   error
+	}{
+		{name: "no scope", role: entities.RoleUser, scope: "", wantScope: ""},
+		{name: "user scope", role: entities.RoleUser, scope: "profile:read sessions:read", wantScope: "profile:read sessions:read"},
+		{name: "admin scope for regular user", role: entities.RoleUser, scope: "profile:read admin:read", wantErr: ErrScopeNotAllowed},
+		{name: "generic admin scope for regular user", role: entities.RoleUser, scope: "admin read", wantErr: ErrScopeNotAllowed},
+		{name: "admin scope for administrator", role: entities.RoleAdmin, scope: "admin:read", wantScope: "admin:read"},
+		{name: "unknown scope", role: entities.RoleAdmin, scope: "openid", wantErr: ErrScopeNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: tt.role, Username: "alice", PasswordHash: passwordHash})
+			repo.rolePermissions[entities.RoleAdmin] = []string{entities.PermissionUsersRead}
+			s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+
+			result, err := s.SignIn(ctx, entities.SignInInput{Username: "alice", Password: "correct-password", Scope: tt.scope}, entities.ClientInfo{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.sessions) != 0 {
+					t.Errorf("sessions were created for a rejected scope")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := auth.ParseAccessToken(result.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Scope != tt.wantScope {
+				t.Errorf("scope = %q, want %q", claims.Scope, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestSignInRehashesLegacyPassword(t *testing.T) {
+	setupTestKeyring(t)
+	t.Setenv("PASSWORD_HASH_SALT", "legacy-salt")
+	// Хэш SHA-1 с общей солью, созданный до перехода на argon2id
+	legacy := fmt.Sprintf("%x%x", "legacy-salt", sha1.Sum([]byte("correct-password")))
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{name: "correct password", password: "correct-password"},
+		{name: "wrong password", password: "wrong-password", wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", PasswordHash: legacy})
+			s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+
+			_, err := s.SignIn(context.Background(), entities.SignInInput{Username: "alice", Password: tt.password}, entities.ClientInfo{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+
+			// После успешного входа хэш пересчитан в argon2id, после неудачного остается прежним
+			hash := repo.users[1].PasswordHash
+			if tt.wantErr != nil {
+				if hash != legacy {
+					t.Errorf("hash = %q, want the legacy hash", hash)
+				}
+				return
+			}
+			match, needsRehash, err := auth.ComparePasswordHash("correct-password", hash)
+			if err != nil || !match || needsRehash || !strings.HasPrefix(hash, "$argon2id$") {
+				t.Errorf("hash %q: match %v, rehash %v, err %v; want a current argon2id hash", hash, match, needsRehash, err)
+			}
+		})
+	}
+}
+
+func TestValidateAccessTokenRevocation(t *testing.T) {
+	setupTestKeyring(t)
+
+	tests := []struct {
+		name        string
+		revoke      func(s *AuthorizationService, repo *fakeAuthRepository, token string) // token — первый из двух токенов пользователя
+		wantRevoked bool                                                                  // Отозван ли проверяемый токен
+		wantOther   bool                                                                  // Отозван ли другой токен того же пользователя
+	}{
+		{name: "valid token", revoke: func(*AuthorizationService, *fakeAuthRepository, string) {}},
+		{name: "revoked jti", revoke: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			if err := s.RevokeAccessToken(context.Background(), token); err != nil {
+				t.Fatal(err)
+			}
+		}, wantRevoked: true},
+		{name: "logout from all devices", revoke: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			if err := s.LogoutAll(context.Background(), 1); err != nil {
+				t.Fatal(err)
+			}
+		}, wantRevoked: true, wantOther: true},
+		{name: "token version changed", revoke: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			// Например, после смены роли
+			if err := repo.IncrementTokenVersion(context.Background(), 1); err != nil {
+				t.Fatal(err)
+			}
+		}, wantRevoked: true, wantOther: true},
+		{name: "user deleted", revoke: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			delete(repo.users, 1)
+			delete(repo.roles, 1)
+		}, wantRevoked: true, wantOther: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", TokenVersion: 2})
+			s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+
+			var tokens []string
+			for i := 0; i < 2; i++ {
+				token, err := auth.GenerateAccessToken(accessTokenTTL, 1, entities.RoleUser, 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tokens = append(tokens, token)
+			}
+			tt.revoke(s, repo, tokens[0])
+
+			for i, wantRevoked := range []bool{tt.wantRevoked, tt.wantOther} {
+				_, err := s.ValidateAccessToken(ctx, tokens[i])
+				if wantRevoked && !errors.Is(err, ErrInvalidAccessToken) {
+					t.Errorf("token %d: got %v, want %v", i, err, ErrInvalidAccessToken)
+				}
+				if !wantRevoked && err != nil {
+					t.Errorf("token %d: got %v, want valid", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAccessTokenVersion(t *testing.T) {
+	setupTestKeyring(t)
+	repo := newFakeAuthRepository()
+	repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", TokenVersion: 2})
+	s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+
+	// Токен, выданный до смены версии, не действует, даже если его jti не отозван
+	for _, version := range []int{0, 1, 3} {
+		token, err := auth.GenerateAccessToken(accessTokenTTL, 1, entities.RoleUser, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.ValidateAccessToken(context.Background(), token); !errors.Is(err, ErrInvalidAccessToken) {
+			t.Errorf("version %d: got %v, want %v", version, err, ErrInvalidAccessToken)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+)
+
+const (
+	defaultEmailVerificationTTL = 48 * time.Hour // Время жизни ссылки, если не задано в конфигурации
+
+	PurposeEmailVerification = "email_verification" // Назначение одноразового токена подтверждения адреса
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+)
+
+// VerifyEmail подтверждает адрес пользователя по токену из письма.
+func (s *AuthorizationService) VerifyEmail(ctx context.Context, token string) error {
+	verification, err := s.repo.ConsumeOneTimeToken(ctx, hashToken(token), PurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+	if verification.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidVerificationToken
+	}
+	return s.repo.MarkEmailVerified(ctx, verification.UserID)
+}
+
+// ResendEmailVerification повторно отправляет ссылку подтверждения, если адрес принадлежит
+// пользователю и еще не подтвержден. Ответ не зависит от наличия адреса в базе.
+func (s *AuthorizationService) ResendEmailVerification(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return sendEmailVerification(ctx, s.repo, s.mailer, &s.cfg.EmailVerification, user)
+}
+
+// sendEmailVerification выдает новый токен подтверждения адреса и отправляет ссылку на него.
+// Ранее отправленные ссылки перестают действовать.
+func sendEmailVerification(ctx context.Context, repo repository.Authorization, m mailer.Mailer, cfg *config.EmailVerification, user bunEntities.User) error {
+	if user.Email == nil {
+		return nil
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	ttl := cfg.TokenTTL
+	if ttl <= 0 {
+		ttl = defaultEmailVerificationTTL
+	}
+	if err := repo.CreateOneTimeToken(ctx, bunEntities.OneTimeToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		Purpose:   PurposeEmailVerification,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	link, err := withQuery(cfg.URL, url.Values{"token": {token}})
+	if err != nil {
+		return errors.New("invalid email verification url: " + err.Error())
+	}
+	sendMailAsync(m, mailer.Message{
+		To:      *user.Email,
+		Subject: "Confirm your email address",
+		Body: "Hello, " + user.Name + "!\n\n" +
+			"To confirm this email address for " + user.Username + ", open the link below:\n\n" +
+			link + "\n\n" +
+			"The link is valid for " + ttl.String() + " and can be used once.\n" +
+			"If you did not create an account, ignore this email.\n",
+	})
+	return nil
+}
@@ -0,0 +1,676 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+// fakeAuthRepository хранит данные в памяти. Методы, которые тестам не нужны,
+// достаются от пустого встроенного интерфейса и паникуют при вызове.
+type fakeAuthRepository struct {
+	repository.Authorization
+
+	mu              sync.Mutex
+	nextSessionID   int
+	sessions        map[string]bunEntities.Session // По refresh token
+	roles           map[int]string
+	users           map[int]bunEntities.User
+	rolePermissions map[string][]string
+	identities      []bunEntities.UserIdentity
+	oidcStates      map[string]bunEntities.OIDCLoginState // По хэшу state
+	securityEvents  []bunEntities.SecurityEvent
+	oneTimeTokens   map[string]bunEntities.OneTimeToken // По хэшу токена
+	memberships     map[int]map[int]string              // Организация -> пользователь -> роль участника
+	loginAttempts   map[string]bunEntities.LoginAttempt // По ключу ограничения
+	twoFactors      map[int]bunEntities.TwoFactor
+	recoveryCodes   map[int]map[string]bool // Пользователь -> хэш кода -> использован
+	rateLimits      map[string]bunEntities.RateLimit
+	revokedTokens   map[string]time.Time // jti -> срок действия отозванного токена
+	passwordHistory map[int][]string     // Пользователь -> хэши паролей, новые первыми
+	defaultRole     string
+}
+
+func newFakeAuthRepository() *fakeAuthRepository {
+	return &fakeAuthRepository{
+		sessions:        make(map[string]bunEntities.Session),
+		roles:           make(map[int]string),
+		users:           make(map[int]bunEntities.User),
+		rolePermissions: make(map[string][]string),
+		oidcStates:      make(map[string]bunEntities.OIDCLoginState),
+		oneTimeTokens:   make(map[string]bunEntities.OneTimeToken),
+		memberships:     make(map[int]map[int]string),
+		loginAttempts:   make(map[string]bunEntities.LoginAttempt),
+		twoFactors:      make(map[int]bunEntities.TwoFactor),
+		recoveryCodes:   make(map[int]map[string]bool),
+		rateLimits:      make(map[string]bunEntities.RateLimit),
+		revokedTokens:   make(map[string]time.Time),
+		passwordHistory: make(map[int][]string),
+		defaultRole:     entities.RoleUser,
+	}
+}
+
+// addUser добавляет пользователя вместе с его ролью
+func (r *fakeAuthRepository) addUser(user bunEntities.User) {
+	r.users[user.ID] = user
+	r.roles[user.ID] = user.Role
+}
+
+// addMember добавляет пользователя в организацию
+func (r *fakeAuthRepository) addMember(organizationID int, userID int, role string) {
+	if r.memberships[organizationID] == nil {
+		r.memberships[organizationID] = make(map[int]string)
+	}
+	r.memberships[organizationID][userID] = role
+}
+
+// newFakeToken возвращает случайный UUID, как refresh token из базы
+func newFakeToken() string {
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+	s := hex.EncodeToString(raw)
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func (r *fakeAuthRepository) insertSession(session bunEntities.Session) string {
+	r.nextSessionID++
+	session.ID = r.nextSessionID
+	session.RefreshToken = newFakeToken()
+	if session.FamilyID == "" {
+		session.FamilyID = newFakeToken()
+	}
+	r.sessions[session.RefreshToken] = session
+	return session.RefreshToken
+}
+
+func (r *fakeAuthRepository) CreateSession(ctx context.Context, session bunEntities.Session) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insertSession(session), nil
+}
+
+func (r *fakeAuthRepository) GetSession(ctx context.Context, refreshToken string) (bunEntities.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[refreshToken]
+	if !ok {
+		return bunEntities.Session{}, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func (r *fakeAuthRepository) RotateSession(ctx context.Context, sessionID int, next bunEntities.Session) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for token, session := range r.sessions {
+		if session.ID != sessionID {
+			continue
+		}
+		if session.RotatedAt != nil {
+			return "", false, nil
+		}
+		now := time.Now()
+		session.RotatedAt = &now
+		r.sessions[token] = session
+		return r.insertSession(next), true, nil
+	}
+	return "", false, nil
+}
+
+func (r *fakeAuthRepository) DeleteSessionFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for token, session := range r.sessions {
+		if session.FamilyID == familyID {
+			delete(r.sessions, token)
+		}
+	}
+	return nil
+}
+
+func (r *fakeAuthRepository) DeleteUserSessions(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for token, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, token)
+		}
+	}
+	return nil
+}
+
+// familySessions возвращает число сессий семейства, включая использованные
+func (r *fakeAuthRepository) familySessions(familyID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, session := range r.sessions {
+		if session.FamilyID == familyID {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *fakeAuthRepository) CreateSecurityEvent(ctx context.Context, event bunEntities.SecurityEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.securityEvents = append(r.securityEvents, event)
+	return nil
+}
+
+func (r *fakeAuthRepository) GetRole(ctx context.Context, userID int) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	role, ok := r.roles[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return role, nil
+}
+
+func (r *fakeAuthRepository) GetUserProfile(ctx context.Context, userID int) (bunEntities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return bunEntities.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (r *fakeAuthRepository) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rolePermissions[role], nil
+}
+
+func (r *fakeAuthRepository) GetDefaultRole(ctx context.Context) (string, error) {
+	return r.defaultRole, nil
+}
+
+func (r *fakeAuthRepository) GetUser(ctx context.Context, username string) (bunEntities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+	return bunEntities.User{}, sql.ErrNoRows
+}
+
+func (r *fakeAuthRepository) UpdatePasswordHash(ctx context.Context, userID int, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.users[userID]
+	user.PasswordHash = passwordHash
+	r.users[userID] = user
+	return nil
+}
+
+func (r *fakeAuthRepository) GetUserByEmail(ctx context.Context, email string) (bunEntities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email != nil && strings.EqualFold(*user.Email, email) {
+			return user, nil
+		}
+	}
+	return bunEntities.User{}, sql.ErrNoRows
+}
+
+func (r *fakeAuthRepository) GetUsername(ctx context.Context, userID int) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return user.Username, nil
+}
+
+func (r *fakeAuthRepository) GetLoginAttempt(ctx context.Context, key string) (bunEntities.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt, ok := r.loginAttempts[key]
+	if !ok {
+		return bunEntities.LoginAttempt{}, sql.ErrNoRows
+	}
+	return attempt, nil
+}
+
+// RegisterFailedLogin, как и репозиторий, начинает счет заново, если прошлая ошибка старше окна
+func (r *fakeAuthRepository) RegisterFailedLogin(ctx context.Context, key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	attempt, ok := r.loginAttempts[key]
+	if !ok || attempt.LastFailedAt.Before(now.Add(-window)) {
+		attempt = bunEntities.LoginAttempt{Key: key, LockedUntil: attempt.LockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailedAt = now
+	r.loginAttempts[key] = attempt
+	return attempt.Failures, nil
+}
+
+func (r *fakeAuthRepository) SetLoginLock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt := r.loginAttempts[key]
+	attempt.LockedUntil = &until
+	r.loginAttempts[key] = attempt
+	return nil
+}
+
+func (r *fakeAuthRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.loginAttempts, key)
+	return nil
+}
+
+func (r *fakeAuthRepository) GetTwoFactor(ctx context.Context, userID int) (bunEntities.TwoFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok {
+		return bunEntities.TwoFactor{}, sql.ErrNoRows
+	}
+	return twoFactor, nil
+}
+
+// enableTwoFactor включает 2FA с секретом secret и кодами восстановления codes
+func (r *fakeAuthRepository) enableTwoFactor(userID int, secret string, codes ...string) {
+	r.twoFactors[userID] = bunEntities.TwoFactor{UserID: userID, Secret: secret, Enabled: true}
+	r.recoveryCodes[userID] = make(map[string]bool)
+	for _, code := range codes {
+		r.recoveryCodes[userID][hashRecoveryCode(code)] = false
+	}
+}
+
+func (r *fakeAuthRepository) UseTwoFactorStep(ctx context.Context, userID int, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok || twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	twoFactor.LastUsedStep = step
+	r.twoFactors[userID] = twoFactor
+	return true, nil
+}
+
+func (r *fakeAuthRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used, ok := r.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (r *fakeAuthRepository) GetUserIdentity(ctx context.Context, provider string, subject string) (bunEntities.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return bunEntities.UserIdentity{}, sql.ErrNoRows
+}
+
+func (r *fakeAuthRepository) CreateUserIdentity(ctx context.Context, identity bunEntities.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeAuthRepository) CreateOIDCLoginState(ctx context.Context, state bunEntities.OIDCLoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.oidcStates[state.StateHash] = state
+	return nil
+}
+
+func (r *fakeAuthRepository) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (bunEntities.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.oidcStates[stateHash]
+	if !ok {
+		return bunEntities.OIDCLoginState{}, sql.ErrNoRows
+	}
+	delete(r.oidcStates, stateHash)
+	return state, nil
+}
+
+// CreateOneTimeToken, как и репозиторий, заменяет прежний токен пользователя с тем же назначением
+func (r *fakeAuthRepository) CreateOneTimeToken(ctx context.Context, token bunEntities.OneTimeToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, existing := range r.oneTimeTokens {
+		if existing.UserID == token.UserID && existing.Purpose == token.Purpose {
+			delete(r.oneTimeTokens, hash)
+		}
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	r.oneTimeTokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *fakeAuthRepository) ConsumeOneTimeToken(ctx context.Context, tokenHash string, purpose string) (bunEntities.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.oneTimeTokens[tokenHash]
+	if !ok || token.Purpose != purpose {
+		return bunEntities.OneTimeToken{}, sql.ErrNoRows
+	}
+	delete(r.oneTimeTokens, tokenHash)
+	return token, nil
+}
+
+func (r *fakeAuthRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.users[userID]
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	r.users[userID] = user
+	return nil
+}
+
+func (r *fakeAuthRepository) GetPasswordChangedAt(ctx context.Context, userID int) (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return user.PasswordChangedAt, nil
+}
+
+// RegisterRequest, как и репозиторий, начинает новое окно, когда прежнее закончилось
+func (r *fakeAuthRepository) RegisterRequest(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	limit, ok := r.rateLimits[key]
+	if !ok || limit.WindowStartedAt.Add(window).Before(now) {
+		limit = bunEntities.RateLimit{Key: key, WindowStartedAt: now}
+	}
+	limit.Requests++
+	r.rateLimits[key] = limit
+	return limit.Requests, limit.WindowStartedAt, nil
+}
+
+func (r *fakeAuthRepository) GetPasswordHistory(ctx context.Context, userID int, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	history := r.passwordHistory[userID]
+	return history[:min(limit, len(history))], nil
+}
+
+// AddPasswordHistory, как и репозиторий, хранит только keep последних хэшей
+func (r *fakeAuthRepository) AddPasswordHistory(ctx context.Context, userID int, passwordHash string, keep int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	history := append([]string{passwordHash}, r.passwordHistory[userID]...)
+	r.passwordHistory[userID] = history[:min(keep, len(history))]
+	return nil
+}
+
+// GetTokenVersion, как и GetRole, считает существующим любого пользователя с ролью
+func (r *fakeAuthRepository) GetTokenVersion(ctx context.Context, userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[userID]; !ok {
+		return 0, sql.ErrNoRows
+	}
+	return r.users[userID].TokenVersion, nil
+}
+
+func (r *fakeAuthRepository) IncrementTokenVersion(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.users[userID]
+	user.TokenVersion++
+	r.users[userID] = user
+	return nil
+}
+
+func (r *fakeAuthRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (r *fakeAuthRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.revokedTokens[jti]
+	return ok, nil
+}
+
+// GetFirstOrganization возвращает организацию с наименьшим ID: у фейка нет времени вступления
+func (r *fakeAuthRepository) GetFirstOrganization(ctx context.Context, userID int) (*int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var first *int
+	for organizationID, members := range r.memberships {
+		if _, ok := members[userID]; ok && (first == nil || organizationID < *first) {
+			id := organizationID
+			first = &id
+		}
+	}
+	return first, nil
+}
+
+func (r *fakeAuthRepository) GetMembershipRole(ctx context.Context, organizationID int, userID int) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	role, ok := r.memberships[organizationID][userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return role, nil
+}
+
+// fakeOAuthRepository хранит OAuth-клиентов и коды авторизации в памяти
+type fakeOAuthRepository struct {
+	repository.OAuth
+
+	mu      sync.Mutex
+	clients map[string]bunEntities.OAuthClient
+	codes   map[string]bunEntities.OAuthCode // По хэшу кода
+}
+
+func newFakeOAuthRepository(clients ...bunEntities.OAuthClient) *fakeOAuthRepository {
+	r := &fakeOAuthRepository{
+		clients: make(map[string]bunEntities.OAuthClient),
+		codes:   make(map[string]bunEntities.OAuthCode),
+	}
+	for _, client := range clients {
+		r.clients[client.ClientID] = client
+	}
+	return r
+}
+
+func (r *fakeOAuthRepository) GetClient(ctx context.Context, clientID string) (bunEntities.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	client, ok := r.clients[clientID]
+	if !ok {
+		return bunEntities.OAuthClient{}, sql.ErrNoRows
+	}
+	return client, nil
+}
+
+func (r *fakeOAuthRepository) CreateCode(ctx context.Context, code bunEntities.OAuthCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes[code.CodeHash] = code
+	return nil
+}
+
+func (r *fakeOAuthRepository) ConsumeCode(ctx context.Context, codeHash string) (bunEntities.OAuthCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code, ok := r.codes[codeHash]
+	if !ok {
+		return bunEntities.OAuthCode{}, sql.ErrNoRows
+	}
+	delete(r.codes, codeHash)
+	return code, nil
+}
+
+func (r *fakeOAuthRepository) CreateClient(ctx context.Context, client bunEntities.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[client.ClientID] = client
+	return nil
+}
+
+// fakeAPITokenRepository хранит API-токены в памяти
+type fakeAPITokenRepository struct {
+	repository.APITokens
+
+	mu     sync.Mutex
+	nextID int
+	tokens map[int]bunEntities.APIToken
+}
+
+func newFakeAPITokenRepository() *fakeAPITokenRepository {
+	return &fakeAPITokenRepository{tokens: make(map[int]bunEntities.APIToken)}
+}
+
+func (r *fakeAPITokenRepository) CreateAPIToken(ctx context.Context, token bunEntities.APIToken) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	token.ID = r.nextID
+	r.tokens[token.ID] = token
+	return token.ID, nil
+}
+
+func (r *fakeAPITokenRepository) GetAPITokenByHash(ctx context.Context, tokenHash string) (bunEntities.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return bunEntities.APIToken{}, sql.ErrNoRows
+}
+
+func (r *fakeAPITokenRepository) TouchAPIToken(ctx context.Context, tokenID int, clientIP string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token := r.tokens[tokenID]
+	now := time.Now()
+	token.LastUsedAt = &now
+	token.LastUsedIP = clientIP
+	r.tokens[tokenID] = token
+	return nil
+}
+
+func (r *fakeAPITokenRepository) DeleteAPIToken(ctx context.Context, tokenID int, userID int, kind string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenID]
+	if !ok || token.Kind != kind || (userID != 0 && token.UserID != userID) {
+		return false, nil
+	}
+	delete(r.tokens, tokenID)
+	return true, nil
+}
+
+// fakeRolesRepository хранит роли в памяти
+type fakeRolesRepository struct {
+	repository.Roles
+
+	roles map[string]bunEntities.Role
+}
+
+func newFakeRolesRepository(names ...string) *fakeRolesRepository {
+	r := &fakeRolesRepository{roles: make(map[string]bunEntities.Role)}
+	for _, name := range names {
+		r.roles[name] = bunEntities.Role{Name: name}
+	}
+	return r
+}
+
+func (r *fakeRolesRepository) GetRoleByName(ctx context.Context, name string) (bunEntities.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return bunEntities.Role{}, sql.ErrNoRows
+	}
+	return role, nil
+}
+
+// fakeUsersRepository читает пользователей из fakeAuthRepository
+type fakeUsersRepository struct {
+	repository.Users
+
+	auth *fakeAuthRepository
+}
+
+func (r *fakeUsersRepository) GetUserByID(ctx context.Context, id int) (*bunEntities.User, error) {
+	user, err := r.auth.GetUserProfile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// fakeOrganizationsRepository читает членство в организациях из fakeAuthRepository
+type fakeOrganizationsRepository struct {
+	repository.Organizations
+
+	auth *fakeAuthRepository
+}
+
+func (r *fakeOrganizationsRepository) GetMemberships(ctx context.Context, userIDs []int) ([]bunEntities.OrganizationMember, error) {
+	r.auth.mu.Lock()
+	defer r.auth.mu.Unlock()
+	var memberships []bunEntities.OrganizationMember
+	for organizationID, members := range r.auth.memberships {
+		for _, userID := range userIDs {
+			if role, ok := members[userID]; ok {
+				memberships = append(memberships, bunEntities.OrganizationMember{OrganizationID: organizationID, UserID: userID, Role: role})
+			}
+		}
+	}
+	return memberships, nil
+}
+
+// setupTestKeyring подключает ключ подписи токенов на время теста
+func setupTestKeyring(t *testing.T) {
+	t.Helper()
+	keyring := auth.NewKeyring()
+	if err := keyring.GenerateEd25519Key("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.SetActive("test"); err != nil {
+		t.Fatal(err)
+	}
+	auth.SetKeyring(keyring)
+}
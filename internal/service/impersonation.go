@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultImpersonationTTL = 15 * time.Minute // Время жизни токена, если не задано в конфигурации
+
+// Типы событий в журнале безопасности
+const (
+	SecurityEventImpersonationStarted = "impersonation_started"
+	SecurityEventImpersonatedRequest  = "impersonated_request"
+)
+
+var (
+	ErrImpersonationUserNotFound = errors.New("user not found")
+	ErrImpersonateSelf           = errors.New("can't impersonate yourself")
+	ErrImpersonateAdmin          = errors.New("users with administrative permissions can't be impersonated")
+)
+
+type ImpersonationService struct {
+	repo repository.Authorization
+	cfg  *config.Impersonation
+}
+
+func NewImpersonationService(repo repository.Authorization, cfg *config.Impersonation) *ImpersonationService {
+	return &ImpersonationService{repo: repo, cfg: cfg}
+}
+
+// Impersonate выдает администратору access token от имени пользователя. В токене есть claim act
+// с администратором, refresh token и сессия не создаются. Начало имперсонации записывается в журнал;
+// если записать его не удалось, токен не выдается.
+// Если ctx ограничен организацией, пользователь должен в ней состоять, и токен выдается с этой организацией;
+// иначе токен получает первую организацию пользователя, как при входе.
+func (s *ImpersonationService) Impersonate(ctx context.Context, actorID int, userID int, input entities.ImpersonationInput, clientIP string) (entities.ImpersonationToken, error) {
+	if actorID == userID {
+		return entities.ImpersonationToken{}, ErrImpersonateSelf
+	}
+
+	role, err := s.repo.GetRole(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.ImpersonationToken{}, ErrImpersonationUserNotFound
+		}
+		return entities.ImpersonationToken{}, err
+	}
+	organizationID, memberRole, err := s.impersonatedOrganization(ctx, userID)
+	if err != nil {
+		return entities.ImpersonationToken{}, err
+	}
+	// Иначе через имперсонацию можно получить права другого администратора.
+	// Роль участника дает права на маршрутах /org, поэтому проверяется и она
+	roles := []string{role}
+	if memberRole != "" {
+		roles = append(roles, memberRole)
+	}
+	for _, role := range roles {
+		administrative, err := isAdministrativeRole(ctx, s.repo, role)
+		if err != nil {
+			return entities.ImpersonationToken{}, err
+		}
+		if administrative {
+			return entities.ImpersonationToken{}, ErrImpersonateAdmin
+		}
+	}
+
+	actorName, err := s.repo.GetUsername(ctx, actorID)
+	if err != nil {
+		return entities.ImpersonationToken{}, err
+	}
+	tokenVersion, err := s.repo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return entities.ImpersonationToken{}, err
+	}
+
+	ttl := s.cfg.TokenTTL
+	if ttl <= 0 {
+		ttl = defaultImpersonationTTL
+	}
+	opts := []auth.TokenOption{auth.WithActor(actorID, actorName)}
+	if organizationID != nil {
+		opts = append(opts, auth.WithOrganization(*organizationID))
+	}
+	accessToken, err := auth.GenerateAccessToken(ttl, userID, role, tokenVersion, opts...)
+	if err != nil {
+		return entities.ImpersonationToken{}, err
+	}
+	claims, err := auth.ParseAccessToken(accessToken)
+	if err != nil {
+		return entities.ImpersonationToken{}, err
+	}
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+
+	if err := s.repo.CreateSecurityEvent(ctx, bunEntities.SecurityEvent{
+		UserID:  &userID,
+		ActorID: &actorID,
+		Type:    SecurityEventImpersonationStarted,
+		Details: map[string]interface{}{
+			"reason":    input.Reason,
+			"tokenId":   claims.Id,
+			"expiresAt": expiresAt,
+			"ip":        clientIP,
+		},
+	}); err != nil {
+		return entities.ImpersonationToken{}, errors.New("can't record impersonation: " + err.Error())
+	}
+	logrus.Warnf("admin %d (%s) started impersonating user %d: %s", actorID, actorName, userID, input.Reason)
+
+	return entities.ImpersonationToken{
+		AccessToken: accessToken,
+		ExpiresAt:   expiresAt,
+		UserID:      userID,
+		ActorID:     actorID,
+	}, nil
+}
+
+// impersonatedOrganization возвращает организацию для токена имперсонации и роль пользователя в ней.
+// Администратор с активной организацией может действовать только от имени ее участников.
+func (s *ImpersonationService) impersonatedOrganization(ctx context.Context, userID int) (*int, string, error) {
+	organizationID, ok := repository.OrganizationFromContext(ctx)
+	if !ok {
+		first, err := s.repo.GetFirstOrganization(ctx, userID)
+		if err != nil || first == nil {
+			return nil, "", err
+		}
+		organizationID = *first
+	}
+	memberRole, err := s.repo.GetMembershipRole(ctx, organizationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", ErrImpersonationUserNotFound // Пользователи других организаций не видны
+		}
+		return nil, "", err
+	}
+	return &organizationID, memberRole, nil
+}
+
+// RecordImpersonatedRequest записывает в журнал запрос, выполненный от имени пользователя.
+func (s *ImpersonationService) RecordImpersonatedRequest(ctx context.Context, request entities.ImpersonatedRequest) error {
+	return s.repo.CreateSecurityEvent(ctx, bunEntities.SecurityEvent{
+		UserID:  &request.UserID,
+		ActorID: &request.ActorID,
+		Type:    SecurityEventImpersonatedRequest,
+		Details: map[string]interface{}{
+			"tokenId": request.TokenID,
+			"method":  request.Method,
+			"path":    request.Path,
+			"status":  request.Status,
+			"ip":      request.IP,
+		},
+	})
+}
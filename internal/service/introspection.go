@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+const defaultIntrospectionCacheSize = 10000 // Размер кэша, если не задан в конфигурации
+
+// Introspect проверяет access token или API-токен по запросу другого сервиса (RFC 7662).
+// Учитываются отзыв токена, удаление пользователя и его текущая роль. Спрашивать могут только
+// конфиденциальные клиенты; недействительный или неизвестный токен дает ответ {"active": false}.
+func (s *OAuthService) Introspect(ctx context.Context, request entities.OAuthIntrospectRequest) (entities.OAuthIntrospection, error) {
+	client, err := s.authenticateClient(ctx, clientCredentials{
+		ClientID:      request.ClientID,
+		ClientSecret:  request.ClientSecret,
+		AssertionType: request.ClientAssertionType,
+		Assertion:     request.ClientAssertion,
+	})
+	if err != nil {
+		return entities.OAuthIntrospection{}, err
+	}
+	if client.ClientSecretHash == "" && client.PublicKey == "" {
+		return entities.OAuthIntrospection{}, newOAuthError(OAuthInvalidClient, "public clients can't introspect tokens")
+	}
+	if request.Token == "" {
+		return entities.OAuthIntrospection{}, newOAuthError(OAuthInvalidRequest, "token is required")
+	}
+
+	key := hashToken(request.Token)
+	if result, ok := s.introspectionCache.get(key); ok {
+		return result, nil
+	}
+
+	var result entities.OAuthIntrospection
+	switch {
+	case IsAPIToken(request.Token):
+		result, err = s.introspectAPIToken(ctx, key)
+	case isRefreshToken(request.Token):
+		// Refresh token предъявляется только этому сервису, другим сервисам проверять его незачем
+		result = entities.OAuthIntrospection{Active: false}
+	default:
+		result, err = s.introspectAccessToken(ctx, request.Token)
+	}
+	if err != nil {
+		return entities.OAuthIntrospection{}, err
+	}
+
+	s.introspectionCache.set(key, result)
+	return result, nil
+}
+
+// introspectAccessToken проверяет JWT так же, как middleware, и возвращает текущую роль пользователя.
+func (s *OAuthService) introspectAccessToken(ctx context.Context, accessToken string) (entities.OAuthIntrospection, error) {
+	claims, err := auth.ParseAccessToken(accessToken)
+	if err != nil {
+		return entities.OAuthIntrospection{Active: false}, nil
+	}
+	if err := checkAccessTokenClaims(ctx, s.authRepo, s.repo, claims); err != nil {
+		if errors.Is(err, ErrInvalidAccessToken) {
+			return entities.OAuthIntrospection{Active: false}, nil
+		}
+		return entities.OAuthIntrospection{}, err
+	}
+
+	role := claims.Role
+	if !claims.IsServicePrincipal() {
+		userID, err := claims.UserID()
+		if err != nil {
+			return entities.OAuthIntrospection{Active: false}, nil
+		}
+		role, err = s.authRepo.GetRole(ctx, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entities.OAuthIntrospection{Active: false}, nil
+			}
+			return entities.OAuthIntrospection{}, err
+		}
+	}
+
+	return entities.OAuthIntrospection{
+		Active:    true,
+		Sub:       claims.Subject,
+		Role:      role,
+		Exp:       claims.ExpiresAt,
+		Iat:       claims.IssuedAt,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		TokenType: entities.OAuthTokenTypeAccessToken,
+		Act:       claims.Act,
+		Org:       claims.Organization,
+	}, nil
+}
+
+// introspectAPIToken проверяет личный токен или ключ сервиса по хэшу. Время последнего
+// использования не обновляется: токен предъявили не нам, а сервису, который его проверяет.
+func (s *OAuthService) introspectAPIToken(ctx context.Context, tokenHash string) (entities.OAuthIntrospection, error) {
+	stored, err := s.apiTokenRepo.GetAPITokenByHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.OAuthIntrospection{Active: false}, nil
+		}
+		return entities.OAuthIntrospection{}, err
+	}
+	if stored.ExpiresAt != nil && stored.ExpiresAt.Before(time.Now()) {
+		return entities.OAuthIntrospection{Active: false}, nil
+	}
+
+	role, err := s.authRepo.GetRole(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.OAuthIntrospection{Active: false}, nil
+		}
+		return entities.OAuthIntrospection{}, err
+	}
+
+	role, err = apiTokenRole(ctx, s.authRepo, role, stored.Scopes)
+	if err != nil {
+		return entities.OAuthIntrospection{}, err
+	}
+
+	result := entities.OAuthIntrospection{
+		Active:    true,
+		Sub:       strconv.Itoa(stored.UserID),
+		Role:      role,
+		Iat:       stored.CreatedAt.Unix(),
+		Scope:     strings.Join(stored.Scopes, " "),
+		TokenType: entities.OAuthTokenTypeAPIToken,
+	}
+	if stored.ExpiresAt != nil {
+		result.Exp = stored.ExpiresAt.Unix()
+	}
+	return result, nil
+}
+
+// introspectionCache недолго хранит результаты проверки токенов по хэшу токена, чтобы частые
+// запросы с одним и тем же токеном не обращались к базе. Отзыв токена и смена роли становятся
+// видны другим сервисам не позже чем через ttl.
+type introspectionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]introspectionCacheEntry
+}
+
+type introspectionCacheEntry struct {
+	result    entities.OAuthIntrospection
+	expiresAt time.Time
+}
+
+// newIntrospectionCache создает кэш; при ttl <= 0 результаты не кэшируются.
+func newIntrospectionCache(ttl time.Duration, size int) *introspectionCache {
+	if size <= 0 {
+		size = defaultIntrospectionCacheSize
+	}
+	return &introspectionCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]introspectionCacheEntry),
+	}
+}
+
+func (c *introspectionCache) get(key string) (entities.OAuthIntrospection, bool) {
+	if c.ttl <= 0 {
+		return entities.OAuthIntrospection{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return entities.OAuthIntrospection{}, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return entities.OAuthIntrospection{}, false
+	}
+	return entry.result, true
+}
+
+// set запоминает результат, но не дольше срока действия самого токена.
+// Если кэш заполнен, сначала удаляются устаревшие записи; если места все равно нет, результат не сохраняется.
+func (c *introspectionCache) set(key string, result entities.OAuthIntrospection) {
+	if c.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	expiresAt := now.Add(c.ttl)
+	if result.Active && result.Exp != 0 {
+		if tokenExpiresAt := time.Unix(result.Exp, 0); tokenExpiresAt.Before(expiresAt) {
+			expiresAt = tokenExpiresAt
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.size {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.size {
+			return
+		}
+	}
+	c.entries[key] = introspectionCacheEntry{result: result, expiresAt: expiresAt}
+}
+
+// delete убирает результат из кэша, например после отзыва токена через этот сервис.
+func (c *introspectionCache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
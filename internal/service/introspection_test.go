@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+func TestIntrospectionCacheTTL(t *testing.T) {
+	tokenExp := time.Now().Add(10 * time.Second).Unix()
+
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		result     entities.OAuthIntrospection
+		wantCached bool
+		wantExpiry time.Duration // Через сколько после записи результат перестает браться из кэша
+	}{
+		{name: "disabled", ttl: 0, result: entities.OAuthIntrospection{Active: true}},
+		{name: "negative ttl", ttl: -time.Second, result: entities.OAuthIntrospection{Active: true}},
+		{name: "active token", ttl: time.Minute, result: entities.OAuthIntrospection{Active: true}, wantCached: true, wantExpiry: time.Minute},
+		{name: "inactive token", ttl: time.Minute, result: entities.OAuthIntrospection{Active: false}, wantCached: true, wantExpiry: time.Minute},
+		{name: "token expires before ttl", ttl: time.Hour, result: entities.OAuthIntrospection{Active: true, Exp: tokenExp}, wantCached: true, wantExpiry: time.Until(time.Unix(tokenExp, 0))},
+		{name: "token expires after ttl", ttl: time.Second, result: entities.OAuthIntrospection{Active: true, Exp: tokenExp}, wantCached: true, wantExpiry: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newIntrospectionCache(tt.ttl, 0)
+			before := time.Now()
+			cache.set("key", tt.result)
+
+			got, ok := cache.get("key")
+			if ok != tt.wantCached {
+				t.Fatalf("cached = %v, want %v", ok, tt.wantCached)
+			}
+			if !ok {
+				return
+			}
+			if got != tt.result {
+				t.Errorf("got %+v, want %+v", got, tt.result)
+			}
+			// Срок записи — ttl, но не позже истечения самого токена (Exp хранится с точностью до секунды)
+			expiry := cache.entries["key"].expiresAt.Sub(before)
+			if expiry > tt.wantExpiry+time.Second || expiry < tt.wantExpiry-time.Second {
+				t.Errorf("entry expires in %s, want %s", expiry, tt.wantExpiry)
+			}
+
+			// Устаревшая запись не возвращается и удаляется
+			entry := cache.entries["key"]
+			entry.expiresAt = time.Now()
+			cache.entries["key"] = entry
+			if _, ok := cache.get("key"); ok {
+				t.Error("expired entry returned")
+			}
+			if _, ok := cache.entries["key"]; ok {
+				t.Error("expired entry kept")
+			}
+		})
+	}
+}
+
+func TestIntrospectionCacheSize(t *testing.T) {
+	cache := newIntrospectionCache(time.Minute, 2)
+	cache.set("a", entities.OAuthIntrospection{Active: true, Sub: "1"})
+	cache.set("b", entities.OAuthIntrospection{Active: true, Sub: "2"})
+
+	// Когда места нет, новый результат не сохраняется, а сохраненные можно обновлять
+	cache.set("c", entities.OAuthIntrospection{Active: true, Sub: "3"})
+	if _, ok := cache.get("c"); ok {
+		t.Error("entry stored over the size limit")
+	}
+	cache.set("a", entities.OAuthIntrospection{Active: false})
+	if got, ok := cache.get("a"); !ok || got.Active {
+		t.Errorf("got %+v (cached %v), want the updated entry", got, ok)
+	}
+
+	// Устаревшие записи освобождают место
+	entry := cache.entries["b"]
+	entry.expiresAt = time.Now().Add(-time.Second)
+	cache.entries["b"] = entry
+	cache.set("c", entities.OAuthIntrospection{Active: true, Sub: "3"})
+	if got, ok := cache.get("c"); !ok || got.Sub != "3" {
+		t.Errorf("got %+v (cached %v), want the new entry after eviction", got, ok)
+	}
+	if len(cache.entries) != 2 {
+		t.Errorf("%d entries, want 2", len(cache.entries))
+	}
+}
+
+func TestIntrospectCache(t *testing.T) {
+	setupTestKeyring(t)
+	client := bunEntities.OAuthClient{ClientID: "resource-server", ClientSecretHash: hashToken("secret")}
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		wantRole string // Роль во втором ответе после смены роли пользователя
+	}{
+		{name: "cached", ttl: time.Minute, wantRole: entities.RoleUser},
+		{name: "cache disabled", ttl: 0, wantRole: entities.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			authRepo := newFakeAuthRepository()
+			authRepo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser})
+			s := NewOAuthService(newFakeOAuthRepository(client), authRepo, nil, nil,
+				&config.OAuth{Introspection: config.Introspection{CacheTTL: tt.ttl}})
+
+			accessToken, err := auth.GenerateAccessToken(time.Minute, 1, entities.RoleUser, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request := entities.OAuthIntrospectRequest{ClientID: client.ClientID, ClientSecret: "secret", Token: accessToken}
+
+			first, err := s.Introspect(ctx, request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !first.Active || first.Role != entities.RoleUser {
+				t.Fatalf("got %+v, want active user", first)
+			}
+
+			// Пока результат в кэше, смена роли другим сервисам не видна
+			authRepo.roles[1] = entities.RoleAdmin
+			second, err := s.Introspect(ctx, request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if second.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", second.Role, tt.wantRole)
+			}
+
+			// Отзыв через этот сервис сразу убирает результат из кэша
+			if err := s.Revoke(ctx, entities.OAuthRevokeRequest{ClientID: client.ClientID, ClientSecret: "secret", Token: accessToken}); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := s.introspectionCache.get(hashToken(accessToken)); ok {
+				t.Error("revoked token left in the cache")
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// LoginThrottledError возвращается, когда попытки входа временно запрещены.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts, try again later"
+}
+
+func usernameThrottleKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginThrottle проверяет, не действует ли задержка или блокировка для username и IP клиента.
+func checkLoginThrottle(ctx context.Context, repo repository.Authorization, username string, clientIP string) error {
+	keys := []string{usernameThrottleKey(username)}
+	if clientIP != "" {
+		keys = append(keys, ipThrottleKey(clientIP))
+	}
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempt, err := repo.GetLoginAttempt(ctx, key)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return err
+		}
+		if attempt.LockedUntil != nil {
+			if wait := time.Until(*attempt.LockedUntil); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// registerFailedLogin учитывает неудачную попытку входа и назначает задержку
+// (удваивающуюся с каждой ошибкой) или временную блокировку.
+func registerFailedLogin(ctx context.Context, repo repository.Authorization, cfg *config.LoginThrottle, username string, clientIP string) {
+	applyThrottleRule(ctx, repo, cfg.Window, usernameThrottleKey(username), cfg.Username)
+	if clientIP != "" {
+		applyThrottleRule(ctx, repo, cfg.Window, ipThrottleKey(clientIP), cfg.IP)
+	}
+}
+
+func applyThrottleRule(ctx context.Context, repo repository.Authorization, window time.Duration, key string, rule config.ThrottleRule) {
+	failures, err := repo.RegisterFailedLogin(ctx, key, window)
+	if err != nil {
+		logrus.Errorf("can't register failed login for %s: %s", key, err.Error())
+		return
+	}
+
+	delay := throttleDelay(failures, rule)
+	if delay <= 0 {
+		return
+	}
+	if err := repo.SetLoginLock(ctx, key, time.Now().Add(delay)); err != nil {
+		logrus.Errorf("can't lock login for %s: %s", key, err.Error())
+		return
+	}
+	if rule.LockoutThreshold > 0 && failures >= rule.LockoutThreshold {
+		logrus.Warnf("login locked for %s after %d failed attempts", key, failures)
+	}
+}
+
+// throttleDelay вычисляет задержку после failures неудачных попыток подряд.
+func throttleDelay(failures int, rule config.ThrottleRule) time.Duration {
+	if rule.LockoutThreshold > 0 && failures >= rule.LockoutThreshold {
+		return rule.LockoutDuration
+	}
+	if failures <= rule.FreeAttempts || rule.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := rule.BaseDelay
+	for i := rule.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if rule.MaxDelay > 0 && delay >= rule.MaxDelay {
+			return rule.MaxDelay
+		}
+	}
+	return delay
+}
+
+// GetLoginLockState возвращает состояние блокировки входа для пользователя.
+func (s *AuthorizationService) GetLoginLockState(ctx context.Context, userID int) (entities.LoginLockState, error) {
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return entities.LoginLockState{}, userNotFound(err)
+	}
+
+	state := entities.LoginLockState{
+		UserID:   userID,
+		Username: username,
+	}
+
+	attempt, err := s.repo.GetLoginAttempt(ctx, usernameThrottleKey(username))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return state, nil
+		}
+		return entities.LoginLockState{}, err
+	}
+
+	state.Failures = attempt.Failures
+	state.LastFailedAt = &attempt.LastFailedAt
+	if attempt.LockedUntil != nil && attempt.LockedUntil.After(time.Now()) {
+		state.Locked = true
+		state.LockedUntil = attempt.LockedUntil
+	}
+	return state, nil
+}
+
+// UnlockUser снимает блокировку входа и сбрасывает счетчик неудачных попыток пользователя.
+func (s *AuthorizationService) UnlockUser(ctx context.Context, userID int) error {
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return userNotFound(err)
+	}
+	return s.repo.ResetLoginAttempts(ctx, usernameThrottleKey(username))
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+func TestThrottleDelay(t *testing.T) {
+	rule := config.ThrottleRule{
+		FreeAttempts:     2,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  time.Hour,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		rule     config.ThrottleRule
+		want     time.Duration
+	}{
+		{name: "free attempt", failures: 1, rule: rule, want: 0},
+		{name: "last free attempt", failures: 2, rule: rule, want: 0},
+		{name: "first delay", failures: 3, rule: rule, want: time.Second},
+		{name: "doubled", failures: 4, rule: rule, want: 2 * time.Second},
+		{name: "doubled twice", failures: 5, rule: rule, want: 4 * time.Second},
+		{name: "capped", failures: 6, rule: rule, want: 5 * time.Second},
+		{name: "capped before lockout", failures: 9, rule: rule, want: 5 * time.Second},
+		{name: "lockout", failures: 10, rule: rule, want: time.Hour},
+		{name: "after lockout", failures: 12, rule: rule, want: time.Hour},
+		{name: "no base delay", failures: 5, rule: config.ThrottleRule{LockoutThreshold: 10, LockoutDuration: time.Hour}, want: 0},
+		{name: "no max delay", failures: 8, rule: config.ThrottleRule{FreeAttempts: 2, BaseDelay: time.Second}, want: 32 * time.Second},
+		{name: "lockout without delays", failures: 3, rule: config.ThrottleRule{LockoutThreshold: 3, LockoutDuration: time.Minute}, want: time.Minute},
+		{name: "disabled", failures: 100, rule: config.ThrottleRule{}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := throttleDelay(tt.failures, tt.rule); got != tt.want {
+				t.Errorf("throttleDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignInLockout(t *testing.T) {
+	setupTestKeyring(t)
+	passwordHash, err := auth.GeneratePasswordHash("correct-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Auth{LoginThrottle: config.LoginThrottle{
+		Window:   time.Hour,
+		Username: config.ThrottleRule{LockoutThreshold: 3, LockoutDuration: time.Hour},
+		IP:       config.ThrottleRule{LockoutThreshold: 5, LockoutDuration: time.Hour},
+	}}
+
+	type attempt struct {
+		username string
+		password string
+		ip       string
+	}
+	tests := []struct {
+		name          string
+		failed        []attempt // Неудачные попытки перед проверяемой
+		try           attempt
+		wantThrottled bool
+	}{
+		{name: "below threshold", failed: []attempt{{"alice", "wrong", "192.0.2.1"}, {"alice", "wrong", "192.0.2.1"}}, try: attempt{"alice", "correct-password", "192.0.2.1"}},
+		{name: "username locked", failed: []attempt{{"alice", "wrong", "192.0.2.1"}, {"alice", "wrong", "192.0.2.2"}, {"ALICE", "wrong", "192.0.2.3"}}, try: attempt{"alice", "correct-password", "192.0.2.4"}, wantThrottled: true},
+		{name: "ip locked", failed: []attempt{{"a", "wrong", "192.0.2.1"}, {"b", "wrong", "192.0.2.1"}, {"c", "wrong", "192.0.2.1"}, {"d", "wrong", "192.0.2.1"}, {"e", "wrong", "192.0.2.1"}}, try: attempt{"alice", "correct-password", "192.0.2.1"}, wantThrottled: true},
+		{name: "other ip not locked", failed: []attempt{{"a", "wrong", "192.0.2.1"}, {"b", "wrong", "192.0.2.1"}, {"c", "wrong", "192.0.2.1"}, {"d", "wrong", "192.0.2.1"}, {"e", "wrong", "192.0.2.1"}}, try: attempt{"alice", "correct-password", "192.0.2.2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", PasswordHash: passwordHash})
+			s := NewAuthorizationService(repo, nil, cfg, nil, nil)
+
+			for _, a := range tt.failed {
+				if _, err := s.SignIn(ctx, entities.SignInInput{Username: a.username, Password: a.password}, entities.ClientInfo{IP: a.ip}); err == nil {
+					t.Fatalf("sign-in of %s with a wrong password succeeded", a.username)
+				}
+			}
+
+			_, err := s.SignIn(ctx, entities.SignInInput{Username: tt.try.username, Password: tt.try.password}, entities.ClientInfo{IP: tt.try.ip})
+			var throttled *LoginThrottledError
+			if errors.As(err, &throttled) != tt.wantThrottled {
+				t.Fatalf("got %v, want throttled %v", err, tt.wantThrottled)
+			}
+			if tt.wantThrottled {
+				// Даже верный пароль не проверяется до конца блокировки
+				if throttled.RetryAfter <= 0 || throttled.RetryAfter > time.Hour {
+					t.Errorf("retry after %s, want within the lockout", throttled.RetryAfter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Успешный вход сбрасывает счетчик по username
+			if _, ok := repo.loginAttempts[usernameThrottleKey("alice")]; ok {
+				t.Errorf("username counter = %+v, want reset", repo.loginAttempts[usernameThrottleKey("alice")])
+			}
+		})
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeAuthRepository()
+	repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "Alice"})
+	until := time.Now().Add(time.Hour)
+	repo.loginAttempts[usernameThrottleKey("alice")] = bunEntities.LoginAttempt{Failures: 5, LastFailedAt: time.Now(), LockedUntil: &until}
+	s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+
+	state, err := s.GetLoginLockState(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Locked || state.Failures != 5 {
+		t.Errorf("state = %+v, want locked after 5 failures", state)
+	}
+
+	if err := s.UnlockUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = s.GetLoginLockState(ctx, 1); err != nil || state.Locked || state.Failures != 0 {
+		t.Errorf("state = %+v (%v), want unlocked", state, err)
+	}
+
+	if err := s.UnlockUser(ctx, 2); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unlock of a missing user: got %v, want %v", err, ErrUserNotFound)
+	}
+}
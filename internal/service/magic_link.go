@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMagicLinkTTL         = 15 * time.Minute // Время жизни ссылки, если не задано в конфигурации
+	defaultMagicLinkMaxRequests = 3                // Ссылок на адрес или IP за окно, если не задано в конфигурации
+	defaultMagicLinkWindow      = 15 * time.Minute // Окно ограничения запросов, если не задано в конфигурации
+
+	PurposeMagicLink = "magic_link" // Назначение одноразового токена входа по ссылке
+)
+
+var (
+	ErrMagicLinkDisabled = errors.New("sign-in by email link is disabled")
+	ErrInvalidMagicLink  = errors.New("invalid or expired sign-in link")
+)
+
+// RateLimitedError возвращается, когда запросы временно ограничены.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return "too many requests, try again later"
+}
+
+func magicLinkEmailKey(email string) string {
+	return "magic-link:email:" + strings.ToLower(email)
+}
+
+func magicLinkIPKey(ip string) string {
+	return "magic-link:ip:" + ip
+}
+
+// RequestMagicLink отправляет ссылку для входа без пароля, если адрес принадлежит пользователю
+// и подтвержден. Ответ не зависит от наличия адреса в базе; запросы ограничиваются по адресу и IP клиента.
+func (s *AuthorizationService) RequestMagicLink(ctx context.Context, email string, clientIP string) error {
+	cfg := s.cfg.MagicLink
+	if !cfg.Enabled {
+		return ErrMagicLinkDisabled
+	}
+
+	maxRequests := cfg.MaxRequests
+	if maxRequests <= 0 {
+		maxRequests = defaultMagicLinkMaxRequests
+	}
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultMagicLinkWindow
+	}
+	keys := []string{magicLinkEmailKey(email)}
+	if clientIP != "" {
+		keys = append(keys, magicLinkIPKey(clientIP))
+	}
+	for _, key := range keys {
+		requests, windowStartedAt, err := s.repo.RegisterRequest(ctx, key, window)
+		if err != nil {
+			return err
+		}
+		if requests > maxRequests {
+			return &RateLimitedError{RetryAfter: time.Until(windowStartedAt.Add(window))}
+		}
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	// Ссылка на неподтвержденный адрес могла бы попасть к чужому человеку
+	if user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	ttl := cfg.TokenTTL
+	if ttl <= 0 {
+		ttl = defaultMagicLinkTTL
+	}
+	if err := s.repo.CreateOneTimeToken(ctx, bunEntities.OneTimeToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		Purpose:   PurposeMagicLink,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	link, err := withQuery(cfg.URL, url.Values{"token": {token}})
+	if err != nil {
+		return errors.New("invalid magic link url: " + err.Error())
+	}
+	sendMailAsync(s.mailer, mailer.Message{
+		To:      *user.Email,
+		Subject: "Sign-in link",
+		Body: "Hello, " + user.Name + "!\n\n" +
+			"To sign in as " + user.Username + ", open the link below:\n\n" +
+			link + "\n\n" +
+			"The link is valid for " + ttl.String() + " and can be used once.\n" +
+			"If you did not request it, ignore this email.\n",
+	})
+	return nil
+}
+
+// MagicLinkSignIn выполняет вход по ссылке из письма. Ссылка перестает действовать после
+// использования, после запроса новой ссылки и после смены пароля.
+func (s *AuthorizationService) MagicLinkSignIn(ctx context.Context, token string, client entities.ClientInfo) (entities.SignInResult, error) {
+	if !s.cfg.MagicLink.Enabled {
+		return entities.SignInResult{}, ErrMagicLinkDisabled
+	}
+
+	link, err := s.repo.ConsumeOneTimeToken(ctx, hashToken(token), PurposeMagicLink)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.SignInResult{}, ErrInvalidMagicLink
+		}
+		return entities.SignInResult{}, err
+	}
+	if link.ExpiresAt.Before(time.Now()) {
+		return entities.SignInResult{}, ErrInvalidMagicLink
+	}
+
+	passwordChangedAt, err := s.repo.GetPasswordChangedAt(ctx, link.UserID)
+	if err != nil {
+		return entities.SignInResult{}, err
+	}
+	if passwordChangedAt != nil && !link.CreatedAt.After(*passwordChangedAt) {
+		return entities.SignInResult{}, ErrInvalidMagicLink
+	}
+
+	role, err := s.repo.GetRole(ctx, link.UserID)
+	if err != nil {
+		return entities.SignInResult{}, err
+	}
+
+	logrus.Infof("user %d signed in by email link", link.UserID)
+	return completeSignIn(ctx, s.repo, link.UserID, role, "", client)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+)
+
+const magicLinkPage = "http://localhost/magic-link"
+
+// newMagicLinkService создает сервис с пользователями alice (1) с подтвержденным адресом и bob (2) с неподтвержденным
+func newMagicLinkService(t *testing.T) (*AuthorizationService, *fakeAuthRepository, *mailer.MemoryMailer) {
+	t.Helper()
+	alice, bob := "alice@example.com", "bob@example.com"
+	verifiedAt := time.Now().Add(-time.Hour)
+	repo := newFakeAuthRepository()
+	repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", Email: &alice, EmailVerifiedAt: &verifiedAt})
+	repo.addUser(bunEntities.User{ID: 2, Role: entities.RoleUser, Username: "bob", Email: &bob})
+	m := mailer.NewMemoryMailer()
+	s := NewAuthorizationService(repo, nil, &config.Auth{MagicLink: config.MagicLink{
+		Enabled:     true,
+		URL:         magicLinkPage,
+		MaxRequests: 2,
+		Window:      time.Hour,
+	}}, m, nil)
+	return s, repo, m
+}
+
+func TestRequestMagicLinkRateLimit(t *testing.T) {
+	type request struct {
+		email string
+		ip    string
+	}
+
+	tests := []struct {
+		name        string
+		requests    []request
+		staleWindow bool // Окно для адреса началось раньше, чем window назад
+		wantLimited bool // Ограничен ли последний запрос
+		wantMails   int
+	}{
+		{name: "within limit", requests: []request{{"alice@example.com", "192.0.2.1"}, {"alice@example.com", "192.0.2.1"}}, wantMails: 2},
+		{name: "email over limit", requests: []request{{"alice@example.com", "192.0.2.1"}, {"ALICE@example.com", "192.0.2.2"}, {"alice@example.com", "192.0.2.3"}}, wantLimited: true, wantMails: 2},
+		{name: "ip over limit", requests: []request{{"a@example.com", "192.0.2.1"}, {"b@example.com", "192.0.2.1"}, {"alice@example.com", "192.0.2.1"}}, wantLimited: true},
+		{name: "unknown addresses count too", requests: []request{{"nobody@example.com", ""}, {"nobody@example.com", ""}, {"nobody@example.com", ""}}, wantLimited: true},
+		{name: "window expired", requests: []request{{"alice@example.com", "192.0.2.1"}}, staleWindow: true, wantMails: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, repo, m := newMagicLinkService(t)
+			if tt.staleWindow {
+				repo.rateLimits[magicLinkEmailKey("alice@example.com")] = bunEntities.RateLimit{Requests: 2, WindowStartedAt: time.Now().Add(-2 * time.Hour)}
+			}
+
+			var err error
+			for i, r := range tt.requests {
+				err = s.RequestMagicLink(ctx, r.email, r.ip)
+				if i < len(tt.requests)-1 && err != nil {
+					t.Fatalf("request %d: %v", i, err)
+				}
+			}
+
+			var limited *RateLimitedError
+			if errors.As(err, &limited) != tt.wantLimited {
+				t.Fatalf("got %v, want limited %v", err, tt.wantLimited)
+			}
+			if limited != nil && (limited.RetryAfter <= 0 || limited.RetryAfter > time.Hour) {
+				t.Errorf("retry after %s, want within the window", limited.RetryAfter)
+			}
+			if !tt.wantLimited && err != nil {
+				t.Fatal(err)
+			}
+			if messages := waitForMessages(t, m, tt.wantMails); len(messages) != tt.wantMails {
+				t.Errorf("sent %d messages, want %d", len(messages), tt.wantMails)
+			}
+			// Ограничение запросов ссылок не касается счетчиков входа
+			if len(repo.loginAttempts) != 0 {
+				t.Errorf("login attempts = %+v, want none", repo.loginAttempts)
+			}
+		})
+	}
+}
+
+func TestMagicLinkSignIn(t *testing.T) {
+	setupTestKeyring(t)
+
+	tests := []struct {
+		name    string
+		links   int                                                                   // Сколько ссылок запрошено; используется первая
+		prepare func(s *AuthorizationService, repo *fakeAuthRepository, token string) // Что происходит между письмом и входом
+		wantErr error
+	}{
+		{name: "valid link", links: 1},
+		{name: "used link", links: 1, prepare: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			if _, err := s.MagicLinkSignIn(context.Background(), token, entities.ClientInfo{}); err != nil {
+				panic(err)
+			}
+		}, wantErr: ErrInvalidMagicLink},
+		{name: "newer link requested", links: 2, wantErr: ErrInvalidMagicLink},
+		{name: "expired link", links: 1, prepare: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			link := repo.oneTimeTokens[hashToken(token)]
+			link.ExpiresAt = time.Now().Add(-time.Second)
+			repo.oneTimeTokens[hashToken(token)] = link
+		}, wantErr: ErrInvalidMagicLink},
+		{name: "password changed after link", links: 1, prepare: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			user := repo.users[1]
+			changedAt := time.Now().Add(time.Second)
+			user.PasswordChangedAt = &changedAt
+			repo.users[1] = user
+		}, wantErr: ErrInvalidMagicLink},
+		{name: "password changed before link", links: 1, prepare: func(s *AuthorizationService, repo *fakeAuthRepository, token string) {
+			user := repo.users[1]
+			changedAt := time.Now().Add(-time.Minute)
+			user.PasswordChangedAt = &changedAt
+			repo.users[1] = user
+		}},
+		{name: "unknown token", links: 0, wantErr: ErrInvalidMagicLink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, repo, m := newMagicLinkService(t)
+
+			token := "unknown"
+			for i := 0; i < tt.links; i++ {
+				if err := s.RequestMagicLink(ctx, "alice@example.com", ""); err != nil {
+					t.Fatal(err)
+				}
+				messages := waitForMessages(t, m, i+1)
+				if len(messages) != i+1 {
+					t.Fatalf("sent %d messages, want %d", len(messages), i+1)
+				}
+				if i == 0 {
+					token = linkToken(t, messages[0].Body, magicLinkPage)
+				}
+			}
+			if tt.prepare != nil {
+				tt.prepare(s, repo, token)
+			}
+
+			result, err := s.MagicLinkSignIn(ctx, token, entities.ClientInfo{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && result.AccessToken == "" {
+				t.Error("no access token")
+			}
+		})
+	}
+}
+
+func TestRequestMagicLinkUnverifiedEmail(t *testing.T) {
+	s, repo, m := newMagicLinkService(t)
+
+	// Ссылка на неподтвержденный адрес могла бы попасть к чужому человеку
+	if err := s.RequestMagicLink(context.Background(), "bob@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if messages := m.Messages(); len(messages) != 0 || len(repo.oneTimeTokens) != 0 {
+		t.Errorf("sent %+v, want no mail and no tokens", messages)
+	}
+}
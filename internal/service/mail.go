@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+	"github.com/sirupsen/logrus"
+)
+
+const mailSendTimeout = 30 * time.Second // Таймаут отправки одного письма
+
+// sendMailAsync отправляет письмо в фоне: время ответа не должно зависеть от того,
+// нашелся ли получатель, а медленный SMTP-сервер не должен задерживать запрос.
+func sendMailAsync(m mailer.Mailer, message mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := m.Send(ctx, message); err != nil {
+			logrus.Errorf("can't send email %q: %s", message.Subject, err.Error())
+		}
+	}()
+}
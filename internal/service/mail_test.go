@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+)
+
+// waitForMessages ждет n писем: отправка идет в фоне
+func waitForMessages(t *testing.T, m *mailer.MemoryMailer, n int) []mailer.Message {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		messages := m.Messages()
+		if len(messages) >= n || time.Now().After(deadline) {
+			return messages
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// linkToken достает токен из ссылки в теле письма
+func linkToken(t *testing.T, body string, page string) string {
+	t.Helper()
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, page) {
+			continue
+		}
+		u, err := url.Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Query().Get("token")
+	}
+	t.Fatalf("no link to %s in %q", page, body)
+	return ""
+}
+
+func TestAccountEmails(t *testing.T) {
+	const (
+		resetPage  = "http://localhost/reset"
+		verifyPage = "http://localhost/verify"
+	)
+	email := func(address string) *string { return &address }
+	verifiedAt := time.Now()
+	users := []bunEntities.User{
+		{ID: 1, Role: entities.RoleUser, Username: "alice", Name: "Alice", Email: email("alice@example.com")},
+		{ID: 2, Role: entities.RoleUser, Username: "bob", Name: "Bob", Email: email("bob@example.com"), EmailVerifiedAt: &verifiedAt},
+	}
+
+	tests := []struct {
+		name     string
+		send     func(s *AuthorizationService, ctx context.Context, email string) error
+		email    string
+		wantTo   string // Пустой, если письма быть не должно
+		page     string
+		purpose  string
+		wantUser int
+	}{
+		{
+			name:     "password reset to known address",
+			send:     (*AuthorizationService).ForgotPassword,
+			email:    "Alice@example.com",
+			wantTo:   "alice@example.com",
+			page:     resetPage,
+			purpose:  PurposePasswordReset,
+			wantUser: 1,
+		},
+		{
+			name:  "password reset to unknown address",
+			send:  (*AuthorizationService).ForgotPassword,
+			email: "nobody@example.com",
+		},
+		{
+			name:     "verification to unverified address",
+			send:     (*AuthorizationService).ResendEmailVerification,
+			email:    "alice@example.com",
+			wantTo:   "alice@example.com",
+			page:     verifyPage,
+			purpose:  PurposeEmailVerification,
+			wantUser: 1,
+		},
+		{
+			name:  "verification to verified address",
+			send:  (*AuthorizationService).ResendEmailVerification,
+			email: "bob@example.com",
+		},
+		{
+			name:  "verification to unknown address",
+			send:  (*AuthorizationService).ResendEmailVerification,
+			email: "nobody@example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			for _, user := range users {
+				repo.addUser(user)
+			}
+			m := mailer.NewMemoryMailer()
+			s := NewAuthorizationService(repo, nil, &config.Auth{
+				PasswordReset:     config.PasswordReset{URL: resetPage},
+				EmailVerification: config.EmailVerification{URL: verifyPage},
+			}, m, nil)
+
+			if err := tt.send(s, ctx, tt.email); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantTo == "" {
+				if messages := m.Messages(); len(messages) != 0 || len(repo.oneTimeTokens) != 0 {
+					t.Fatalf("sent %+v, want no mail and no tokens", messages)
+				}
+				return
+			}
+
+			messages := waitForMessages(t, m, 1)
+			if len(messages) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(messages))
+			}
+			if messages[0].To != tt.wantTo {
+				t.Errorf("mail to %q, want %q", messages[0].To, tt.wantTo)
+			}
+			token := linkToken(t, messages[0].Body, tt.page)
+			stored, ok := repo.oneTimeTokens[hashToken(token)]
+			if !ok || stored.Purpose != tt.purpose || stored.UserID != tt.wantUser {
+				t.Errorf("token from mail matches %+v, want %s for user %d", stored, tt.purpose, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestVerifyEmailFromMail(t *testing.T) {
+	tests := []struct {
+		name    string
+		resends int // Сколько раз ссылка запрошена повторно после первой
+		use     int // Ссылка из какого по счету письма используется
+		wantErr error
+	}{
+		{name: "single mail", resends: 0, use: 0},
+		{name: "latest mail after resend", resends: 2, use: 2},
+		{name: "earlier mail after resend", resends: 1, use: 0, wantErr: ErrInvalidVerificationToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			address := "alice@example.com"
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", Email: &address})
+			m := mailer.NewMemoryMailer()
+			s := NewAuthorizationService(repo, nil, &config.Auth{
+				EmailVerification: config.EmailVerification{URL: "http://localhost/verify"},
+			}, m, nil)
+
+			for i := 0; i <= tt.resends; i++ {
+				if err := s.ResendEmailVerification(ctx, address); err != nil {
+					t.Fatal(err)
+				}
+				// Ждем письмо, чтобы порядок писем совпадал с порядком запросов
+				if messages := waitForMessages(t, m, i+1); len(messages) != i+1 {
+					t.Fatalf("sent %d messages, want %d", len(messages), i+1)
+				}
+			}
+			token := linkToken(t, m.Messages()[tt.use].Body, "http://localhost/verify")
+
+			err := s.VerifyEmail(ctx, token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if verified := repo.users[1].EmailVerifiedAt != nil; verified != (tt.wantErr == nil) {
+				t.Errorf("email verified = %v", verified)
+			}
+		})
+	}
+}
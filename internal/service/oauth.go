@@ -0,0 +1,685 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/sirupsen/logrus"
+)
+
+const authorizationCodeTTL = 2 * time.Minute // Время жизни кода авторизации
+
+// Коды ошибок OAuth 2.0 (RFC 6749, разделы 4.1.2.1 и 5.2)
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthAccessDenied            = "access_denied"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+)
+
+var ErrOAuthClientNotFound = errors.New("oauth client not found")
+
+// oauthGrantTypes — grant_type, которые поддерживает token endpoint
+var oauthGrantTypes = []string{
+	entities.OAuthGrantAuthorizationCode,
+	entities.OAuthGrantRefreshToken,
+	entities.OAuthGrantClientCredentials,
+}
+
+// OAuthError — ошибка протокола OAuth 2.0, которая передается клиенту в стандартном виде.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(code string, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// clientCredentials — данные, которыми клиент подтверждает свою личность:
+// секрет (HTTP Basic или client_secret) или подписанный его ключом JWT (private_key_jwt).
+type clientCredentials struct {
+	ClientID      string
+	ClientSecret  string
+	AssertionType string
+	Assertion     string
+}
+
+type OAuthService struct {
+	repo               repository.OAuth
+	authRepo           repository.Authorization
+	rolesRepo          repository.Roles
+	apiTokenRepo       repository.APITokens
+	cfg                *config.OAuth
+	introspectionCache *introspectionCache
+}
+
+func NewOAuthService(repo repository.OAuth, authRepo repository.Authorization, rolesRepo repository.Roles, apiTokenRepo repository.APITokens, cfg *config.OAuth) *OAuthService {
+	return &OAuthService{
+		repo:               repo,
+		authRepo:           authRepo,
+		rolesRepo:          rolesRepo,
+		apiTokenRepo:       apiTokenRepo,
+		cfg:                cfg,
+		introspectionCache: newIntrospectionCache(cfg.Introspection.CacheTTL, cfg.Introspection.CacheSize),
+	}
+}
+
+// CreateClient регистрирует OAuth-клиента. Секрет конфиденциального клиента возвращается один раз;
+// клиент с открытым ключом секрета не получает и подтверждает личность подписанным JWT.
+// Сервисный клиент получает права только той роли, которая указана при регистрации.
+func (s *OAuthService) CreateClient(ctx context.Context, input entities.OAuthClientInput) (entities.OAuthClientCredentials, error) {
+	if input.Role != "" {
+		if err := roleExists(ctx, s.rolesRepo, input.Role); err != nil {
+			return entities.OAuthClientCredentials{}, err
+		}
+	}
+
+	clientID, err := randomToken(16)
+	if err != nil {
+		return entities.OAuthClientCredentials{}, err
+	}
+
+	redirectURIs := input.RedirectURIs
+	if redirectURIs == nil {
+		redirectURIs = []string{} // У сервисного клиента адресов перенаправления нет
+	}
+	client := bunEntities.OAuthClient{
+		ClientID:     clientID,
+		Name:         input.Name,
+		RedirectURIs: redirectURIs,
+		Scopes:       input.Scopes,
+		GrantTypes:   input.GrantTypes,
+		PublicKey:    input.PublicKey,
+		Role:         input.Role,
+		CreatedAt:    time.Now(),
+	}
+
+	var clientSecret string
+	if !input.Public && input.PublicKey == "" {
+		clientSecret, err = randomToken(32)
+		if err != nil {
+			return entities.OAuthClientCredentials{}, err
+		}
+		client.ClientSecretHash = hashToken(clientSecret)
+	}
+
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return entities.OAuthClientCredentials{}, err
+	}
+
+	return entities.OAuthClientCredentials{
+		OAuthClient:  oauthClientInfo(client),
+		ClientSecret: clientSecret,
+	}, nil
+}
+
+// ServiceClientRole возвращает роль, права которой получает сервисный клиент.
+// Пустая строка означает, что прав у клиента нет.
+func (s *OAuthService) ServiceClientRole(ctx context.Context, clientID string) (string, error) {
+	client, err := s.getClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrOAuthClientNotFound
+		}
+		return "", err
+	}
+	return client.Role, nil
+}
+
+// getClient возвращает OAuth-клиента; scope клиентов, зарегистрированных до появления
+// текущего списка scope, переводятся в него.
+func (s *OAuthService) getClient(ctx context.Context, clientID string) (bunEntities.OAuthClient, error) {
+	client, err := s.repo.GetClient(ctx, clientID)
+	if err != nil {
+		return bunEntities.OAuthClient{}, err
+	}
+	client.Scopes = entities.NormalizeScopes(client.Scopes)
+	return client, nil
+}
+
+// GetClients возвращает зарегистрированных OAuth-клиентов.
+func (s *OAuthService) GetClients(ctx context.Context) ([]entities.OAuthClient, error) {
+	clients, err := s.repo.GetClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.OAuthClient, 0, len(clients))
+	for _, client := range clients {
+		result = append(result, oauthClientInfo(client))
+	}
+	return result, nil
+}
+
+// DeleteClient удаляет OAuth-клиента; его refresh token перестают действовать.
+func (s *OAuthService) DeleteClient(ctx context.Context, clientID string) error {
+	deleted, err := s.repo.DeleteClient(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+// authorizationGrant — проверенный запрос авторизации
+type authorizationGrant struct {
+	client      bunEntities.OAuthClient
+	redirectURI string
+	scope       string
+}
+
+// checkAuthorization проверяет запрос авторизации. Пока клиент и redirect_uri не проверены, ошибка
+// возвращается как *OAuthError; после этого ошибка передается клиенту адресом перенаправления errorRedirect.
+func (s *OAuthService) checkAuthorization(ctx context.Context, request entities.OAuthAuthorizeRequest) (authorizationGrant, string, error) {
+	client, err := s.getClient(ctx, request.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return authorizationGrant{}, "", newOAuthError(OAuthInvalidClient, "unknown client")
+		}
+		return authorizationGrant{}, "", err
+	}
+	if !slices.Contains(client.GrantTypes, entities.OAuthGrantAuthorizationCode) {
+		return authorizationGrant{}, "", newOAuthError(OAuthUnauthorizedClient, "client is not allowed to use the authorization code flow")
+	}
+
+	redirectURI := request.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return authorizationGrant{}, "", newOAuthError(OAuthInvalidRequest, "redirect_uri is not registered for this client")
+	}
+
+	redirectError := func(code string, description string) (authorizationGrant, string, error) {
+		errorRedirect, err := authorizationErrorRedirect(redirectURI, request.State, code, description)
+		return authorizationGrant{}, errorRedirect, err
+	}
+
+	if request.ResponseType != "code" {
+		return redirectError(OAuthUnsupportedResponseType, "only response_type=code is supported")
+	}
+	// PKCE обязателен для всех клиентов, метод plain не принимается
+	if request.CodeChallengeMethod != "S256" {
+		return redirectError(OAuthInvalidRequest, "code_challenge_method must be S256")
+	}
+	if len(request.CodeChallenge) != 43 {
+		return redirectError(OAuthInvalidRequest, "invalid code_challenge")
+	}
+
+	scope, ok := resolveScope(request.Scope, client.Scopes)
+	if !ok {
+		return redirectError(OAuthInvalidScope, "requested scope is not allowed for this client")
+	}
+
+	return authorizationGrant{client: client, redirectURI: redirectURI, scope: scope}, "", nil
+}
+
+// authorizationErrorRedirect возвращает адрес перенаправления клиента с ошибкой (RFC 6749, раздел 4.1.2.1)
+func authorizationErrorRedirect(redirectURI string, state string, code string, description string) (string, error) {
+	return withQuery(redirectURI, url.Values{
+		"error":             {code},
+		"error_description": {description},
+		"state":             {state},
+	})
+}
+
+// Consent проверяет запрос авторизации и возвращает то, на что пользователь дает согласие.
+// Если запрос неверен, но redirect_uri уже проверен, вместо согласия возвращается адрес перенаправления с ошибкой.
+func (s *OAuthService) Consent(ctx context.Context, request entities.OAuthAuthorizeRequest) (entities.OAuthConsent, string, error) {
+	grant, errorRedirect, err := s.checkAuthorization(ctx, request)
+	if err != nil || errorRedirect != "" {
+		return entities.OAuthConsent{}, errorRedirect, err
+	}
+
+	return entities.OAuthConsent{
+		ClientID:    grant.client.ClientID,
+		ClientName:  grant.client.Name,
+		Scopes:      strings.Fields(grant.scope),
+		RedirectURI: grant.redirectURI,
+	}, "", nil
+}
+
+// Authorize выдает код авторизации пользователю userID, если он согласился (approved), и возвращает
+// адрес перенаправления клиента. Пока клиент и redirect_uri не проверены, ошибка возвращается как *OAuthError;
+// после этого ошибки, как и отказ пользователя, передаются клиенту в параметрах перенаправления.
+func (s *OAuthService) Authorize(ctx context.Context, userID int, request entities.OAuthAuthorizeRequest, approved bool) (string, error) {
+	grant, errorRedirect, err := s.checkAuthorization(ctx, request)
+	if err != nil || errorRedirect != "" {
+		return errorRedirect, err
+	}
+	if !approved {
+		return authorizationErrorRedirect(grant.redirectURI, request.State, OAuthAccessDenied, "the user denied the request")
+	}
+
+	// Пользователь не может передать клиенту больше, чем разрешает его роль. Scope по умолчанию
+	// сужаются молча, а явно запрошенные недоступные scope отклоняются
+	role, err := s.authRepo.GetRole(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	allowed, err := userScopes(ctx, s.authRepo, role)
+	if err != nil {
+		return "", err
+	}
+	scope := restrictScope(grant.scope, allowed)
+	if scope == "" || (strings.TrimSpace(request.Scope) != "" && scope != grant.scope) {
+		return authorizationErrorRedirect(grant.redirectURI, request.State, OAuthInvalidScope, "requested scope is not allowed for this user")
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateCode(ctx, bunEntities.OAuthCode{
+		CodeHash:            hashToken(code),
+		ClientID:            grant.client.ClientID,
+		UserID:              userID,
+		RedirectURI:         grant.redirectURI,
+		RedirectURISupplied: request.RedirectURI != "",
+		Scope:               scope,
+		CodeChallenge:       request.CodeChallenge,
+		ExpiresAt:           time.Now().Add(authorizationCodeTTL),
+	}); err != nil {
+		return "", err
+	}
+
+	return withQuery(grant.redirectURI, url.Values{
+		"code":  {code},
+		"state": {request.State},
+	})
+}
+
+// Token обменивает код авторизации или refresh token на новую пару токенов,
+// а сервисному клиенту выдает access token от его имени (client_credentials).
+func (s *OAuthService) Token(ctx context.Context, request entities.OAuthTokenRequest, clientInfo entities.ClientInfo) (entities.OAuthTokenResponse, error) {
+	client, err := s.authenticateClient(ctx, clientCredentials{
+		ClientID:      request.ClientID,
+		ClientSecret:  request.ClientSecret,
+		AssertionType: request.ClientAssertionType,
+		Assertion:     request.ClientAssertion,
+	})
+	if err != nil {
+		return entities.OAuthTokenResponse{}, err
+	}
+
+	if !slices.Contains(oauthGrantTypes, request.GrantType) {
+		return entities.OAuthTokenResponse{}, newOAuthError(OAuthUnsupportedGrantType,
+			"grant_type must be authorization_code, refresh_token or client_credentials")
+	}
+	if !slices.Contains(client.GrantTypes, request.GrantType) {
+		return entities.OAuthTokenResponse{}, newOAuthError(OAuthUnauthorizedClient, "grant_type is not allowed for this client")
+	}
+
+	var accessToken, refreshToken, scope string
+	switch request.GrantType {
+	case entities.OAuthGrantAuthorizationCode:
+		accessToken, refreshToken, scope, err = s.exchangeCode(ctx, client, request, clientInfo)
+	case entities.OAuthGrantRefreshToken:
+		accessToken, refreshToken, scope, err = s.refresh(ctx, client, request, clientInfo)
+	case entities.OAuthGrantClientCredentials:
+		accessToken, scope, err = s.clientCredentialsToken(client, request)
+	}
+	if err != nil {
+		return entities.OAuthTokenResponse{}, err
+	}
+
+	return entities.OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}, nil
+}
+
+// exchangeCode выдает токены по коду авторизации после проверки PKCE.
+func (s *OAuthService) exchangeCode(ctx context.Context, client bunEntities.OAuthClient, request entities.OAuthTokenRequest, clientInfo entities.ClientInfo) (string, string, string, error) {
+	if request.Code == "" || request.CodeVerifier == "" {
+		return "", "", "", newOAuthError(OAuthInvalidRequest, "code and code_verifier are required")
+	}
+
+	code, err := s.repo.ConsumeCode(ctx, hashToken(request.Code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", "", newOAuthError(OAuthInvalidGrant, "invalid authorization code")
+		}
+		return "", "", "", err
+	}
+
+	if code.ClientID != client.ClientID || code.ExpiresAt.Before(time.Now()) {
+		return "", "", "", newOAuthError(OAuthInvalidGrant, "invalid authorization code")
+	}
+	// redirect_uri из запроса авторизации должен прийти снова и совпасть (RFC 6749, раздел 4.1.3)
+	if (code.RedirectURISupplied || request.RedirectURI != "") && request.RedirectURI != code.RedirectURI {
+		return "", "", "", newOAuthError(OAuthInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !verifyCodeChallenge(request.CodeVerifier, code.CodeChallenge) {
+		return "", "", "", newOAuthError(OAuthInvalidGrant, "invalid code_verifier")
+	}
+
+	role, err := s.authRepo.GetRole(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", "", newOAuthError(OAuthInvalidGrant, "invalid authorization code")
+		}
+		return "", "", "", err
+	}
+
+	accessToken, refreshToken, err := issueTokens(ctx, s.authRepo, code.UserID, role, client.ClientID, code.Scope, clientInfo)
+	if err != nil {
+		return "", "", "", err
+	}
+	return accessToken, refreshToken, code.Scope, nil
+}
+
+// clientCredentialsToken выдает сервисному клиенту access token с client_id в subject.
+// Refresh token не выдается: клиент в любой момент может запросить новый токен.
+func (s *OAuthService) clientCredentialsToken(client bunEntities.OAuthClient, request entities.OAuthTokenRequest) (string, string, error) {
+	// Публичный клиент не может подтвердить, что запрос исходит от него
+	if client.ClientSecretHash == "" && client.PublicKey == "" {
+		return "", "", newOAuthError(OAuthUnauthorizedClient, "public clients can't use the client_credentials grant")
+	}
+
+	scope, ok := resolveScope(request.Scope, client.Scopes)
+	if !ok {
+		return "", "", newOAuthError(OAuthInvalidScope, "requested scope is not allowed for this client")
+	}
+
+	accessToken, err := auth.GenerateClientToken(accessTokenTTL, client.ClientID, scope)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, scope, nil
+}
+
+// refresh обновляет токены по refresh token клиента; scope можно только сузить.
+func (s *OAuthService) refresh(ctx context.Context, client bunEntities.OAuthClient, request entities.OAuthTokenRequest, clientInfo entities.ClientInfo) (string, string, string, error) {
+	if !isRefreshToken(request.RefreshToken) {
+		return "", "", "", newOAuthError(OAuthInvalidGrant, "invalid refresh token")
+	}
+
+	session, err := s.authRepo.GetSession(ctx, request.RefreshToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", "", newOAuthError(OAuthInvalidGrant, "invalid refresh token")
+		}
+		return "", "", "", err
+	}
+	if session.ClientID != client.ClientID {
+		return "", "", "", newOAuthError(OAuthInvalidGrant, "invalid refresh token")
+	}
+
+	// Сессии, выданные до появления текущего списка scope, продолжают работать с замененными scope
+	scope := strings.Join(entities.NormalizeScopes(strings.Fields(session.Scope)), " ")
+	if request.Scope != "" {
+		var ok bool
+		scope, ok = resolveScope(request.Scope, strings.Fields(scope))
+		if !ok {
+			return "", "", "", newOAuthError(OAuthInvalidScope, "requested scope exceeds the original grant")
+		}
+	}
+
+	accessToken, refreshToken, err := rotateSession(ctx, s.authRepo, session, scope, clientInfo)
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			return "", "", "", newOAuthError(OAuthInvalidGrant, err.Error())
+		}
+		if session.ExpiresAt.Before(time.Now()) {
+			return "", "", "", newOAuthError(OAuthInvalidGrant, "refresh token expired")
+		}
+		return "", "", "", err
+	}
+	return accessToken, refreshToken, scope, nil
+}
+
+// Revoke отзывает refresh token (вместе с семейством сессий) или access token клиента (RFC 7009).
+// Неизвестные и чужие токены игнорируются.
+func (s *OAuthService) Revoke(ctx context.Context, request entities.OAuthRevokeRequest) error {
+	client, err := s.authenticateClient(ctx, clientCredentials{
+		ClientID:      request.ClientID,
+		ClientSecret:  request.ClientSecret,
+		AssertionType: request.ClientAssertionType,
+		Assertion:     request.ClientAssertion,
+	})
+	if err != nil {
+		return err
+	}
+	if request.Token == "" {
+		return newOAuthError(OAuthInvalidRequest, "token is required")
+	}
+	defer s.introspectionCache.delete(hashToken(request.Token)) // Отозванный токен не должен оставаться активным в кэше
+
+	if isRefreshToken(request.Token) {
+		session, err := s.authRepo.GetSession(ctx, request.Token)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		if session.ClientID != client.ClientID {
+			return nil
+		}
+		return s.authRepo.DeleteSessionFamily(ctx, session.FamilyID)
+	}
+
+	claims, err := auth.ParseAccessToken(request.Token)
+	if err != nil || claims.ClientID != client.ClientID || claims.Id == "" {
+		return nil
+	}
+	return s.authRepo.RevokeToken(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+// authenticateClient проверяет client_id и секрет конфиденциального клиента
+// или подписанное ключом клиента утверждение (private_key_jwt).
+func (s *OAuthService) authenticateClient(ctx context.Context, credentials clientCredentials) (bunEntities.OAuthClient, error) {
+	if credentials.AssertionType != "" || credentials.Assertion != "" {
+		// Клиент должен использовать только один способ аутентификации (RFC 6749, раздел 2.3)
+		if credentials.ClientSecret != "" {
+			return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidRequest, "use either client_secret or client_assertion")
+		}
+		return s.authenticateAssertion(ctx, credentials)
+	}
+	if credentials.ClientID == "" {
+		return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidClient, "client_id is required")
+	}
+
+	client, err := s.getClient(ctx, credentials.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidClient, "client authentication failed")
+		}
+		return bunEntities.OAuthClient{}, err
+	}
+
+	// Клиент с открытым ключом подтверждает личность только подписанным JWT
+	if client.PublicKey != "" ||
+		client.ClientSecretHash != "" &&
+			subtle.ConstantTimeCompare([]byte(hashToken(credentials.ClientSecret)), []byte(client.ClientSecretHash)) != 1 {
+		logrus.Warnf("oauth client %s failed authentication", credentials.ClientID)
+		return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidClient, "client authentication failed")
+	}
+	return client, nil
+}
+
+// authenticateAssertion проверяет JWT, подписанный ключом клиента (RFC 7523), и отклоняет
+// повторное использование одного и того же утверждения.
+func (s *OAuthService) authenticateAssertion(ctx context.Context, credentials clientCredentials) (bunEntities.OAuthClient, error) {
+	if credentials.AssertionType != auth.ClientAssertionType || credentials.Assertion == "" {
+		return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidRequest, "client_assertion_type must be "+auth.ClientAssertionType)
+	}
+	// Без адреса token endpoint нельзя проверить aud утверждения
+	if s.cfg.TokenURL == "" {
+		return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidClient, "private_key_jwt client authentication is not configured")
+	}
+
+	var client bunEntities.OAuthClient
+	var lookupErr error
+	assertion, err := auth.ParseClientAssertion(credentials.Assertion, s.cfg.TokenURL, func(clientID string) (crypto.PublicKey, error) {
+		client, lookupErr = s.getClient(ctx, clientID)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if client.PublicKey == "" {
+			return nil, errors.New("client has no public key")
+		}
+		return auth.ParsePublicKeyPEM([]byte(client.PublicKey))
+	})
+	if err != nil {
+		if lookupErr != nil && !errors.Is(lookupErr, sql.ErrNoRows) {
+			return bunEntities.OAuthClient{}, lookupErr
+		}
+		logrus.Warnf("oauth client assertion rejected: %s", err.Error())
+		return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidClient, "client authentication failed")
+	}
+	if credentials.ClientID != "" && credentials.ClientID != assertion.ClientID {
+		return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidClient, "client_id does not match the client assertion")
+	}
+
+	// jti разных клиентов могут совпадать, поэтому храним его вместе с client_id
+	firstUse, err := s.authRepo.ConsumeTokenID(ctx, hashToken(assertion.ClientID+":"+assertion.ID), assertion.ExpiresAt)
+	if err != nil {
+		return bunEntities.OAuthClient{}, err
+	}
+	if !firstUse {
+		logrus.Warnf("oauth client %s reused a client assertion", assertion.ClientID)
+		return bunEntities.OAuthClient{}, newOAuthError(OAuthInvalidClient, "client assertion has already been used")
+	}
+	return client, nil
+}
+
+// resolveScope проверяет, что запрошенные scope входят в разрешенные, и возвращает их в каноническом виде.
+// Пустой запрос означает все разрешенные scope.
+func resolveScope(requested string, allowed []string) (string, bool) {
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(allowed, " "), true
+	}
+
+	var scopes []string
+	for _, scope := range strings.Fields(requested) {
+		if !slices.Contains(allowed, scope) {
+			return "", false
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, " "), true
+}
+
+// userScopes возвращает scope, которые можно выдать токенам пользователя с ролью role.
+// Scope для /admin получают только роли, у которых есть хотя бы одно право.
+func userScopes(ctx context.Context, repo repository.Authorization, role string) ([]string, error) {
+	administrative, err := isAdministrativeRole(ctx, repo, role)
+	if err != nil {
+		return nil, err
+	}
+	if administrative {
+		return entities.Scopes, nil
+	}
+	return slices.DeleteFunc(slices.Clone(entities.Scopes), func(scope string) bool {
+		return entities.HasAdminScope([]string{scope})
+	}), nil
+}
+
+// restrictScope оставляет в scope только разрешенные значения.
+func restrictScope(scope string, allowed []string) string {
+	scopes := slices.DeleteFunc(strings.Fields(scope), func(scope string) bool {
+		return !slices.Contains(allowed, scope)
+	})
+	return strings.Join(scopes, " ")
+}
+
+// verifyCodeChallenge проверяет code_verifier по методу S256 (RFC 7636, раздел 4.6).
+func verifyCodeChallenge(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// withQuery добавляет параметры к адресу перенаправления, пропуская пустые значения.
+func withQuery(rawURL string, params url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func oauthClientInfo(client bunEntities.OAuthClient) entities.OAuthClient {
+	return entities.OAuthClient{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       entities.NormalizeScopes(client.Scopes),
+		GrantTypes:   client.GrantTypes,
+		AuthMethod:   oauthClientAuthMethod(client),
+		Public:       client.ClientSecretHash == "" && client.PublicKey == "",
+		Role:         client.Role,
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+// oauthClientAuthMethod возвращает способ, которым клиент подтверждает личность на token endpoint
+func oauthClientAuthMethod(client bunEntities.OAuthClient) string {
+	switch {
+	case client.PublicKey != "":
+		return entities.OAuthAuthMethodPrivateKeyJWT
+	case client.ClientSecretHash != "":
+		return entities.OAuthAuthMethodClientSecret
+	default:
+		return entities.OAuthAuthMethodNone
+	}
+}
+
+// randomToken генерирует случайную строку из n байт в кодировке base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("can't generate token: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken возвращает SHA-256 высокоэнтропийного токена для хранения в базе.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
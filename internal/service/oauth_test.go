@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+func TestExchangeCodeRedirectURI(t *testing.T) {
+	setupTestKeyring(t)
+
+	const (
+		registered = "https://app.example.com/callback"
+		verifier   = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	client := bunEntities.OAuthClient{
+		ClientID:     "client",
+		Name:         "App",
+		RedirectURIs: []string{registered},
+		Scopes:       []string{entities.ScopeProfileRead},
+		GrantTypes:   []string{entities.OAuthGrantAuthorizationCode},
+	}
+
+	tests := []struct {
+		name              string
+		authorizeRedirect string // redirect_uri в запросе авторизации
+		tokenRedirect     string // redirect_uri в запросе к token endpoint
+		wantErr           bool
+	}{
+		{name: "supplied and repeated", authorizeRedirect: registered, tokenRedirect: registered},
+		{name: "supplied and omitted", authorizeRedirect: registered, tokenRedirect: "", wantErr: true},
+		{name: "supplied and different", authorizeRedirect: registered, tokenRedirect: "https://evil.example.com/", wantErr: true},
+		{name: "omitted and omitted", authorizeRedirect: "", tokenRedirect: ""},
+		{name: "omitted and registered", authorizeRedirect: "", tokenRedirect: registered},
+		{name: "omitted and different", authorizeRedirect: "", tokenRedirect: "https://evil.example.com/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			authRepo := newFakeAuthRepository()
+			authRepo.roles[1] = entities.RoleUser
+			s := NewOAuthService(newFakeOAuthRepository(client), authRepo, nil, nil, &config.OAuth{})
+
+			redirect, err := s.Authorize(ctx, 1, entities.OAuthAuthorizeRequest{
+				ResponseType:        "code",
+				ClientID:            client.ClientID,
+				RedirectURI:         tt.authorizeRedirect,
+				CodeChallenge:       challenge,
+				CodeChallengeMethod: "S256",
+			}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(redirect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code := u.Query().Get("code")
+			if code == "" {
+				t.Fatalf("no code in redirect %s", redirect)
+			}
+
+			_, _, _, err = s.exchangeCode(ctx, client, entities.OAuthTokenRequest{
+				GrantType:    entities.OAuthGrantAuthorizationCode,
+				Code:         code,
+				RedirectURI:  tt.tokenRedirect,
+				CodeVerifier: verifier,
+			}, entities.ClientInfo{})
+			var oauthErr *OAuthError
+			switch {
+			case tt.wantErr && (!errors.As(err, &oauthErr) || oauthErr.Code != OAuthInvalidGrant):
+				t.Errorf("got %v, want %s", err, OAuthInvalidGrant)
+			case !tt.wantErr && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthorizeDenied(t *testing.T) {
+	client := bunEntities.OAuthClient{
+		ClientID:     "client",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []string{entities.OAuthGrantAuthorizationCode},
+	}
+	repo := newFakeOAuthRepository(client)
+	s := NewOAuthService(repo, newFakeAuthRepository(), nil, nil, &config.OAuth{})
+
+	redirect, err := s.Authorize(context.Background(), 1, entities.OAuthAuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		State:               "xyz",
+		CodeChallenge:       strings.Repeat("a", 43),
+		CodeChallengeMethod: "S256",
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(redirect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Query().Get("error"); got != OAuthAccessDenied {
+		t.Errorf("error = %q, want %q", got, OAuthAccessDenied)
+	}
+	if got := u.Query().Get("state"); got != "xyz" {
+		t.Errorf("state = %q, want xyz", got)
+	}
+	if len(repo.codes) != 0 {
+		t.Errorf("%d codes issued after denial, want 0", len(repo.codes))
+	}
+}
+
+func TestServiceClientRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		wantRole string
+		wantErr  error
+	}{
+		{name: "registered role", role: "reporting", wantRole: "reporting"},
+		{name: "no role", role: "", wantRole: ""},
+		{name: "unknown role", role: "auditor", wantErr: ErrRoleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := NewOAuthService(newFakeOAuthRepository(), newFakeAuthRepository(), newFakeRolesRepository(entities.RoleAdmin, "reporting"), nil, &config.OAuth{})
+			input := entities.OAuthClientInput{
+				Name:       "Reports",
+				Scopes:     []string{entities.ScopeAdminRead},
+				GrantTypes: []string{entities.OAuthGrantClientCredentials},
+				Role:       tt.role,
+			}
+			if err := input.ValidateOAuthClientInput(); err != nil {
+				t.Fatal(err)
+			}
+
+			client, err := s.CreateClient(ctx, input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			role, err := s.ServiceClientRole(ctx, client.ClientID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role != tt.wantRole {
+				t.Errorf("role = %q, want %q", role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserScopes(t *testing.T) {
+	scopes := []string{entities.ScopeProfileRead, entities.ScopeAdminRead}
+
+	tests := []struct {
+		name         string
+		clientScopes []string // По умолчанию scopes
+		role         string
+		scope        string // scope в запросе авторизации
+		wantScope    string
+		wantError    string // error в адресе перенаправления
+	}{
+		{name: "default scope narrowed for regular user", role: entities.RoleUser, wantScope: "profile:read"},
+		{name: "default scope for administrator", role: entities.RoleAdmin, wantScope: "profile:read admin:read"},
+		{name: "admin scope requested by regular user", role: entities.RoleUser, scope: "profile:read admin:read", wantError: OAuthInvalidScope},
+		{name: "admin scope requested by administrator", role: entities.RoleAdmin, scope: "admin:read", wantScope: "admin:read"},
+		{name: "legacy client scopes", clientScopes: []string{"openid", "email", "sessions:read", "offline_access"}, role: entities.RoleUser, wantScope: "profile:read sessions:read"},
+		{name: "legacy client without known scopes", clientScopes: []string{"offline_access"}, role: entities.RoleUser, wantScope: "profile:read"},
+		{name: "legacy scope requested explicitly", clientScopes: []string{"openid"}, role: entities.RoleUser, scope: "openid", wantError: OAuthInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := bunEntities.OAuthClient{
+				ClientID:     "client",
+				RedirectURIs: []string{"https://app.example.com/callback"},
+				Scopes:       scopes,
+				GrantTypes:   []string{entities.OAuthGrantAuthorizationCode},
+			}
+			if tt.clientScopes != nil {
+				client.Scopes = tt.clientScopes
+			}
+			authRepo := newFakeAuthRepository()
+			authRepo.roles[1] = tt.role
+			authRepo.rolePermissions[entities.RoleAdmin] = []string{entities.PermissionUsersRead}
+			repo := newFakeOAuthRepository(client)
+			s := NewOAuthService(repo, authRepo, nil, nil, &config.OAuth{})
+
+			redirect, err := s.Authorize(context.Background(), 1, entities.OAuthAuthorizeRequest{
+				ResponseType:        "code",
+				ClientID:            client.ClientID,
+				Scope:               tt.scope,
+				CodeChallenge:       strings.Repeat("a", 43),
+				CodeChallengeMethod: "S256",
+			}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(redirect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := u.Query().Get("error"); got != tt.wantError {
+				t.Fatalf("error = %q, want %q", got, tt.wantError)
+			}
+			if tt.wantError != "" {
+				if len(repo.codes) != 0 {
+					t.Errorf("%d codes issued, want 0", len(repo.codes))
+				}
+				return
+			}
+			code, ok := repo.codes[hashToken(u.Query().Get("code"))]
+			if !ok || code.Scope != tt.wantScope {
+				t.Errorf("code scope = %q, want %q", code.Scope, tt.wantScope)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/oidc"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultOIDCStateTTL      = 10 * time.Minute     // Время на вход у провайдера, если не задано в конфигурации
+	defaultOIDCUsernameClaim = "preferred_username" // Claim с именем пользователя по умолчанию
+	oidcRequestTimeout       = 10 * time.Second     // Таймаут запросов к провайдеру
+)
+
+var (
+	ErrOIDCProviderNotFound = errors.New("identity provider not found")
+	ErrInvalidOIDCState     = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed      = errors.New("identity provider login failed")
+	ErrOIDCAccountNotLinked = errors.New("no account is linked to this identity")
+	ErrOIDCAccountConflict  = errors.New("user with this username already exists")
+	ErrOIDCIdentityInUse    = errors.New("this identity is already linked to another account")
+)
+
+type OIDCService struct {
+	repo      repository.Authorization
+	cfg       *config.OIDC
+	providers map[string]*oidc.Provider
+}
+
+func NewOIDCService(repo repository.Authorization, cfg *config.OIDC) *OIDCService {
+	client := &http.Client{Timeout: oidcRequestTimeout}
+
+	providers := make(map[string]*oidc.Provider, len(cfg.Providers))
+	for name, provider := range cfg.Providers {
+		scopes := provider.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "profile", "email"}
+		}
+		providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       scopes,
+		}, client)
+	}
+
+	return &OIDCService{repo: repo, cfg: cfg, providers: providers}
+}
+
+// Login начинает вход через провайдера: сохраняет state, nonce и PKCE verifier
+// и возвращает адрес провайдера вместе со state, который нужно привязать к браузеру.
+func (s *OIDCService) Login(ctx context.Context, providerName string) (string, string, error) {
+	return s.startLogin(ctx, providerName, nil)
+}
+
+// Link начинает вход через провайдера от имени вошедшего пользователя userID: после возврата
+// учетная запись провайдера будет привязана к нему. Возвращает то же, что Login.
+func (s *OIDCService) Link(ctx context.Context, providerName string, userID int) (string, string, error) {
+	return s.startLogin(ctx, providerName, &userID)
+}
+
+func (s *OIDCService) startLogin(ctx context.Context, providerName string, linkUserID *int) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrOIDCProviderNotFound
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	stateTTL := s.cfg.StateTTL
+	if stateTTL <= 0 {
+		stateTTL = defaultOIDCStateTTL
+	}
+	if err := s.repo.CreateOIDCLoginState(ctx, bunEntities.OIDCLoginState{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(stateTTL),
+	}); err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		logrus.Errorf("oidc provider %s: %s", providerName, err.Error())
+		return "", "", ErrOIDCLoginFailed
+	}
+	return authURL, state, nil
+}
+
+// Callback завершает вход через провайдера: обменивает код на ID token, проверяет его,
+// привязывает учетную запись провайдера (если вход начат через Link), находит или создает
+// пользователя и выдает собственные токены сервиса.
+func (s *OIDCService) Callback(ctx context.Context, providerName string, state string, code string, client entities.ClientInfo) (entities.SignInResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return entities.SignInResult{}, ErrOIDCProviderNotFound
+	}
+
+	loginState, err := s.repo.ConsumeOIDCLoginState(ctx, hashToken(state))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.SignInResult{}, ErrInvalidOIDCState
+		}
+		return entities.SignInResult{}, err
+	}
+	if loginState.Provider != providerName || loginState.ExpiresAt.Before(time.Now()) {
+		return entities.SignInResult{}, ErrInvalidOIDCState
+	}
+
+	idToken, err := provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		logrus.Errorf("oidc provider %s: %s", providerName, err.Error())
+		return entities.SignInResult{}, ErrOIDCLoginFailed
+	}
+	claims, err := provider.VerifyIDToken(ctx, idToken, loginState.Nonce)
+	if err != nil {
+		logrus.Errorf("oidc provider %s: %s", providerName, err.Error())
+		return entities.SignInResult{}, ErrOIDCLoginFailed
+	}
+
+	var userID int
+	var role string
+	if loginState.LinkUserID != nil {
+		userID, role, err = s.linkUser(ctx, providerName, claims, *loginState.LinkUserID)
+	} else {
+		userID, role, err = s.resolveUser(ctx, providerName, claims)
+	}
+	if err != nil {
+		return entities.SignInResult{}, err
+	}
+
+	return completeSignIn(ctx, s.repo, userID, role, "", client)
+}
+
+// linkUser привязывает учетную запись провайдера к пользователю, который начал вход через Link.
+func (s *OIDCService) linkUser(ctx context.Context, providerName string, claims *oidc.Claims, userID int) (int, string, error) {
+	identity, err := s.repo.GetUserIdentity(ctx, providerName, claims.Subject)
+	switch {
+	case err == nil && identity.UserID != userID:
+		return 0, "", ErrOIDCIdentityInUse
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return 0, "", err
+	case err != nil:
+		if err := s.repo.CreateUserIdentity(ctx, bunEntities.UserIdentity{
+			UserID:   userID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+		}); err != nil {
+			return 0, "", err
+		}
+		logrus.Infof("oidc provider %s: identity %s linked to user %d at their request", providerName, claims.Subject, userID)
+	}
+
+	role, err := s.repo.GetRole(ctx, userID)
+	if err != nil {
+		return 0, "", err
+	}
+	return userID, role, nil
+}
+
+// resolveUser находит пользователя, привязанного к внешней учетной записи. Если привязки нет,
+// в зависимости от настроек провайдера привязывает пользователя с тем же подтвержденным email или создает нового.
+func (s *OIDCService) resolveUser(ctx context.Context, providerName string, claims *oidc.Claims) (int, string, error) {
+	identity, err := s.repo.GetUserIdentity(ctx, providerName, claims.Subject)
+	if err == nil {
+		role, err := s.repo.GetRole(ctx, identity.UserID)
+		if err != nil {
+			return 0, "", err
+		}
+		return identity.UserID, role, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, "", err
+	}
+
+	providerCfg := s.cfg.Providers[providerName]
+	newIdentity := bunEntities.UserIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+	}
+
+	if providerCfg.LinkExisting {
+		userID, role, linked, err := s.linkByEmail(ctx, providerName, claims, newIdentity)
+		if err != nil || linked {
+			return userID, role, err
+		}
+	}
+	if !providerCfg.CreateUsers {
+		return 0, "", ErrOIDCAccountNotLinked
+	}
+
+	usernameClaim := providerCfg.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = defaultOIDCUsernameClaim
+	}
+	username := claims.String(usernameClaim)
+	if username == "" {
+		logrus.Errorf("oidc provider %s: id token has no %s claim", providerName, usernameClaim)
+		return 0, "", ErrOIDCLoginFailed
+	}
+	if _, err := s.repo.GetUser(ctx, username); err == nil {
+		return 0, "", ErrOIDCAccountConflict
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return 0, "", err
+	}
+
+	name := claims.String("name")
+	if name == "" {
+		name = username
+	}
+	role, err := s.repo.GetDefaultRole(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	userID, err := s.repo.CreateFederatedUser(ctx, bunEntities.User{
+		Role:         role,
+		Name:         name,
+		Username:     username,
+		PasswordHash: "", // Вход по паролю невозможен, пока пользователь его не задаст
+		City:         "",
+	}, newIdentity)
+	if err != nil {
+		return 0, "", err
+	}
+	logrus.Infof("oidc provider %s: user %d created for identity %s", providerName, userID, claims.Subject)
+	return userID, role, nil
+}
+
+// linkByEmail привязывает учетную запись провайдера к пользователю с тем же email, если адрес подтвержден
+// и провайдером (email_verified), и у нас. Username из токена не проверяется провайдером и для привязки
+// не подходит. Пользователей, чья роль дает права, автоматически не привязываем: им нужен Link.
+func (s *OIDCService) linkByEmail(ctx context.Context, providerName string, claims *oidc.Claims, identity bunEntities.UserIdentity) (int, string, bool, error) {
+	email := claims.String("email")
+	if email == "" || !claims.Bool("email_verified") {
+		return 0, "", false, nil
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", false, nil
+		}
+		return 0, "", false, err
+	}
+	if user.EmailVerifiedAt == nil {
+		return 0, "", false, nil
+	}
+
+	permissions, err := s.repo.GetRolePermissions(ctx, user.Role)
+	if err != nil {
+		return 0, "", false, err
+	}
+	if len(permissions) > 0 {
+		logrus.Warnf("oidc provider %s: identity %s not linked to user %d with privileged role %s", providerName, claims.Subject, user.ID, user.Role)
+		return 0, "", false, ErrOIDCAccountNotLinked
+	}
+
+	identity.UserID = user.ID
+	if err := s.repo.CreateUserIdentity(ctx, identity); err != nil {
+		return 0, "", false, err
+	}
+	logrus.Infof("oidc provider %s: identity %s linked to user %d by verified email", providerName, claims.Subject, user.ID)
+	return user.ID, user.Role, true, nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+)
+
+const (
+	testOIDCProvider = "corp"
+	testOIDCClientID = "users-api"
+	testOIDCSubject  = "idp-user-1"
+)
+
+// mockOIDCProvider — провайдер OpenID Connect с discovery, JWKS и token endpoint.
+// Token endpoint выдает ID token с claims, заданными тестом, и nonce из последнего входа.
+type mockOIDCProvider struct {
+	*httptest.Server
+	key ed25519.PrivateKey
+
+	mu     sync.Mutex
+	nonce  string
+	claims jwt.MapClaims
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mockOIDCProvider{key: privateKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"kid": "mock",
+				"use": "sig",
+				"x":   base64.RawURLEncoding.EncodeToString(publicKey),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		claims := jwt.MapClaims{
+			"iss":   p.URL,
+			"aud":   testOIDCClientID,
+			"sub":   testOIDCSubject,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": p.nonce,
+		}
+		for name, value := range p.claims {
+			claims[name] = value
+		}
+		p.mu.Unlock()
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = "mock"
+		idToken, err := token.SignedString(p.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"id_token": idToken, "token_type": "Bearer"})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// signIn проходит вход у провайдера: запоминает nonce из адреса входа и возвращает state
+func (p *mockOIDCProvider) signIn(t *testing.T, authURL string, claims jwt.MapClaims) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.nonce = u.Query().Get("nonce")
+	p.claims = claims
+	p.mu.Unlock()
+	return u.Query().Get("state")
+}
+
+func TestOIDCCallbackLinking(t *testing.T) {
+	setupTestKeyring(t)
+	provider := newMockOIDCProvider(t)
+
+	email := func(address string) *string { return &address }
+	verifiedAt := time.Now()
+	users := []bunEntities.User{
+		{ID: 1, Role: entities.RoleAdmin, Username: "admin", Email: email("admin@example.com"), EmailVerifiedAt: &verifiedAt},
+		{ID: 2, Role: entities.RoleUser, Username: "alice", Email: email("alice@example.com"), EmailVerifiedAt: &verifiedAt},
+		{ID: 3, Role: entities.RoleUser, Username: "bob", Email: email("bob@example.com")},
+	}
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		linkUser int   // Вход начат через Link этим пользователем
+		linked   []int // Пользователи, к которым уже привязана учетная запись провайдера
+		wantUser int
+		wantErr  error
+	}{
+		{
+			name:     "verified email links regular user",
+			claims:   jwt.MapClaims{"email": "Alice@example.com", "email_verified": true},
+			wantUser: 2,
+		},
+		{
+			name:     "email_verified as string",
+			claims:   jwt.MapClaims{"email": "alice@example.com", "email_verified": "true"},
+			wantUser: 2,
+		},
+		{
+			name:    "unverified email is not linked",
+			claims:  jwt.MapClaims{"email": "alice@example.com", "email_verified": false},
+			wantErr: ErrOIDCAccountNotLinked,
+		},
+		{
+			name:    "username claim alone is not linked",
+			claims:  jwt.MapClaims{"preferred_username": "admin"},
+			wantErr: ErrOIDCAccountNotLinked,
+		},
+		{
+			name:    "privileged role is never linked automatically",
+			claims:  jwt.MapClaims{"email": "admin@example.com", "email_verified": true},
+			wantErr: ErrOIDCAccountNotLinked,
+		},
+		{
+			name:    "local email not verified",
+			claims:  jwt.MapClaims{"email": "bob@example.com", "email_verified": true},
+			wantErr: ErrOIDCAccountNotLinked,
+		},
+		{
+			name:     "explicit link by privileged user",
+			claims:   jwt.MapClaims{"preferred_username": "someone"},
+			linkUser: 1,
+			wantUser: 1,
+		},
+		{
+			name:     "explicit link of identity owned by another user",
+			claims:   jwt.MapClaims{},
+			linkUser: 1,
+			linked:   []int{2},
+			wantErr:  ErrOIDCIdentityInUse,
+		},
+		{
+			name:     "existing link signs in",
+			claims:   jwt.MapClaims{"preferred_username": "admin"},
+			linked:   []int{3},
+			wantUser: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			for _, user := range users {
+				repo.addUser(user)
+			}
+			repo.rolePermissions[entities.RoleAdmin] = []string{entities.PermissionUsersWrite}
+			for _, userID := range tt.linked {
+				repo.identities = append(repo.identities, bunEntities.UserIdentity{UserID: userID, Provider: testOIDCProvider, Subject: testOIDCSubject})
+			}
+			s := NewOIDCService(repo, &config.OIDC{
+				Providers: map[string]config.OIDCProvider{
+					testOIDCProvider: {
+						Issuer:       provider.URL,
+						ClientID:     testOIDCClientID,
+						RedirectURL:  "http://localhost/auth/oidc/corp/callback",
+						LinkExisting: true,
+					},
+				},
+			})
+
+			var authURL string
+			var err error
+			if tt.linkUser != 0 {
+				authURL, _, err = s.Link(ctx, testOIDCProvider, tt.linkUser)
+			} else {
+				authURL, _, err = s.Login(ctx, testOIDCProvider)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			state := provider.signIn(t, authURL, tt.claims)
+
+			result, err := s.Callback(ctx, testOIDCProvider, state, "code", entities.ClientInfo{})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.identities) != len(tt.linked) {
+					t.Errorf("identities = %+v, want no new links", repo.identities)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.AccessToken == "" {
+				t.Error("no access token issued")
+			}
+			identity, err := repo.GetUserIdentity(ctx, testOIDCProvider, testOIDCSubject)
+			if err != nil || identity.UserID != tt.wantUser {
+				t.Errorf("identity linked to %d (%v), want %d", identity.UserID, err, tt.wantUser)
+			}
+		})
+	}
+}
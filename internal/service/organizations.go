@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrOrganizationExists   = errors.New("organization with this slug already exists")
+	ErrMemberNotFound       = errors.New("user is not a member of this organization")
+	ErrMemberExists         = errors.New("user is already a member of this organization")
+)
+
+type OrganizationsService struct {
+	repo      repository.Organizations
+	authRepo  repository.Authorization
+	rolesRepo repository.Roles
+}
+
+func NewOrganizationsService(repo repository.Organizations, authRepo repository.Authorization, rolesRepo repository.Roles) *OrganizationsService {
+	return &OrganizationsService{repo: repo, authRepo: authRepo, rolesRepo: rolesRepo}
+}
+
+// WithOrganization ограничивает запросы к пользователям с этим ctx участниками организации.
+func WithOrganization(ctx context.Context, organizationID int) context.Context {
+	return repository.WithOrganization(ctx, organizationID)
+}
+
+// GetOrganizations возвращает все организации.
+func (s *OrganizationsService) GetOrganizations(ctx context.Context) ([]entities.Organization, error) {
+	organizations, err := s.repo.GetOrganizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.Organization, 0, len(organizations))
+	for _, organization := range organizations {
+		result = append(result, organizationInfo(organization))
+	}
+	return result, nil
+}
+
+// GetOrganization возвращает организацию по ID.
+func (s *OrganizationsService) GetOrganization(ctx context.Context, id int) (entities.Organization, error) {
+	organization, err := s.repo.GetOrganization(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.Organization{}, ErrOrganizationNotFound
+		}
+		return entities.Organization{}, err
+	}
+	return organizationInfo(organization), nil
+}
+
+// CreateOrganization создает организацию без участников.
+func (s *OrganizationsService) CreateOrganization(ctx context.Context, input entities.OrganizationInput) (entities.Organization, error) {
+	if _, err := s.repo.GetOrganizationBySlug(ctx, input.Slug); err == nil {
+		return entities.Organization{}, ErrOrganizationExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return entities.Organization{}, err
+	}
+
+	organization := bunEntities.Organization{
+		Name:      input.Name,
+		Slug:      input.Slug,
+		CreatedAt: time.Now(),
+	}
+	id, err := s.repo.CreateOrganization(ctx, organization)
+	if err != nil {
+		return entities.Organization{}, err
+	}
+	organization.ID = id
+	return organizationInfo(organization), nil
+}
+
+// DeleteOrganization удаляет организацию. Учетные записи участников остаются.
+func (s *OrganizationsService) DeleteOrganization(ctx context.Context, id int) error {
+	deleted, err := s.repo.DeleteOrganization(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+// GetMembers возвращает участников организации с их ролями в ней.
+func (s *OrganizationsService) GetMembers(ctx context.Context, organizationID int) ([]entities.Member, error) {
+	if _, err := s.GetOrganization(ctx, organizationID); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetMembers(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.Member, 0, len(members))
+	for _, member := range members {
+		result = append(result, memberInfo(member))
+	}
+	return result, nil
+}
+
+// AddMember добавляет существующего пользователя в организацию. Без роли участник получает роль по умолчанию.
+func (s *OrganizationsService) AddMember(ctx context.Context, organizationID int, input entities.MemberInput) error {
+	if _, err := s.GetOrganization(ctx, organizationID); err != nil {
+		return err
+	}
+	if _, err := s.authRepo.GetUserProfile(ctx, input.UserID); err != nil {
+		return userNotFound(err)
+	}
+
+	role := input.Role
+	if role == "" {
+		defaultRole, err := s.authRepo.GetDefaultRole(ctx)
+		if err != nil {
+			return err
+		}
+		role = defaultRole
+	} else if err := roleExists(ctx, s.rolesRepo, role); err != nil {
+		return err
+	}
+
+	added, err := s.repo.AddMember(ctx, bunEntities.OrganizationMember{
+		OrganizationID: organizationID,
+		UserID:         input.UserID,
+		Role:           role,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrMemberExists
+	}
+	return nil
+}
+
+// UpdateMemberRole меняет роль участника в организации.
+func (s *OrganizationsService) UpdateMemberRole(ctx context.Context, organizationID int, userID int, input entities.MemberUpdateInput) error {
+	if err := roleExists(ctx, s.rolesRepo, input.Role); err != nil {
+		return err
+	}
+	updated, err := s.repo.UpdateMemberRole(ctx, organizationID, userID, input.Role)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// RemoveMember исключает пользователя из организации. Учетная запись пользователя остается.
+func (s *OrganizationsService) RemoveMember(ctx context.Context, organizationID int, userID int) error {
+	removed, err := s.repo.RemoveMember(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// GetUserOrganizations возвращает организации пользователя; activeID — организация из текущего access token.
+func (s *OrganizationsService) GetUserOrganizations(ctx context.Context, userID int, activeID int) ([]entities.UserOrganization, error) {
+	members, err := s.repo.GetUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.UserOrganization, 0, len(members))
+	for _, member := range members {
+		if member.Organization == nil {
+			continue
+		}
+		result = append(result, entities.UserOrganization{
+			Organization: organizationInfo(*member.Organization),
+			Role:         member.Role,
+			Active:       member.OrganizationID == activeID,
+		})
+	}
+	return result, nil
+}
+
+// GetMembershipRole возвращает роль пользователя в организации.
+func (s *OrganizationsService) GetMembershipRole(ctx context.Context, organizationID int, userID int) (string, error) {
+	role, err := s.authRepo.GetMembershipRole(ctx, organizationID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrMemberNotFound
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+// SwitchOrganization делает активной другую организацию пользователя: refresh token ротируется
+// как при Refresh, а новый access token выдается с выбранной организацией.
+func (s *AuthorizationService) SwitchOrganization(ctx context.Context, refreshToken string, organizationID int, client entities.ClientInfo) (string, string, error) {
+	if !isRefreshToken(refreshToken) {
+		return "", "", ErrInvalidRefreshToken
+	}
+	session, err := s.repo.GetSession(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrInvalidRefreshToken
+		}
+		return "", "", err
+	}
+	if session.ClientID != "" {
+		return "", "", ErrInvalidRefreshToken // Сессии OAuth-клиентов обновляются только через /oauth/token
+	}
+
+	if _, err := s.repo.GetMembershipRole(ctx, organizationID, session.UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrMemberNotFound
+		}
+		return "", "", err
+	}
+
+	session.OrganizationID = &organizationID
+	return rotateSession(ctx, s.repo, session, session.Scope, client)
+}
+
+// activeOrganization возвращает организацию для нового access token: выбранную ранее, если пользователь
+// все еще в ней состоит, иначе первую из его организаций.
+func activeOrganization(ctx context.Context, repo repository.Authorization, userID int, preferred *int) (*int, error) {
+	if preferred != nil {
+		if _, err := repo.GetMembershipRole(ctx, *preferred, userID); err == nil {
+			return preferred, nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+	return repo.GetFirstOrganization(ctx, userID)
+}
+
+func organizationInfo(organization bunEntities.Organization) entities.Organization {
+	return entities.Organization{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		Slug:      organization.Slug,
+		CreatedAt: organization.CreatedAt,
+	}
+}
+
+func memberInfo(member bunEntities.OrganizationMember) entities.Member {
+	info := entities.Member{
+		UserID:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	}
+	if member.User != nil {
+		info.Username = member.User.Username
+		info.Name = member.User.Name
+	}
+	return info
+}
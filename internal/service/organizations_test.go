@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+// newTenantRepository создает две организации: в первой alice (1) и администратор организации carol (3),
+// во второй bob (2). Администратор admin (10) в организациях не состоит
+func newTenantRepository() *fakeAuthRepository {
+	repo := newFakeAuthRepository()
+	repo.addUser(bunEntities.User{ID: 10, Role: entities.RoleAdmin, Username: "admin"})
+	repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice"})
+	repo.addUser(bunEntities.User{ID: 2, Role: entities.RoleUser, Username: "bob"})
+	repo.addUser(bunEntities.User{ID: 3, Role: entities.RoleUser, Username: "carol"})
+	repo.rolePermissions[entities.RoleAdmin] = []string{entities.PermissionUsersImpersonate}
+	repo.rolePermissions["org-admin"] = []string{entities.PermissionUsersWrite}
+	repo.addMember(1, 1, entities.RoleUser)
+	repo.addMember(1, 3, "org-admin")
+	repo.addMember(2, 2, entities.RoleUser)
+	return repo
+}
+
+func TestImpersonateTenantIsolation(t *testing.T) {
+	setupTestKeyring(t)
+
+	tests := []struct {
+		name             string
+		activeOrg        int // Активная организация администратора; 0 — не выбрана
+		userID           int
+		wantOrganization int
+		wantErr          error
+	}{
+		{name: "member of active organization", activeOrg: 1, userID: 1, wantOrganization: 1},
+		{name: "member of another organization", activeOrg: 1, userID: 2, wantErr: ErrImpersonationUserNotFound},
+		{name: "no active organization", activeOrg: 0, userID: 2, wantOrganization: 2},
+		{name: "administrator of the organization", activeOrg: 1, userID: 3, wantErr: ErrImpersonateAdmin},
+		{name: "administrator of the organization without active organization", activeOrg: 0, userID: 3, wantErr: ErrImpersonateAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.activeOrg != 0 {
+				ctx = WithOrganization(ctx, tt.activeOrg)
+			}
+			repo := newTenantRepository()
+			s := NewImpersonationService(repo, &config.Impersonation{})
+
+			token, err := s.Impersonate(ctx, 10, tt.userID, entities.ImpersonationInput{Reason: "support"}, "192.0.2.1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.securityEvents) != 0 {
+					t.Errorf("security events = %+v, want none", repo.securityEvents)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := auth.ParseAccessToken(token.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Organization != tt.wantOrganization {
+				t.Errorf("org claim = %d, want %d", claims.Organization, tt.wantOrganization)
+			}
+			if actorID, ok := claims.ActorID(); !ok || actorID != 10 {
+				t.Errorf("act = %v, want admin 10", claims.Act)
+			}
+		})
+	}
+}
+
+func TestSwitchOrganizationTenantIsolation(t *testing.T) {
+	setupTestKeyring(t)
+
+	tests := []struct {
+		name         string
+		userID       int
+		organization int
+		wantErr      error
+	}{
+		{name: "own organization", userID: 1, organization: 1},
+		{name: "another organization", userID: 1, organization: 2, wantErr: ErrMemberNotFound},
+		{name: "unknown organization", userID: 2, organization: 3, wantErr: ErrMemberNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newTenantRepository()
+			s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+			client := entities.ClientInfo{IP: "192.0.2.1"}
+
+			_, refreshToken, err := issueTokens(ctx, repo, tt.userID, entities.RoleUser, "", "", client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			accessToken, _, err := s.SwitchOrganization(ctx, refreshToken, tt.organization, client)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := auth.ParseAccessToken(accessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Organization != tt.organization {
+				t.Errorf("org claim = %d, want %d", claims.Organization, tt.organization)
+			}
+		})
+	}
+}
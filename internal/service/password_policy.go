@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPasswordMinLength = 8   // Минимальная длина пароля, если не задана в конфигурации
+	defaultPasswordMaxLength = 128 // Максимальная длина пароля, если не задана в конфигурации
+)
+
+// PasswordPolicy проверяет новые пароли по правилам из конфигурации, списку утекших паролей
+// и истории паролей пользователя.
+type PasswordPolicy struct {
+	rules       entities.PasswordPolicy
+	historySize int
+}
+
+// NewPasswordPolicy создает парольную политику и загружает список утекших паролей.
+func NewPasswordPolicy(cfg *config.PasswordPolicy) (*PasswordPolicy, error) {
+	rules := entities.PasswordPolicy{
+		MinLength:     cfg.MinLength,
+		MaxLength:     cfg.MaxLength,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+	}
+	if rules.MinLength <= 0 {
+		rules.MinLength = defaultPasswordMinLength
+	}
+	if rules.MaxLength <= 0 {
+		rules.MaxLength = defaultPasswordMaxLength
+	}
+
+	if cfg.BreachedListFile != "" {
+		breached, err := loadBreachedPasswords(cfg.BreachedListFile)
+		if err != nil {
+			return nil, err
+		}
+		rules.Breached = breached
+		logrus.Infof("%d breached passwords loaded from %s", len(breached), cfg.BreachedListFile)
+	}
+
+	return &PasswordPolicy{rules: rules, historySize: cfg.HistorySize}, nil
+}
+
+// loadBreachedPasswords читает файл с паролями по одному в строке; пустые строки и строки с # пропускаются.
+func loadBreachedPasswords(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("can't open breached password list: " + err.Error())
+	}
+	defer file.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("can't read breached password list: " + err.Error())
+	}
+	return breached, nil
+}
+
+// check проверяет новый пароль. Для существующего пользователя (userID > 0) пароль также
+// сравнивается с его последними паролями.
+func (p *PasswordPolicy) check(ctx context.Context, repo repository.Authorization, userID int, password string, info ...string) error {
+	if err := p.rules.Check(password, info...); err != nil {
+		return err
+	}
+	if userID == 0 || p.historySize <= 0 {
+		return nil
+	}
+
+	hashes, err := repo.GetPasswordHistory(ctx, userID, p.historySize)
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		match, _, err := auth.ComparePasswordHash(password, hash)
+		if err != nil {
+			logrus.Errorf("can't compare password history of user %d: %s", userID, err.Error())
+			continue
+		}
+		if match {
+			return entities.PasswordHistoryError(p.historySize)
+		}
+	}
+	return nil
+}
+
+// remember сохраняет хэш нового пароля в истории. Ошибка не отменяет смену пароля, поэтому только логируется.
+func (p *PasswordPolicy) remember(ctx context.Context, repo repository.Authorization, userID int, passwordHash string) {
+	if p.historySize <= 0 {
+		return
+	}
+	if err := repo.AddPasswordHistory(ctx, userID, passwordHash, p.historySize); err != nil {
+		logrus.Errorf("can't save password history of user %d: %s", userID, err.Error())
+	}
+}
+
+// personalInfo собирает данные пользователя, которые нельзя использовать в пароле.
+func personalInfo(username string, name string, city string, email string) []string {
+	info := []string{username, name, city, email}
+	if local, _, ok := strings.Cut(email, "@"); ok {
+		info = append(info, local)
+	}
+	return info
+}
+
+// stringValue возвращает значение необязательной строки или пустую строку.
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+func TestPasswordPolicyHistory(t *testing.T) {
+	const historySize = 2
+
+	tests := []struct {
+		name     string
+		previous []string // Пароли пользователя по порядку смены, текущий последним
+		password string
+		userID   int
+		wantErr  bool
+	}{
+		{name: "new password", previous: []string{"Passw0rd-one", "Passw0rd-two"}, password: "Passw0rd-three", userID: 1},
+		{name: "current password", previous: []string{"Passw0rd-one", "Passw0rd-two"}, password: "Passw0rd-two", userID: 1, wantErr: true},
+		{name: "previous password", previous: []string{"Passw0rd-one", "Passw0rd-two"}, password: "Passw0rd-one", userID: 1, wantErr: true},
+		{name: "older than history", previous: []string{"Passw0rd-one", "Passw0rd-two", "Passw0rd-three"}, password: "Passw0rd-one", userID: 1},
+		{name: "new user", previous: []string{"Passw0rd-one"}, password: "Passw0rd-one", userID: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			policy, err := NewPasswordPolicy(&config.PasswordPolicy{HistorySize: historySize})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, password := range tt.previous {
+				hash, err := auth.GeneratePasswordHash(password)
+				if err != nil {
+					t.Fatal(err)
+				}
+				policy.remember(ctx, repo, 1, hash)
+			}
+			if len(repo.passwordHistory[1]) > historySize {
+				t.Fatalf("history keeps %d hashes, want at most %d", len(repo.passwordHistory[1]), historySize)
+			}
+
+			err = policy.check(ctx, repo, tt.userID, tt.password)
+			var policyErr *entities.PasswordPolicyError
+			if tt.wantErr != (errors.As(err, &policyErr) && policyErr.Rule == entities.PasswordRuleHistory) {
+				t.Errorf("got %v, want history error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyDisabledHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeAuthRepository()
+	policy, err := NewPasswordPolicy(&config.PasswordPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy.remember(ctx, repo, 1, "hash")
+	if len(repo.passwordHistory) != 0 {
+		t.Errorf("history = %v, want nothing saved", repo.passwordHistory)
+	}
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(list, []byte("# top passwords\n\nQwerty-123\n  letmein-now  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPasswordPolicy(&config.PasswordPolicy{BreachedListFile: list})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		password string
+		wantRule string
+	}{
+		{password: "short", wantRule: entities.PasswordRuleMinLength}, // Длина по умолчанию
+		{password: "qwerty-123", wantRule: entities.PasswordRuleBreached},
+		{password: "LETMEIN-NOW", wantRule: entities.PasswordRuleBreached},
+		{password: "# top passwords"},
+		{password: "correct horse battery staple"},
+	}
+	for _, tt := range tests {
+		err := policy.check(context.Background(), nil, 0, tt.password)
+		var policyErr *entities.PasswordPolicyError
+		if tt.wantRule == "" && err != nil || tt.wantRule != "" && (!errors.As(err, &policyErr) || policyErr.Rule != tt.wantRule) {
+			t.Errorf("%q: got %v, want rule %q", tt.password, err, tt.wantRule)
+		}
+	}
+
+	if _, err := NewPasswordPolicy(&config.PasswordPolicy{BreachedListFile: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Error("missing breached list accepted")
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPasswordResetTTL = time.Hour // Время жизни ссылки, если не задано в конфигурации
+
+	PurposePasswordReset = "password_reset" // Назначение одноразового токена сброса пароля
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// ForgotPassword отправляет ссылку для сброса пароля, если адрес принадлежит пользователю.
+// Ответ не зависит от наличия адреса в базе.
+func (s *AuthorizationService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	ttl := s.cfg.PasswordReset.TokenTTL
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTTL
+	}
+	if err := s.repo.CreateOneTimeToken(ctx, bunEntities.OneTimeToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		Purpose:   PurposePasswordReset,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	link, err := withQuery(s.cfg.PasswordReset.URL, url.Values{"token": {token}})
+	if err != nil {
+		return errors.New("invalid password reset url: " + err.Error())
+	}
+	sendMailAsync(s.mailer, mailer.Message{
+		To:      *user.Email,
+		Subject: "Password reset",
+		Body: "Hello, " + user.Name + "!\n\n" +
+			"To set a new password for " + user.Username + ", open the link below:\n\n" +
+			link + "\n\n" +
+			"The link is valid for " + ttl.String() + " and can be used once.\n" +
+			"If you did not request a password reset, ignore this email.\n",
+	})
+	return nil
+}
+
+// ResetPassword задает новый пароль по токену из письма и завершает все сессии пользователя.
+// Пароль проверяется по парольной политике до использования токена, чтобы отклоненный пароль не тратил ссылку.
+func (s *AuthorizationService) ResetPassword(ctx context.Context, input entities.ResetPasswordInput) error {
+	tokenHash := hashToken(input.Token)
+	token, err := s.repo.GetOneTimeToken(ctx, tokenHash, PurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if token.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.repo.GetUserProfile(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.passwords.check(ctx, s.repo, user.ID, input.Password,
+		personalInfo(user.Username, user.Name, user.City, stringValue(user.Email))...); err != nil {
+		return err
+	}
+
+	// Токен мог быть использован параллельным запросом, поэтому повторно получаем его с удалением
+	if _, err := s.repo.ConsumeOneTimeToken(ctx, tokenHash, PurposePasswordReset); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	passwordHash, err := auth.GeneratePasswordHash(input.Password)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.ResetPassword(ctx, token.UserID, passwordHash, PurposePasswordReset); err != nil {
+		return err
+	}
+	s.passwords.remember(ctx, s.repo, token.UserID, passwordHash)
+
+	// Новый пароль снимает блокировку входа по имени пользователя
+	if username, err := s.repo.GetUsername(ctx, token.UserID); err == nil {
+		if err := s.repo.ResetLoginAttempts(ctx, usernameThrottleKey(username)); err != nil {
+			logrus.Errorf("can't reset login attempts for user %d: %s", token.UserID, err.Error())
+		}
+	}
+	return nil
+}
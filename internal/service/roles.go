@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+)
+
+var (
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleExists           = errors.New("role with this name already exists")
+	ErrRoleInUse            = errors.New("role is assigned to users or service clients")
+	ErrBuiltinRole          = errors.New("built-in roles can't be deleted and the admin role's permissions can't be changed")
+	ErrDefaultRole          = errors.New("the default role can't be deleted; make another role the default first")
+	ErrRoleAssignmentDenied = errors.New("changing the role requires the " + entities.PermissionRolesAssign + " permission")
+)
+
+// UnknownPermissionError возвращается, если роли выдается несуществующее право.
+type UnknownPermissionError struct {
+	Permission string
+}
+
+func (e *UnknownPermissionError) Error() string {
+	return "unknown permission " + e.Permission
+}
+
+type RolesService struct {
+	repo     repository.Roles
+	authRepo repository.Authorization
+}
+
+func NewRolesService(repo repository.Roles, authRepo repository.Authorization) *RolesService {
+	return &RolesService{repo: repo, authRepo: authRepo}
+}
+
+// GetRoles возвращает все роли с их правами.
+func (s *RolesService) GetRoles(ctx context.Context) ([]entities.Role, error) {
+	roles, err := s.repo.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.repo.GetRolePermissionsMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.Role, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, roleInfo(role, permissions[role.Name]))
+	}
+	return result, nil
+}
+
+// GetRoleByName возвращает роль с ее правами.
+func (s *RolesService) GetRoleByName(ctx context.Context, name string) (entities.Role, error) {
+	role, err := s.repo.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.Role{}, ErrRoleNotFound
+		}
+		return entities.Role{}, err
+	}
+	permissions, err := s.authRepo.GetRolePermissions(ctx, name)
+	if err != nil {
+		return entities.Role{}, err
+	}
+	return roleInfo(role, permissions), nil
+}
+
+// GetPermissions возвращает права, которые можно выдать роли.
+func (s *RolesService) GetPermissions(ctx context.Context) ([]entities.Permission, error) {
+	permissions, err := s.repo.GetPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		result = append(result, entities.Permission{
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+	return result, nil
+}
+
+// GetRolePermissions возвращает права роли; у несуществующей роли прав нет.
+func (s *RolesService) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	return s.authRepo.GetRolePermissions(ctx, role)
+}
+
+// CreateRole создает роль с указанными правами.
+func (s *RolesService) CreateRole(ctx context.Context, input entities.RoleInput) (entities.Role, error) {
+	if err := s.checkPermissions(ctx, input.Permissions); err != nil {
+		return entities.Role{}, err
+	}
+	if _, err := s.repo.GetRoleByName(ctx, input.Name); err == nil {
+		return entities.Role{}, ErrRoleExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return entities.Role{}, err
+	}
+
+	role := bunEntities.Role{
+		Name:        input.Name,
+		Description: input.Description,
+		IsDefault:   input.IsDefault,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repo.CreateRole(ctx, role, input.Permissions); err != nil {
+		return entities.Role{}, err
+	}
+	return s.GetRoleByName(ctx, input.Name)
+}
+
+// UpdateRole меняет описание, права или признак роли по умолчанию.
+// Права admin не меняются, чтобы нельзя было потерять доступ к управлению ролями.
+func (s *RolesService) UpdateRole(ctx context.Context, name string, input entities.RoleUpdateInput) (entities.Role, error) {
+	if input.Permissions != nil {
+		if name == entities.RoleAdmin {
+			return entities.Role{}, ErrBuiltinRole
+		}
+		if err := s.checkPermissions(ctx, *input.Permissions); err != nil {
+			return entities.Role{}, err
+		}
+	}
+
+	updated, err := s.repo.UpdateRole(ctx, name, input.Description, input.Permissions, input.IsDefault != nil && *input.IsDefault)
+	if err != nil {
+		return entities.Role{}, err
+	}
+	if !updated {
+		return entities.Role{}, ErrRoleNotFound
+	}
+	return s.GetRoleByName(ctx, name)
+}
+
+// DeleteRole удаляет роль, если она не встроенная, не назначается по умолчанию и не назначена пользователям.
+func (s *RolesService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.repo.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	if role.Builtin {
+		return ErrBuiltinRole
+	}
+	if role.IsDefault {
+		return ErrDefaultRole
+	}
+
+	users, err := s.repo.CountRoleUsers(ctx, name)
+	if err != nil {
+		return err
+	}
+	if users > 0 {
+		return ErrRoleInUse
+	}
+
+	deleted, err := s.repo.DeleteRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// checkPermissions проверяет, что все права существуют.
+func (s *RolesService) checkPermissions(ctx context.Context, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	known, err := s.repo.GetPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if !slices.ContainsFunc(known, func(p bunEntities.Permission) bool { return p.Name == permission }) {
+			return &UnknownPermissionError{Permission: permission}
+		}
+	}
+	return nil
+}
+
+// roleExists проверяет, что роль существует, перед назначением ее пользователю.
+func roleExists(ctx context.Context, repo repository.Roles, name string) error {
+	if _, err := repo.GetRoleByName(ctx, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// isAdministrativeRole сообщает, есть ли у роли хотя бы одно право.
+func isAdministrativeRole(ctx context.Context, repo repository.Authorization, role string) (bool, error) {
+	permissions, err := repo.GetRolePermissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return len(permissions) > 0, nil
+}
+
+// hasPermission сообщает, есть ли у роли указанное право.
+func hasPermission(ctx context.Context, repo repository.Authorization, role string, permission string) (bool, error) {
+	permissions, err := repo.GetRolePermissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(permissions, permission), nil
+}
+
+func roleInfo(role bunEntities.Role, permissions []string) entities.Role {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return entities.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		Builtin:     role.Builtin,
+		IsDefault:   role.IsDefault,
+		CreatedAt:   role.CreatedAt,
+	}
+}
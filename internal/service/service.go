@@ -2,16 +2,99 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/kolibriee/users-rest-api/internal/config"
 	"github.com/kolibriee/users-rest-api/internal/entities"
 	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
 	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
 )
 
 type Authorization interface {
 	SignUp(ctx context.Context, user entities.SignUpInput) (int, error)
-	SignIn(ctx context.Context, ignInUser entities.SignInInput) (string, string, error)
-	Refresh(ctx context.Context, refreshToken string) (string, string, error)
+	SignIn(ctx context.Context, signInUser entities.SignInInput, client entities.ClientInfo) (entities.SignInResult, error)
+	SignInTwoFactor(ctx context.Context, input entities.TwoFactorSignInInput, client entities.ClientInfo) (string, string, error)
+	Refresh(ctx context.Context, refreshToken string, client entities.ClientInfo) (string, string, error)
+	SwitchOrganization(ctx context.Context, refreshToken string, organizationID int, client entities.ClientInfo) (string, string, error)
+	ValidateAccessToken(ctx context.Context, accessToken string) (*auth.CustomClaims, error)
+	RevokeAccessToken(ctx context.Context, accessToken string) error
+	SessionUser(ctx context.Context, refreshToken string) (int, string, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAll(ctx context.Context, userID int) error
+	GetSessions(ctx context.Context, userID int) ([]entities.SessionInfo, error)
+	RevokeSession(ctx context.Context, userID int, sessionID int) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, input entities.ResetPasswordInput) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendEmailVerification(ctx context.Context, email string) error
+	RequestMagicLink(ctx context.Context, email string, clientIP string) error
+	MagicLinkSignIn(ctx context.Context, token string, client entities.ClientInfo) (entities.SignInResult, error)
+	Reauthenticate(ctx context.Context, userID int, scope string, input entities.ReauthenticateInput, clientIP string) (entities.Reauthentication, error)
+	CheckRecentAuth(ctx context.Context, userID int, authTime time.Time, currentPassword string, clientIP string) error
+	GetLoginLockState(ctx context.Context, userID int) (entities.LoginLockState, error)
+	UnlockUser(ctx context.Context, userID int) error
+}
+
+type TwoFactor interface {
+	Enroll(ctx context.Context, userID int) (entities.TwoFactorEnrollment, error)
+	Confirm(ctx context.Context, userID int, code string, clientIP string) ([]string, error)
+	Disable(ctx context.Context, userID int, code string, clientIP string) error
+	Reset(ctx context.Context, userID int) error
+}
+
+type OIDC interface {
+	Login(ctx context.Context, providerName string) (string, string, error)
+	Link(ctx context.Context, providerName string, userID int) (string, string, error)
+	Callback(ctx context.Context, providerName string, state string, code string, client entities.ClientInfo) (entities.SignInResult, error)
+}
+
+type OAuth interface {
+	CreateClient(ctx context.Context, input entities.OAuthClientInput) (entities.OAuthClientCredentials, error)
+	GetClients(ctx context.Context) ([]entities.OAuthClient, error)
+	ServiceClientRole(ctx context.Context, clientID string) (string, error)
+	DeleteClient(ctx context.Context, clientID string) error
+	Consent(ctx context.Context, request entities.OAuthAuthorizeRequest) (entities.OAuthConsent, string, error)
+	Authorize(ctx context.Context, userID int, request entities.OAuthAuthorizeRequest, approved bool) (string, error)
+	Token(ctx context.Context, request entities.OAuthTokenRequest, clientInfo entities.ClientInfo) (entities.OAuthTokenResponse, error)
+	Revoke(ctx context.Context, request entities.OAuthRevokeRequest) error
+	Introspect(ctx context.Context, request entities.OAuthIntrospectRequest) (entities.OAuthIntrospection, error)
+}
+
+type APITokens interface {
+	CreateAPIToken(ctx context.Context, userID int, kind string, input entities.APITokenInput) (entities.APITokenCreated, error)
+	GetAPITokens(ctx context.Context, userID int, kind string) ([]entities.APIToken, error)
+	DeleteAPIToken(ctx context.Context, userID int, kind string, tokenID int) error
+	AuthenticateAPIToken(ctx context.Context, token string, clientIP string) (entities.APITokenPrincipal, error)
+}
+
+type Impersonation interface {
+	Impersonate(ctx context.Context, actorID int, userID int, input entities.ImpersonationInput, clientIP string) (entities.ImpersonationToken, error)
+	RecordImpersonatedRequest(ctx context.Context, request entities.ImpersonatedRequest) error
+}
+
+type Roles interface {
+	GetRoles(ctx context.Context) ([]entities.Role, error)
+	GetRoleByName(ctx context.Context, name string) (entities.Role, error)
+	GetPermissions(ctx context.Context) ([]entities.Permission, error)
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+	CreateRole(ctx context.Context, input entities.RoleInput) (entities.Role, error)
+	UpdateRole(ctx context.Context, name string, input entities.RoleUpdateInput) (entities.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+}
+
+type Organizations interface {
+	GetOrganizations(ctx context.Context) ([]entities.Organization, error)
+	GetOrganization(ctx context.Context, id int) (entities.Organization, error)
+	CreateOrganization(ctx context.Context, input entities.OrganizationInput) (entities.Organization, error)
+	DeleteOrganization(ctx context.Context, id int) error
+	GetMembers(ctx context.Context, organizationID int) ([]entities.Member, error)
+	AddMember(ctx context.Context, organizationID int, input entities.MemberInput) error
+	UpdateMemberRole(ctx context.Context, organizationID int, userID int, input entities.MemberUpdateInput) error
+	RemoveMember(ctx context.Context, organizationID int, userID int) error
+	GetUserOrganizations(ctx context.Context, userID int, activeID int) ([]entities.UserOrganization, error)
+	GetMembershipRole(ctx context.Context, organizationID int, userID int) (string, error)
 }
 
 type Users interface {
@@ -20,16 +103,32 @@ type Users interface {
 	CreateUser(ctx context.Context, user entities.CreateUserInput) (int, error)
 	UpdateUser(ctx context.Context, userID int, user entities.UserUpdateInput) error
 	DeleteUser(ctx context.Context, id int) error
+	AuthorizeUser(ctx context.Context, subject entities.AccessSubject, action string, userID int) error
+	FilterUsers(ctx context.Context, subject entities.AccessSubject, action string, users []bunEntities.User) ([]bunEntities.User, error)
 }
 
 type Service struct {
 	Authorization
+	TwoFactor
+	OIDC
+	OAuth
+	APITokens
+	Impersonation
+	Roles
+	Organizations
 	Users
 }
 
-func NewService(repo *repository.Repository) *Service {
+func NewService(repo *repository.Repository, cfg *config.Config, mailer mailer.Mailer, passwords *PasswordPolicy, policy *AccessPolicy) *Service {
 	return &Service{
-		Authorization: NewAuthorizationService(repo.Authorization),
-		Users:         NewUsersService(repo.Users),
+		Authorization: NewAuthorizationService(repo.Authorization, repo.OAuth, &cfg.Auth, mailer, passwords),
+		TwoFactor:     NewTwoFactorService(repo.Authorization, &cfg.Auth),
+		OIDC:          NewOIDCService(repo.Authorization, &cfg.Auth.OIDC),
+		OAuth:         NewOAuthService(repo.OAuth, repo.Authorization, repo.Roles, repo.APITokens, &cfg.Auth.OAuth),
+		APITokens:     NewAPITokenService(repo.APITokens, repo.Authorization),
+		Impersonation: NewImpersonationService(repo.Authorization, &cfg.Auth.Impersonation),
+		Roles:         NewRolesService(repo.Roles, repo.Authorization),
+		Organizations: NewOrganizationsService(repo.Organizations, repo.Authorization, repo.Roles),
+		Users:         NewUsersService(repo.Users, repo.Authorization, repo.Roles, repo.Organizations, &cfg.Auth.EmailVerification, mailer, passwords, policy),
 	}
 }
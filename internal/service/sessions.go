@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/pkg/useragent"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Logout завершает сессию, которой принадлежит refresh token.
+func (s *AuthorizationService) Logout(ctx context.Context, refreshToken string) error {
+	return s.repo.DeleteSession(ctx, refreshToken)
+}
+
+// SessionUser возвращает владельца действующей сессии браузера и его роль, не обновляя refresh token.
+// Сессии OAuth-клиентов и использованные токены не подходят.
+func (s *AuthorizationService) SessionUser(ctx context.Context, refreshToken string) (int, string, error) {
+	if !isRefreshToken(refreshToken) {
+		return 0, "", ErrInvalidRefreshToken
+	}
+	session, err := s.repo.GetSession(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", ErrInvalidRefreshToken
+		}
+		return 0, "", err
+	}
+	if session.ClientID != "" || session.RotatedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		return 0, "", ErrInvalidRefreshToken
+	}
+
+	role, err := s.repo.GetRole(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", ErrInvalidRefreshToken
+		}
+		return 0, "", err
+	}
+	return session.UserID, role, nil
+}
+
+// LogoutAll завершает все сессии пользователя и отзывает все выданные ему access token.
+func (s *AuthorizationService) LogoutAll(ctx context.Context, userID int) error {
+	if err := s.repo.DeleteUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	return s.repo.IncrementTokenVersion(ctx, userID)
+}
+
+// GetSessions возвращает действующие сессии пользователя с устройством, IP и временем входа и последнего использования.
+func (s *AuthorizationService) GetSessions(ctx context.Context, userID int) ([]entities.SessionInfo, error) {
+	sessions, err := s.repo.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		device := session.Device
+		if device == "" {
+			device = useragent.Unknown
+		}
+		result = append(result, entities.SessionInfo{
+			ID:         session.ID,
+			ClientID:   session.ClientID,
+			Device:     device,
+			UserAgent:  session.UserAgent,
+			CreatedIP:  session.CreatedIP,
+			LastUsedIP: session.LastUsedIP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession завершает одну сессию пользователя по ее ID.
+func (s *AuthorizationService) RevokeSession(ctx context.Context, userID int, sessionID int) error {
+	deleted, err := s.repo.DeleteUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSessionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultStepUpMaxAge = 5 * time.Minute // Срок действия повторного подтверждения, если не задан в конфигурации
+
+var (
+	ErrReauthenticationRequired = errors.New("recent authentication required: send currentPassword or call /auth/reauthenticate")
+	ErrInvalidCurrentPassword   = errors.New("current password is incorrect")
+	ErrTwoFactorCodeRequired    = errors.New("two-factor code is required")
+)
+
+// Reauthenticate повторно проверяет пароль (и код 2FA, если она включена) вошедшего пользователя
+// и выдает access token с claim auth_time и тем же scope, что у текущего токена. Ошибки учитываются так же, как при входе.
+func (s *AuthorizationService) Reauthenticate(ctx context.Context, userID int, scope string, input entities.ReauthenticateInput, clientIP string) (entities.Reauthentication, error) {
+	if err := s.VerifyCurrentPassword(ctx, userID, input.Password, clientIP); err != nil {
+		return entities.Reauthentication{}, err
+	}
+
+	enabled, err := twoFactorEnabled(ctx, s.repo, userID)
+	if err != nil {
+		return entities.Reauthentication{}, err
+	}
+	if enabled {
+		if input.Code == "" {
+			return entities.Reauthentication{}, ErrTwoFactorCodeRequired
+		}
+		if err := verifySecondFactor(ctx, s.repo, userID, input.Code); err != nil {
+			if errors.Is(err, ErrInvalidTwoFactorCode) {
+				if username, usernameErr := s.repo.GetUsername(ctx, userID); usernameErr == nil {
+					registerFailedLogin(ctx, s.repo, &s.cfg.LoginThrottle, username, clientIP)
+				}
+			}
+			return entities.Reauthentication{}, err
+		}
+	}
+
+	role, err := s.repo.GetRole(ctx, userID)
+	if err != nil {
+		return entities.Reauthentication{}, err
+	}
+	tokenVersion, err := s.repo.GetTokenVersion(ctx, userID)
+	if err != nil {
+		return entities.Reauthentication{}, err
+	}
+	now := time.Now()
+	opts := []auth.TokenOption{auth.WithAuthTime(now), auth.WithScope(scope)}
+	if organizationID, ok := repository.OrganizationFromContext(ctx); ok {
+		opts = append(opts, auth.WithOrganization(organizationID)) // Активная организация сохраняется
+	}
+	accessToken, err := auth.GenerateAccessToken(accessTokenTTL, userID, role, tokenVersion, opts...)
+	if err != nil {
+		return entities.Reauthentication{}, err
+	}
+
+	return entities.Reauthentication{
+		AccessToken:          accessToken,
+		ReauthenticatedUntil: now.Add(s.stepUpMaxAge()),
+	}, nil
+}
+
+// VerifyCurrentPassword проверяет текущий пароль пользователя перед важным изменением.
+// Подбор пароля ограничивается так же, как при входе.
+func (s *AuthorizationService) VerifyCurrentPassword(ctx context.Context, userID int, password string, clientIP string) error {
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkLoginThrottle(ctx, s.repo, username, clientIP); err != nil {
+		return err
+	}
+
+	if _, err := s.authenticate(ctx, username, password); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			registerFailedLogin(ctx, s.repo, &s.cfg.LoginThrottle, username, clientIP)
+			return ErrInvalidCurrentPassword
+		}
+		return err
+	}
+
+	if err := s.repo.ResetLoginAttempts(ctx, usernameThrottleKey(username)); err != nil {
+		logrus.Errorf("can't reset login attempts for user %d: %s", userID, err.Error())
+	}
+	return nil
+}
+
+// CheckRecentAuth разрешает важное изменение, если пользователь подтвердил личность через
+// /auth/reauthenticate не раньше auth.stepUp.maxAge назад или прислал текущий пароль.
+func (s *AuthorizationService) CheckRecentAuth(ctx context.Context, userID int, authTime time.Time, currentPassword string, clientIP string) error {
+	if !authTime.IsZero() && time.Since(authTime) <= s.stepUpMaxAge() {
+		return nil
+	}
+	if currentPassword == "" {
+		return ErrReauthenticationRequired
+	}
+	return s.VerifyCurrentPassword(ctx, userID, currentPassword, clientIP)
+}
+
+func (s *AuthorizationService) stepUpMaxAge() time.Duration {
+	if s.cfg.StepUp.MaxAge <= 0 {
+		return defaultStepUpMaxAge
+	}
+	return s.cfg.StepUp.MaxAge
+}
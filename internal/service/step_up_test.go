@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+)
+
+func TestCheckRecentAuth(t *testing.T) {
+	passwordHash, err := auth.GeneratePasswordHash("correct-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		maxAge          time.Duration
+		authTime        time.Time
+		currentPassword string
+		wantErr         error
+		wantFailures    int
+	}{
+		{name: "recent reauthentication", authTime: now.Add(-time.Minute)},
+		{name: "expired reauthentication", authTime: now.Add(-10 * time.Minute), wantErr: ErrReauthenticationRequired},
+		{name: "configured max age", maxAge: 15 * time.Minute, authTime: now.Add(-10 * time.Minute)},
+		{name: "no auth time", wantErr: ErrReauthenticationRequired},
+		{name: "current password", currentPassword: "correct-password"},
+		{name: "expired reauthentication and current password", authTime: now.Add(-time.Hour), currentPassword: "correct-password"},
+		{name: "wrong current password", currentPassword: "wrong", wantErr: ErrInvalidCurrentPassword, wantFailures: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", PasswordHash: passwordHash})
+			cfg := &config.Auth{
+				StepUp:        config.StepUp{MaxAge: tt.maxAge},
+				LoginThrottle: config.LoginThrottle{Window: time.Hour, Username: config.ThrottleRule{LockoutThreshold: 5, LockoutDuration: time.Hour}},
+			}
+			s := NewAuthorizationService(repo, nil, cfg, nil, nil)
+
+			err := s.CheckRecentAuth(context.Background(), 1, tt.authTime, tt.currentPassword, "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			// Неверный текущий пароль считается неудачной попыткой входа
+			if failures := repo.loginAttempts[usernameThrottleKey("alice")].Failures; failures != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestReauthenticate(t *testing.T) {
+	setupTestKeyring(t)
+	passwordHash, err := auth.GeneratePasswordHash("correct-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		twoFactor bool
+		input     entities.ReauthenticateInput
+		wantErr   error
+	}{
+		{name: "password", input: entities.ReauthenticateInput{Password: "correct-password"}},
+		{name: "wrong password", input: entities.ReauthenticateInput{Password: "wrong"}, wantErr: ErrInvalidCurrentPassword},
+		{name: "two-factor without code", twoFactor: true, input: entities.ReauthenticateInput{Password: "correct-password"}, wantErr: ErrTwoFactorCodeRequired},
+		{name: "two-factor with recovery code", twoFactor: true, input: entities.ReauthenticateInput{Password: "correct-password", Code: "recovery-1"}},
+		{name: "two-factor with wrong code", twoFactor: true, input: entities.ReauthenticateInput{Password: "correct-password", Code: "wrong"}, wantErr: ErrInvalidTwoFactorCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice", PasswordHash: passwordHash})
+			if tt.twoFactor {
+				repo.enableTwoFactor(1, "JBSWY3DPEHPK3PXP", "recovery-1")
+			}
+			s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+
+			before := time.Now()
+			result, err := s.Reauthenticate(ctx, 1, "profile:write", tt.input, "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			// Новый токен несет auth_time и сохраняет scope прежнего
+			claims, err := auth.ParseAccessToken(result.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if authTime := time.Unix(claims.AuthTime, 0); authTime.Before(before.Truncate(time.Second)) || claims.Scope != "profile:write" {
+				t.Errorf("claims = %+v, want auth_time after %s and scope profile:write", claims, before)
+			}
+			// Срок отсчитывается от момента проверки пароля, который был между before и концом вызова
+			if until := result.ReauthenticatedUntil.Sub(before); until < defaultStepUpMaxAge || until > defaultStepUpMaxAge+time.Since(before) {
+				t.Errorf("reauthenticated until %s, want %s after reauthentication", result.ReauthenticatedUntil, defaultStepUpMaxAge)
+			}
+			if err := s.CheckRecentAuth(ctx, 1, time.Unix(claims.AuthTime, 0), "", "192.0.2.1"); err != nil {
+				t.Errorf("check after reauthentication: %v", err)
+			}
+		})
+	}
+}
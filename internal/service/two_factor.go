@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	"github.com/kolibriee/users-rest-api/internal/repository"
+	"github.com/kolibriee/users-rest-api/pkg/totp"
+)
+
+const (
+	twoFactorTokenTTL  = 5 * time.Minute // Время жизни токена второго шага входа
+	recoveryCodesCount = 10              // Количество кодов восстановления
+	totpSkew           = 1               // Допустимое расхождение часов в интервалах TOTP
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+type TwoFactorService struct {
+	repo repository.Authorization
+	cfg  *config.Auth
+}
+
+func NewTwoFactorService(repo repository.Authorization, cfg *config.Auth) *TwoFactorService {
+	return &TwoFactorService{repo: repo, cfg: cfg}
+}
+
+// Enroll создает новый секрет TOTP, который вступит в силу после подтверждения кодом.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID int) (entities.TwoFactorEnrollment, error) {
+	twoFactor, err := s.repo.GetTwoFactor(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return entities.TwoFactorEnrollment{}, err
+	}
+	if err == nil && twoFactor.Enabled {
+		return entities.TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return entities.TwoFactorEnrollment{}, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return entities.TwoFactorEnrollment{}, err
+	}
+	if err := s.repo.SaveTwoFactorSecret(ctx, userID, secret); err != nil {
+		return entities.TwoFactorEnrollment{}, err
+	}
+
+	return entities.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.KeyURI(s.cfg.TwoFactor.Issuer, username, secret),
+	}, nil
+}
+
+// Confirm включает 2FA после проверки кода из приложения и возвращает коды восстановления.
+// Подбор кода ограничивается так же, как подбор паролей.
+func (s *TwoFactorService) Confirm(ctx context.Context, userID int, code string, clientIP string) ([]string, error) {
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLoginThrottle(ctx, s.repo, username, clientIP); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTwoFactorNotEnrolled
+		}
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		registerFailedLogin(ctx, s.repo, &s.cfg.LoginThrottle, username, clientIP)
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableTwoFactor(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable отключает 2FA по запросу пользователя, подтвержденному действующим кодом.
+// Подбор кода ограничивается так же, как подбор паролей.
+func (s *TwoFactorService) Disable(ctx context.Context, userID int, code string, clientIP string) error {
+	username, err := s.repo.GetUsername(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkLoginThrottle(ctx, s.repo, username, clientIP); err != nil {
+		return err
+	}
+	if err := verifySecondFactor(ctx, s.repo, userID, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			registerFailedLogin(ctx, s.repo, &s.cfg.LoginThrottle, username, clientIP)
+		}
+		return err
+	}
+	return s.repo.DeleteTwoFactor(ctx, userID)
+}
+
+// Reset отключает 2FA пользователя без проверки кода (для администратора).
+func (s *TwoFactorService) Reset(ctx context.Context, userID int) error {
+	return s.repo.DeleteTwoFactor(ctx, userID)
+}
+
+// twoFactorEnabled сообщает, включена ли у пользователя 2FA.
+func twoFactorEnabled(ctx context.Context, repo repository.Authorization, userID int) (bool, error) {
+	twoFactor, err := repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+// verifySecondFactor проверяет код TOTP или одноразовый код восстановления.
+// Каждый код TOTP принимается только один раз.
+func verifySecondFactor(ctx context.Context, repo repository.Authorization, userID int, code string) error {
+	twoFactor, err := repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if !twoFactor.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(twoFactor.Secret, code, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		used, err := repo.UseTwoFactorStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// generateRecoveryCodes создает коды восстановления вида xxxxx-xxxxx и их хэши для хранения.
+func generateRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz023456789" // 32 символа без i, l, o и 1
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, errors.New("can't generate recovery codes: " + err.Error())
+		}
+		var b strings.Builder
+		for j, c := range raw {
+			if j == len(raw)/2 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(alphabet[int(c)%len(alphabet)])
+		}
+		codes = append(codes, b.String())
+		hashes = append(hashes, hashRecoveryCode(b.String()))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode нормализует код (регистр, дефисы, пробелы) и возвращает его SHA-256.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
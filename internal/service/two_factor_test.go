@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kolibriee/users-rest-api/internal/config"
+	"github.com/kolibriee/users-rest-api/internal/entities"
+	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
+	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/kolibriee/users-rest-api/pkg/totp"
+)
+
+func TestSignInTwoFactor(t *testing.T) {
+	setupTestKeyring(t)
+
+	const (
+		secret       = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+		recoveryCode = "abcde-fghjk"
+	)
+	currentCode := func(t *testing.T) string {
+		code, err := totp.GenerateCode(secret, totp.Step(time.Now()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	cfg := &config.Auth{LoginThrottle: config.LoginThrottle{
+		Window:   time.Hour,
+		Username: config.ThrottleRule{LockoutThreshold: 5, LockoutDuration: time.Hour},
+	}}
+
+	tests := []struct {
+		name         string
+		code         func(t *testing.T) string
+		previousUses int  // Сколько раз тот же код уже использован
+		deleted      bool // Пользователь удален после первого шага
+		locked       bool // Вход по username заблокирован
+		wantErr      error
+		wantFailures int // Ошибок по username после запроса
+	}{
+		{name: "totp code", code: currentCode},
+		{name: "reused totp code", code: currentCode, previousUses: 1, wantErr: ErrInvalidTwoFactorCode, wantFailures: 2},
+		{name: "wrong code", code: func(*testing.T) string { return "000000" }, wantErr: ErrInvalidTwoFactorCode, wantFailures: 2},
+		{name: "recovery code", code: func(*testing.T) string { return "ABCDE FGHJK" }},
+		{name: "reused recovery code", code: func(*testing.T) string { return recoveryCode }, previousUses: 1, wantErr: ErrInvalidTwoFactorCode, wantFailures: 2},
+		{name: "deleted user", code: currentCode, deleted: true, wantErr: ErrInvalidCredentials, wantFailures: 1},
+		{name: "locked username", code: currentCode, locked: true, wantErr: &LoginThrottledError{}, wantFailures: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAuthRepository()
+			repo.addUser(bunEntities.User{ID: 1, Role: entities.RoleUser, Username: "alice"})
+			repo.enableTwoFactor(1, secret, recoveryCode)
+			// Неверный пароль до успешного входа
+			repo.loginAttempts[usernameThrottleKey("alice")] = bunEntities.LoginAttempt{Failures: 1, LastFailedAt: time.Now()}
+			if tt.locked {
+				until := time.Now().Add(time.Hour)
+				repo.loginAttempts[usernameThrottleKey("alice")] = bunEntities.LoginAttempt{Failures: 1, LastFailedAt: time.Now(), LockedUntil: &until}
+			}
+			s := NewAuthorizationService(repo, nil, cfg, nil, nil)
+
+			code := tt.code(t)
+			for i := 0; i < tt.previousUses; i++ {
+				if err := verifySecondFactor(ctx, repo, 1, code); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.deleted {
+				delete(repo.users, 1)
+			}
+			challengeToken, err := auth.GenerateTwoFactorToken(twoFactorTokenTTL, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			accessToken, _, err := s.SignInTwoFactor(ctx, entities.TwoFactorSignInInput{ChallengeToken: challengeToken, Code: code}, entities.ClientInfo{})
+			var throttled *LoginThrottledError
+			switch {
+			case errors.As(tt.wantErr, &throttled):
+				if !errors.As(err, &throttled) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && accessToken == "" {
+				t.Error("no access token")
+			}
+			if failures := repo.loginAttempts[usernameThrottleKey("alice")].Failures; failures != tt.wantFailures {
+				t.Errorf("username failures = %d, want %d", failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestSignInTwoFactorInvalidChallenge(t *testing.T) {
+	setupTestKeyring(t)
+
+	repo := newFakeAuthRepository()
+	s := NewAuthorizationService(repo, nil, &config.Auth{}, nil, nil)
+
+	// Access token не подходит вместо токена второго шага
+	accessToken, err := auth.GenerateAccessToken(accessTokenTTL, 1, entities.RoleUser, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{"", "garbage", accessToken} {
+		if _, _, err := s.SignInTwoFactor(context.Background(), entities.TwoFactorSignInInput{ChallengeToken: token, Code: "000000"}, entities.ClientInfo{}); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("token %q: got %v, want %v", token, err, ErrInvalidCredentials)
+		}
+	}
+}
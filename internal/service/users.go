@@ -2,19 +2,34 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
+	"github.com/kolibriee/users-rest-api/internal/config"
 	"github.com/kolibriee/users-rest-api/internal/entities"
 	bunEntities "github.com/kolibriee/users-rest-api/internal/entities/bun"
 	"github.com/kolibriee/users-rest-api/internal/repository"
 	"github.com/kolibriee/users-rest-api/pkg/auth"
+	"github.com/kolibriee/users-rest-api/pkg/mailer"
+	"github.com/sirupsen/logrus"
 )
 
+// ErrUserNotFound возвращается и для пользователя из другой организации, чтобы не выдавать его существование.
+var ErrUserNotFound = errors.New("user not found")
+
 type UsersService struct {
-	repo repository.Users
+	repo      repository.Users
+	authRepo  repository.Authorization
+	rolesRepo repository.Roles
+	orgRepo   repository.Organizations
+	cfg       *config.EmailVerification
+	mailer    mailer.Mailer
+	passwords *PasswordPolicy
+	policy    *AccessPolicy
 }
 
-func NewUsersService(repo repository.Users) *UsersService {
-	return &UsersService{repo: repo}
+func NewUsersService(repo repository.Users, authRepo repository.Authorization, rolesRepo repository.Roles, orgRepo repository.Organizations, cfg *config.EmailVerification, mailer mailer.Mailer, passwords *PasswordPolicy, policy *AccessPolicy) *UsersService {
+	return &UsersService{repo: repo, authRepo: authRepo, rolesRepo: rolesRepo, orgRepo: orgRepo, cfg: cfg, mailer: mailer, passwords: passwords, policy: policy}
 }
 
 func (s *UsersService) GetAllUsers(ctx context.Context) ([]bunEntities.User, error) {
@@ -22,21 +37,117 @@ func (s *UsersService) GetAllUsers(ctx context.Context) ([]bunEntities.User, err
 }
 
 func (s *UsersService) GetUserByID(ctx context.Context, id int) (*bunEntities.User, error) {
-	return s.repo.GetUserByID(ctx, id)
+	user, err := s.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+	return user, nil
 }
 
 func (s *UsersService) CreateUser(ctx context.Context, user entities.CreateUserInput) (int, error) {
-	user.Password = auth.GeneratePasswordHash(user.Password)
-	return s.repo.CreateUser(ctx, user)
+	if user.Role == "" {
+		role, err := s.authRepo.GetDefaultRole(ctx)
+		if err != nil {
+			return 0, err
+		}
+		user.Role = role
+	} else if err := roleExists(ctx, s.rolesRepo, user.Role); err != nil {
+		return 0, err
+	}
+	if err := s.passwords.check(ctx, s.authRepo, 0, user.Password,
+		personalInfo(user.Username, user.Name, user.City, user.Email)...); err != nil {
+		return 0, err
+	}
+
+	passwordHash, err := auth.GeneratePasswordHash(user.Password)
+	if err != nil {
+		return 0, err
+	}
+	user.Password = passwordHash
+	id, err := s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+	s.passwords.remember(ctx, s.authRepo, id, passwordHash)
+
+	if user.Email != "" {
+		if err := sendEmailVerification(ctx, s.authRepo, s.mailer, s.cfg, bunEntities.User{
+			ID:       id,
+			Name:     user.Name,
+			Username: user.Username,
+			Email:    &user.Email,
+		}); err != nil {
+			logrus.Errorf("can't send email verification to user %d: %s", id, err.Error())
+		}
+	}
+	return id, nil
 }
 
 func (s *UsersService) UpdateUser(ctx context.Context, userID int, user entities.UserUpdateInput) error {
+	if user.Role != nil {
+		if err := roleExists(ctx, s.rolesRepo, *user.Role); err != nil {
+			return err
+		}
+	}
 	if user.Password != nil {
-		*user.Password = auth.GeneratePasswordHash(*user.Password)
+		// Пароль сравнивается с данными пользователя с учетом изменений из этого же запроса
+		current, err := s.repo.GetUserByID(ctx, userID)
+		if err != nil {
+			return userNotFound(err)
+		}
+		username, name, city, email := current.Username, current.Name, current.City, stringValue(current.Email)
+		if user.Username != nil {
+			username = *user.Username
+		}
+		if user.Name != nil {
+			name = *user.Name
+		}
+		if user.City != nil {
+			city = *user.City
+		}
+		if user.Email != nil {
+			email = *user.Email
+		}
+		if err := s.passwords.check(ctx, s.authRepo, userID, *user.Password, personalInfo(username, name, city, email)...); err != nil {
+			return err
+		}
+
+		passwordHash, err := auth.GeneratePasswordHash(*user.Password)
+		if err != nil {
+			return err
+		}
+		*user.Password = passwordHash
+	}
+	if err := s.repo.UpdateUser(ctx, userID, user); err != nil {
+		return userNotFound(err)
 	}
-	return s.repo.UpdateUser(ctx, userID, user)
+	if user.Password != nil {
+		s.passwords.remember(ctx, s.authRepo, userID, *user.Password)
+	}
+
+	// Смена адреса сбрасывает подтверждение, поэтому отправляем ссылку на новый адрес
+	if user.Email != nil && *user.Email != "" {
+		updated, err := s.repo.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if updated.EmailVerifiedAt == nil {
+			if err := sendEmailVerification(ctx, s.authRepo, s.mailer, s.cfg, *updated); err != nil {
+				logrus.Errorf("can't send email verification to user %d: %s", userID, err.Error())
+			}
+		}
+	}
+	return nil
 }
 
 func (s *UsersService) DeleteUser(ctx context.Context, userID int) error {
-	return s.repo.DeleteUser(ctx, userID)
+	return userNotFound(s.repo.DeleteUser(ctx, userID))
+}
+
+// userNotFound заменяет sql.ErrNoRows на ErrUserNotFound.
+func userNotFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
 }
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS security_events;
+
+DROP INDEX IF EXISTS sessions_family_id_idx;
+DROP INDEX IF EXISTS sessions_refresh_token_idx;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS family_id;
\ No newline at end of file
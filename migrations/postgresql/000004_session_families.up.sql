@@ -0,0 +1,15 @@
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS family_id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS sessions_refresh_token_idx ON sessions (refresh_token);
+CREATE INDEX IF NOT EXISTS sessions_family_id_idx ON sessions (family_id);
+
+CREATE TABLE IF NOT EXISTS security_events (
+    id SERIAL NOT NULL UNIQUE,
+    user_id INT REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(64) NOT NULL,
+    details JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS security_events_user_id_idx ON security_events (user_id);
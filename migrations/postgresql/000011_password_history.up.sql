@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS password_history (
+    id SERIAL NOT NULL UNIQUE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS password_history_user_id_idx ON password_history (user_id, id);
+
+INSERT INTO password_history (user_id, password_hash)
+SELECT id, password_hash FROM users WHERE password_hash <> '';
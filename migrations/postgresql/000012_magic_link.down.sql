@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS rate_limits;
+
+ALTER TABLE users DROP COLUMN IF EXISTS password_changed_at;
\ No newline at end of file
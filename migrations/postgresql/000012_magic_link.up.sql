@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key VARCHAR(340) NOT NULL PRIMARY KEY,
+    requests INT NOT NULL DEFAULT 0,
+    window_started_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
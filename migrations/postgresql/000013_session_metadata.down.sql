@@ -0,0 +1,6 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS device;
+ALTER TABLE sessions DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE sessions DROP COLUMN IF EXISTS last_used_ip;
+ALTER TABLE sessions DROP COLUMN IF EXISTS created_ip;
+ALTER TABLE sessions DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS created_at;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS created_ip VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_used_ip VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent VARCHAR(512);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device VARCHAR(100);
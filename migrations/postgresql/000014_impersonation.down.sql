@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS security_events_actor_id_idx;
+ALTER TABLE security_events DROP COLUMN IF EXISTS actor_id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE security_events ADD COLUMN IF NOT EXISTS actor_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS security_events_actor_id_idx ON security_events (actor_id);
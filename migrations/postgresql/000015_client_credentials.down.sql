@@ -0,0 +1,3 @@
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS role;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS public_key;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS grant_types;
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE oauth_clients DROP CONSTRAINT IF EXISTS oauth_clients_role_fkey;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;
+
+UPDATE users SET role = left(role, -5) WHERE role IN ('service_role', 'system_role', 'anonymous_role');
+
+UPDATE oauth_clients SET role = left(role, -5) WHERE role IN ('service_role', 'system_role', 'anonymous_role');
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
\ No newline at end of file
@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(55) NOT NULL PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    builtin BOOLEAN NOT NULL DEFAULT FALSE,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT roles_name_reserved CHECK (name NOT IN ('service', 'system', 'anonymous'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS roles_is_default_idx ON roles (is_default) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(64) NOT NULL PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(55) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'View other users and the user list'),
+    ('users:write', 'Create, update and delete other users, unlock them and reset their 2FA'),
+    ('users:impersonate', 'Act as another user'),
+    ('sessions:read', 'View sessions of other users'),
+    ('sessions:revoke', 'Revoke sessions of other users'),
+    ('tokens:read', 'View personal access tokens of other users'),
+    ('tokens:revoke', 'Revoke personal access tokens of other users'),
+    ('oauth_clients:read', 'View OAuth clients'),
+    ('oauth_clients:write', 'Register and delete OAuth clients'),
+    ('api_keys:read', 'View service API keys'),
+    ('api_keys:write', 'Issue and revoke service API keys'),
+    ('roles:read', 'View roles and permissions'),
+    ('roles:write', 'Create, update and delete roles'),
+    ('roles:assign', 'Change the role of a user')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description, builtin, is_default) VALUES
+    ('admin', 'Administrator with all permissions', TRUE, FALSE),
+    ('user', 'Regular user, can only manage their own account', TRUE, TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions
+ON CONFLICT DO NOTHING;
+
+UPDATE users SET role = role || '_role' WHERE role IN ('service', 'system', 'anonymous');
+
+UPDATE oauth_clients SET role = role || '_role' WHERE role IN ('service', 'system', 'anonymous');
+
+INSERT INTO roles (name)
+SELECT DISTINCT role FROM users
+UNION
+SELECT DISTINCT role FROM oauth_clients WHERE role IS NOT NULL
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name);
+ALTER TABLE oauth_clients ADD CONSTRAINT oauth_clients_role_fkey FOREIGN KEY (role) REFERENCES roles(name);
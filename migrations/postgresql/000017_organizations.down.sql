@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name IN ('organizations:read', 'organizations:write');
+ALTER TABLE sessions DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
\ No newline at end of file
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(55) NOT NULL REFERENCES roles(name),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organization_members_user_id_idx ON organization_members (user_id);
+
+INSERT INTO organizations (name, slug) VALUES ('Default', 'default')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT o.id, u.id, u.role FROM users u
+JOIN organizations o ON o.slug = 'default'
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS organization_id INT REFERENCES organizations(id) ON DELETE SET NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('organizations:read', 'View all organizations and their members'),
+    ('organizations:write', 'Create and delete organizations and manage members of any organization')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'organizations:read'),
+    ('admin', 'organizations:write')
+ON CONFLICT DO NOTHING;
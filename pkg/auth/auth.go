@@ -1,60 +1,208 @@
 package auth
 
 import (
-	"crypto/sha1"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+// Назначение токенов, не являющихся access token
+const (
+	PurposeTwoFactor = "2fa" // Промежуточный токен между вводом пароля и кода 2FA
+)
+
 type CustomClaims struct {
 	jwt.StandardClaims
-	Role string `json:"role"`
+	Role         string `json:"role"`
+	TokenVersion int    `json:"ver"` // Версия токенов пользователя на момент выдачи
+	Purpose      string `json:"purpose,omitempty"`
+	Scope        string `json:"scope,omitempty"`     // Scope через пробел; пусто — все права пользователя
+	ClientID     string `json:"client_id,omitempty"` // OAuth-клиент, которому выдан токен
+	Act          *Actor `json:"act,omitempty"`       // Администратор, действующий от имени пользователя
+	AuthTime     int64  `json:"auth_time,omitempty"` // Когда пользователь последний раз подтвердил пароль (Unix)
+	Organization int    `json:"org,omitempty"`       // Активная организация пользователя; 0 — не выбрана
+}
+
+// Actor — claim act (RFC 8693): кто на самом деле выполняет запросы от имени пользователя из sub.
+type Actor struct {
+	Subject  string `json:"sub"`
+	Username string `json:"username,omitempty"`
+}
+
+// TokenOption задает дополнительные claims access token.
+type TokenOption func(*CustomClaims)
+
+// WithScope ограничивает access token перечисленными через пробел scope.
+func WithScope(scope string) TokenOption {
+	return func(c *CustomClaims) {
+		c.Scope = scope
+	}
+}
+
+// WithClientID отмечает access token как выданный OAuth-клиенту.
+func WithClientID(clientID string) TokenOption {
+	return func(c *CustomClaims) {
+		c.ClientID = clientID
+	}
+}
+
+// WithActor отмечает access token как выданный администратору для входа от имени пользователя.
+func WithActor(actorID int, username string) TokenOption {
+	return func(c *CustomClaims) {
+		c.Act = &Actor{Subject: strconv.Itoa(actorID), Username: username}
+	}
+}
+
+// WithAuthTime отмечает время, когда пользователь подтвердил личность паролем.
+func WithAuthTime(authTime time.Time) TokenOption {
+	return func(c *CustomClaims) {
+		c.AuthTime = authTime.Unix()
+	}
+}
+
+// WithOrganization отмечает организацию, от имени участника которой действует пользователь.
+func WithOrganization(organizationID int) TokenOption {
+	return func(c *CustomClaims) {
+		c.Organization = organizationID
+	}
+}
+
+// ActorID возвращает ID администратора из claim act. ok = false, если токен выдан не для имперсонации.
+func (c *CustomClaims) ActorID() (int, bool) {
+	if c.Act == nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(c.Act.Subject)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// UserID возвращает ID пользователя из subject.
+func (c *CustomClaims) UserID() (int, error) {
+	return strconv.Atoi(c.Subject)
 }
 
-func GenerateAccessToken(accessTokenTTL time.Duration, userId int, role string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &CustomClaims{
+// GenerateAccessToken выдает access token с уникальным jti и текущей версией токенов пользователя.
+func GenerateAccessToken(accessTokenTTL time.Duration, userId int, role string, tokenVersion int, opts ...TokenOption) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	claims := &CustomClaims{
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
 			Subject:   fmt.Sprintf("%d", userId),
 			ExpiresAt: time.Now().Add(accessTokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
-		Role: role,
-	})
-	accessToken, err := token.SignedString([]byte(os.Getenv("TOKEN_SECRET_KEY")))
+		Role:         role,
+		TokenVersion: tokenVersion,
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+	accessToken, err := signClaims(claims)
 	if err != nil {
 		return "", errors.New("can't generate access token")
 	}
 	return accessToken, nil
 }
 
-func ParseToken(accessToken string) (int, string, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(os.Getenv("TOKEN_SECRET_KEY")), nil
-	})
+// ParseToken проверяет подпись access token ключом, выбранным по kid
+// (или общим секретом HS256 в переходный период), и возвращает владельца токена:
+// пользователя или, для токена client_credentials, сервисного клиента.
+func ParseToken(accessToken string) (Principal, error) {
+	claims, err := ParseAccessToken(accessToken)
 	if err != nil {
-		return 0, "", errors.New("invalid token")
+		return Principal{}, err
 	}
-	claims, ok := token.Claims.(*CustomClaims)
-	if !ok {
-		return 0, "", errors.New("token claims are not of type *CustomClaims")
+	return claims.Principal()
+}
+
+// ParseAccessToken проверяет access token и возвращает все его claims.
+// Отзыв токена (jti, версия токенов) проверяется отдельно на стороне сервиса.
+func ParseAccessToken(accessToken string) (*CustomClaims, error) {
+	claims, err := parseClaims(accessToken)
+	if err != nil || claims.Purpose != "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// GenerateTwoFactorToken создает короткоживущий токен, подтверждающий, что пароль уже проверен
+// и пользователю осталось ввести код второго фактора. WithScope сохраняет scope, запрошенный при входе.
+func GenerateTwoFactorToken(ttl time.Duration, userId int, opts ...TokenOption) (string, error) {
+	claims := &CustomClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   fmt.Sprintf("%d", userId),
+			ExpiresAt: time.Now().Add(ttl).Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+		Purpose: PurposeTwoFactor,
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+	challengeToken, err := signClaims(claims)
+	if err != nil {
+		return "", errors.New("can't generate two-factor token")
+	}
+	return challengeToken, nil
+}
+
+// ParseTwoFactorToken проверяет токен, выданный GenerateTwoFactorToken, и возвращает ID пользователя
+// и scope, запрошенный при входе.
+func ParseTwoFactorToken(challengeToken string) (int, string, error) {
+	claims, err := parseClaims(challengeToken)
+	if err != nil || claims.Purpose != PurposeTwoFactor {
+		return 0, "", errors.New("invalid two-factor token")
 	}
 	userId, err := strconv.Atoi(claims.Subject)
 	if err != nil {
 		return 0, "", err
 	}
-	return userId, claims.Role, nil
+	return userId, claims.Scope, nil
 }
 
-func GeneratePasswordHash(password string) string {
-	hash := sha1.New()
-	hash.Write([]byte(password))
-	return fmt.Sprintf("%x", hash.Sum([]byte(os.Getenv("PASSWORD_HASH_SALT"))))
+// newTokenID генерирует случайный идентификатор токена (jti).
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("can't generate token id: " + err.Error())
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// signClaims подписывает claims активным ключом текущего набора ключей.
+func signClaims(claims jwt.Claims) (string, error) {
+	keyring, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.sign(claims)
+}
+
+// parseClaims проверяет подпись и срок действия токена и возвращает его claims.
+func parseClaims(tokenString string) (*CustomClaims, error) {
+	keyring, err := currentKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, keyring.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(*CustomClaims)
+	if !ok {
+		return nil, errors.New("token claims are not of type *CustomClaims")
+	}
+	return claims, nil
 }
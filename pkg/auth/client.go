@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// RoleService — роль в access token сервисного клиента (grant client_credentials).
+	RoleService = "service"
+
+	// ClientAssertionType — тип утверждения клиента для private_key_jwt (RFC 7523, раздел 2.2).
+	ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	maxClientAssertionLifetime = time.Hour // Утверждения с более долгим сроком действия не принимаются
+)
+
+// Principal — владелец access token: пользователь или сервисный клиент.
+type Principal struct {
+	UserID   int    // 0 у сервисного клиента
+	ClientID string // Заполнен только у сервисного клиента
+	Role     string
+}
+
+// IsServicePrincipal сообщает, выдан ли токен сервисному клиенту, а не пользователю.
+func (c *CustomClaims) IsServicePrincipal() bool {
+	return c.Role == RoleService
+}
+
+// Principal возвращает владельца токена. У сервисного клиента subject — client_id, а не ID пользователя.
+func (c *CustomClaims) Principal() (Principal, error) {
+	if c.IsServicePrincipal() {
+		if c.Subject == "" || c.Subject != c.ClientID {
+			return Principal{}, errors.New("invalid service token subject")
+		}
+		return Principal{ClientID: c.Subject, Role: c.Role}, nil
+	}
+	userID, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return Principal{}, errors.New("invalid token subject")
+	}
+	return Principal{UserID: userID, Role: c.Role}, nil
+}
+
+// GenerateClientToken выдает access token сервисному клиенту с client_id в subject.
+func GenerateClientToken(ttl time.Duration, clientID string, scope string) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	accessToken, err := signClaims(&CustomClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   clientID,
+			ExpiresAt: time.Now().Add(ttl).Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+		Role:     RoleService,
+		Scope:    scope,
+		ClientID: clientID,
+	})
+	if err != nil {
+		return "", errors.New("can't generate access token")
+	}
+	return accessToken, nil
+}
+
+// ParsePublicKeyPEM разбирает открытый ключ клиента в формате PEM: RSA, ECDSA P-256 или Ed25519.
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("public key must be a PEM block of type PUBLIC KEY")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ECDSA keys are supported")
+		}
+		return key, nil
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+}
+
+// ClientAssertion — проверенное утверждение, которым клиент подтвердил свою личность.
+type ClientAssertion struct {
+	ClientID  string
+	ID        string // jti, по нему отсекается повторное использование
+	ExpiresAt time.Time
+}
+
+// ParseClientAssertion проверяет JWT, подписанный закрытым ключом клиента (RFC 7523, private_key_jwt):
+// подпись ключом клиента из keyFor, iss и sub равны client_id, aud — адрес token endpoint,
+// есть jti и exp, срок действия не больше часа.
+func ParseClientAssertion(assertion string, audience string, keyFor func(clientID string) (crypto.PublicKey, error)) (ClientAssertion, error) {
+	invalid := errors.New("invalid client assertion")
+
+	token, err := jwt.ParseWithClaims(assertion, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
+		claims := token.Claims.(jwt.MapClaims)
+		issuer, _ := claims["iss"].(string)
+		subject, _ := claims["sub"].(string)
+		if issuer == "" || issuer != subject {
+			return nil, invalid
+		}
+		key, err := keyFor(issuer)
+		if err != nil {
+			return nil, err
+		}
+		// Алгоритм из заголовка должен соответствовать типу ключа клиента
+		switch key.(type) {
+		case *rsa.PublicKey:
+			_, ok := token.Method.(*jwt.SigningMethodRSA)
+			if !ok {
+				return nil, invalid
+			}
+		case *ecdsa.PublicKey:
+			if token.Method != jwt.SigningMethodES256 {
+				return nil, invalid
+			}
+		case ed25519.PublicKey:
+			if token.Method != jwt.SigningMethodEdDSA {
+				return nil, invalid
+			}
+		default:
+			return nil, invalid
+		}
+		return key, nil
+	})
+	if err != nil {
+		return ClientAssertion{}, err
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	now := time.Now()
+	if !claims.VerifyAudience(audience, true) || !claims.VerifyExpiresAt(now.Unix(), true) {
+		return ClientAssertion{}, invalid
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Unix(int64(exp), 0).After(now.Add(maxClientAssertionLifetime)) {
+		return ClientAssertion{}, invalid
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return ClientAssertion{}, invalid
+	}
+	clientID, _ := claims["iss"].(string)
+
+	return ClientAssertion{
+		ClientID:  clientID,
+		ID:        jti,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
+}
@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// SigningKey — асимметричный ключ подписи токенов с идентификатором kid.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+}
+
+// Keyring хранит несколько ключей одновременно: активный подписывает новые токены,
+// остальные продолжают проверять ранее выданные, пока не будут удалены при ротации.
+type Keyring struct {
+	mu         sync.RWMutex
+	activeID   string
+	keys       map[string]*SigningKey
+	hmacSecret []byte
+	hmacUntil  time.Time
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*SigningKey)}
+}
+
+// defaultKeyring используется функциями пакета для выпуска и проверки токенов.
+var (
+	defaultKeyringMu sync.RWMutex
+	defaultKeyring   *Keyring
+)
+
+// SetKeyring задает набор ключей, которым подписываются и проверяются токены.
+func SetKeyring(k *Keyring) {
+	defaultKeyringMu.Lock()
+	defer defaultKeyringMu.Unlock()
+	defaultKeyring = k
+}
+
+func currentKeyring() (*Keyring, error) {
+	defaultKeyringMu.RLock()
+	defer defaultKeyringMu.RUnlock()
+	if defaultKeyring == nil {
+		return nil, errors.New("token keyring is not configured")
+	}
+	return defaultKeyring, nil
+}
+
+// LoadKeyring загружает закрытые ключи из файлов <kid>.pem каталога dir (RSA или Ed25519, PKCS#8/PKCS#1).
+func LoadKeyring(dir string) (*Keyring, error) {
+	k := NewKeyring()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.New("can't read signing key: " + err.Error())
+		}
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		if err := k.AddPrivateKeyPEM(kid, data); err != nil {
+			return nil, errors.New("can't load signing key " + kid + ": " + err.Error())
+		}
+	}
+
+	return k, nil
+}
+
+// AddPrivateKeyPEM добавляет закрытый ключ в формате PEM.
+func (k *Keyring) AddPrivateKeyPEM(kid string, data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("invalid PEM data")
+	}
+
+	var privateKey crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return errors.New("unsupported PEM block type " + block.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	return k.AddPrivateKey(kid, privateKey)
+}
+
+// AddPrivateKey добавляет закрытый ключ RSA (RS256) или Ed25519 (EdDSA).
+func (k *Keyring) AddPrivateKey(kid string, privateKey crypto.PrivateKey) error {
+	key := &SigningKey{ID: kid, PrivateKey: privateKey}
+	switch pk := privateKey.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.PublicKey = &pk.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PublicKey = pk.Public()
+	default:
+		return errors.New("unsupported key type, use RSA or Ed25519")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[kid] = key
+	return nil
+}
+
+// GenerateEd25519Key создает временный ключ Ed25519 (ключи, не сохраненные на диск, теряются при перезапуске).
+func (k *Keyring) GenerateEd25519Key(kid string) error {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	return k.AddPrivateKey(kid, privateKey)
+}
+
+// SetActive выбирает ключ, которым подписываются новые токены.
+func (k *Keyring) SetActive(kid string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[kid]; !ok {
+		return errors.New("signing key " + kid + " not found")
+	}
+	k.activeID = kid
+	return nil
+}
+
+// Len возвращает количество асимметричных ключей.
+func (k *Keyring) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.keys)
+}
+
+// KeyIDs возвращает идентификаторы загруженных ключей.
+func (k *Keyring) KeyIDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return sortedKeys(k.keys)
+}
+
+// AcceptHS256Until разрешает проверку токенов HS256, подписанных общим секретом, до момента until.
+// Используется на время перехода с симметричной подписи.
+func (k *Keyring) AcceptHS256Until(secret []byte, until time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.hmacSecret = secret
+	k.hmacUntil = until
+}
+
+// sign подписывает claims активным ключом и проставляет kid в заголовок.
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	key, ok := k.keys[k.activeID]
+	k.mu.RUnlock()
+	if !ok {
+		return "", errors.New("active signing key is not configured")
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// keyFunc выбирает ключ проверки по kid и алгоритму из заголовка токена.
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() || len(k.hmacSecret) == 0 || time.Now().After(k.hmacUntil) {
+			return nil, errors.New("invalid signing method")
+		}
+		return k.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.PublicKey, nil
+}
+
+// JWK — открытый ключ в формате RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet — набор открытых ключей для /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает открытые части всех ключей.
+func (k *Keyring) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(k.keys))}
+	for _, kid := range sortedKeys(k.keys) {
+		key := k.keys[kid]
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// PublicJWKS возвращает открытые ключи текущего набора ключей.
+func PublicJWKS() JWKSet {
+	k, err := currentKeyring()
+	if err != nil {
+		return JWKSet{Keys: []JWK{}}
+	}
+	return k.JWKS()
+}
+
+func sortedKeys(keys map[string]*SigningKey) []string {
+	ids := make([]string, 0, len(keys))
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
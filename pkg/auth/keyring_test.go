@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// useKeyring делает k текущим набором ключей на время теста
+func useKeyring(t *testing.T, k *Keyring) {
+	t.Helper()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(nil) })
+}
+
+// signWith подписывает claims пользователя 1 ключом privateKey методом method с заголовком kid
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, privateKey interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, &CustomClaims{
+		StandardClaims: jwt.StandardClaims{Subject: "1", ExpiresAt: time.Now().Add(time.Minute).Unix()},
+		Role:           "user",
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestKeyringKeySelection(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, strangerKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring := NewKeyring()
+	if err := keyring.AddPrivateKey("old", oldKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.AddPrivateKey("new", newKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.SetActive("new"); err != nil {
+		t.Fatal(err)
+	}
+	useKeyring(t, keyring)
+
+	issued, err := GenerateAccessToken(time.Minute, 1, "user", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "active key", token: issued},
+		{name: "previous key after rotation", token: signWith(t, jwt.SigningMethodEdDSA, "old", oldKey)},
+		{name: "unknown kid", token: signWith(t, jwt.SigningMethodEdDSA, "removed", oldKey), wantErr: true},
+		{name: "no kid", token: signWith(t, jwt.SigningMethodEdDSA, "", oldKey), wantErr: true},
+		{name: "kid of another key", token: signWith(t, jwt.SigningMethodEdDSA, "old", strangerKey), wantErr: true},
+		{name: "algorithm of another key", token: signWith(t, jwt.SigningMethodRS256, "old", newKey), wantErr: true},
+		{name: "hs256 without transition", token: signWith(t, jwt.SigningMethodHS256, "", []byte("secret")), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseAccessToken(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && claims.Subject != "1" {
+				t.Errorf("subject = %q, want 1", claims.Subject)
+			}
+		})
+	}
+
+	// Новые токены подписываются активным ключом и несут его kid
+	token, _, err := new(jwt.Parser).ParseUnverified(issued, &CustomClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Header["kid"] != "new" || token.Method.Alg() != jwt.SigningMethodRS256.Alg() {
+		t.Errorf("header = %v, want kid new with RS256", token.Header)
+	}
+}
+
+func TestKeyringHS256AcceptUntil(t *testing.T) {
+	secret := []byte("shared-secret")
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		until   time.Time
+		token   string
+		wantErr bool
+	}{
+		{name: "before cutoff", until: time.Now().Add(time.Hour), token: signWith(t, jwt.SigningMethodHS256, "", secret)},
+		{name: "after cutoff", until: time.Now().Add(-time.Second), token: signWith(t, jwt.SigningMethodHS256, "", secret), wantErr: true},
+		{name: "another secret", until: time.Now().Add(time.Hour), token: signWith(t, jwt.SigningMethodHS256, "", []byte("other")), wantErr: true},
+		{name: "hs512", until: time.Now().Add(time.Hour), token: signWith(t, jwt.SigningMethodHS512, "", secret), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring := NewKeyring()
+			if err := keyring.AddPrivateKey("main", key); err != nil {
+				t.Fatal(err)
+			}
+			if err := keyring.SetActive("main"); err != nil {
+				t.Fatal(err)
+			}
+			keyring.AcceptHS256Until(secret, tt.until)
+			useKeyring(t, keyring)
+
+			if _, err := ParseAccessToken(tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+			// Общим секретом токены только проверяются, новые подписываются асимметричным ключом
+			issued, err := GenerateAccessToken(time.Minute, 1, "user", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token, _, err := new(jwt.Parser).ParseUnverified(issued, &CustomClaims{}); err != nil || token.Method.Alg() != jwt.SigningMethodEdDSA.Alg() {
+				t.Errorf("new token signed with %v, want EdDSA", token.Header)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Параметры argon2id для новых хэшей. При их изменении старые хэши
+// будут пересчитаны при следующем успешном входе пользователя.
+const (
+	argon2Memory  uint32 = 64 * 1024
+	argon2Time    uint32 = 3
+	argon2Threads uint8  = 2
+	argon2SaltLen        = 16
+	argon2KeyLen  uint32 = 32
+)
+
+const argon2Prefix = "$argon2id$"
+
+var ErrInvalidHash = errors.New("invalid password hash format")
+
+// argon2Params описывает параметры, закодированные в хэше.
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// GeneratePasswordHash хэширует пароль алгоритмом argon2id с индивидуальной солью.
+// Результат записывается в формате PHC: $argon2id$v=19$m=...,t=...,p=...$salt$hash.
+func GeneratePasswordHash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.New("can't generate salt: " + err.Error())
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// ComparePasswordHash проверяет пароль по сохраненному хэшу.
+// needsRehash сообщает, что хэш устарел (SHA-1 или другие параметры argon2id)
+// и его стоит пересчитать после успешной проверки.
+func ComparePasswordHash(password string, encodedHash string) (match bool, needsRehash bool, err error) {
+	if !strings.HasPrefix(encodedHash, argon2Prefix) {
+		// Хэши, созданные до перехода на argon2id
+		legacy := legacyPasswordHash(password)
+		return subtle.ConstantTimeCompare([]byte(legacy), []byte(encodedHash)) == 1, true, nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(encodedHash)
+	if err != nil {
+		return false, false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash = params.memory != argon2Memory ||
+		params.time != argon2Time ||
+		params.threads != argon2Threads ||
+		uint32(len(key)) != argon2KeyLen
+	return true, needsRehash, nil
+}
+
+func decodeArgon2Hash(encodedHash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	return params, salt, key, nil
+}
+
+// legacyPasswordHash повторяет прежнюю схему хэширования (SHA-1 с общей солью)
+// и используется только для проверки еще не пересчитанных паролей.
+func legacyPasswordHash(password string) string {
+	hash := sha1.New()
+	hash.Write([]byte(password))
+	return fmt.Sprintf("%x", hash.Sum([]byte(os.Getenv("PASSWORD_HASH_SALT"))))
+}
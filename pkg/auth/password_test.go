@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestComparePasswordHash(t *testing.T) {
+	t.Setenv("PASSWORD_HASH_SALT", "legacy-salt")
+
+	current, err := GeneratePasswordHash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Прежняя схема: SHA-1 от пароля, к которому дописана общая соль
+	sum := sha1.Sum([]byte("secret"))
+	legacy := fmt.Sprintf("%x%x", "legacy-salt", sum)
+
+	tests := []struct {
+		name            string
+		password        string
+		hash            string
+		wantMatch       bool
+		wantNeedsRehash bool
+		wantErr         error
+	}{
+		{name: "argon2id", password: "secret", hash: current, wantMatch: true},
+		{name: "argon2id wrong password", password: "wrong", hash: current},
+		{name: "argon2id old parameters", password: "secret", hash: argon2Hash("secret", 32*1024, 2, 1), wantMatch: true, wantNeedsRehash: true},
+		{name: "argon2id old parameters wrong password", password: "wrong", hash: argon2Hash("secret", 32*1024, 2, 1)},
+		{name: "legacy sha1", password: "secret", hash: legacy, wantMatch: true, wantNeedsRehash: true},
+		{name: "legacy sha1 wrong password", password: "wrong", hash: legacy, wantNeedsRehash: true},
+		{name: "truncated argon2id", password: "secret", hash: strings.Join(strings.Split(current, "$")[:4], "$"), wantErr: ErrInvalidHash},
+		{name: "bad parameters", password: "secret", hash: "$argon2id$v=19$m=x$c2FsdA$a2V5", wantErr: ErrInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, needsRehash, err := ComparePasswordHash(tt.password, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if match != tt.wantMatch || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("got (match %v, rehash %v), want (%v, %v)", match, needsRehash, tt.wantMatch, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestLegacyPasswordHashUsesSalt(t *testing.T) {
+	t.Setenv("PASSWORD_HASH_SALT", "one")
+	hash := legacyPasswordHash("secret")
+
+	// С другой солью тот же пароль не подходит
+	t.Setenv("PASSWORD_HASH_SALT", "two")
+	if match, _, _ := ComparePasswordHash("secret", hash); match {
+		t.Error("legacy hash matched with another salt")
+	}
+}
+
+// argon2Hash кодирует хэш argon2id с заданными параметрами, как GeneratePasswordHash
+func argon2Hash(password string, memory uint32, time uint32, threads uint8) string {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte(password), salt, time, memory, threads, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, memory, time, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret — ключ "12345678901234567890" из RFC 6238 в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	// Тестовые векторы RFC 6238 (приложение B, SHA-1); в RFC коды из 8 цифр, здесь — последние 6
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			got, err := GenerateCode(rfcSecret, Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("code = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		skew     int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", secret: rfcSecret, code: "050471", skew: 1, wantStep: Step(now), wantOK: true},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "050471", skew: 1, wantStep: Step(now), wantOK: true},
+		{name: "surrounding spaces", secret: rfcSecret, code: " 050471 ", skew: 1, wantStep: Step(now), wantOK: true},
+		{name: "previous step within skew", secret: rfcSecret, code: mustCode(t, Step(now)-1), skew: 1, wantStep: Step(now) - 1, wantOK: true},
+		{name: "previous step without skew", secret: rfcSecret, code: mustCode(t, Step(now)-1), skew: 0},
+		{name: "step beyond skew", secret: rfcSecret, code: mustCode(t, Step(now)+2), skew: 1},
+		{name: "wrong code", secret: rfcSecret, code: "000000", skew: 1},
+		{name: "eight digits", secret: rfcSecret, code: "14050471", skew: 1},
+		{name: "invalid secret", secret: "not base32!", code: "050471", skew: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("got (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// mustCode возвращает код ключа из RFC 6238 для интервала step
+func mustCode(t *testing.T, step int64) string {
+	t.Helper()
+	code, err := GenerateCode(rfcSecret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
@@ -0,0 +1,124 @@
+// Package useragent строит короткое описание устройства по заголовку User-Agent,
+// например "Chrome 126 on Windows" или "curl 8.5".
+package useragent
+
+import (
+	"strings"
+)
+
+// Unknown возвращается, если User-Agent пуст или не распознан.
+const Unknown = "Unknown device"
+
+// product — распознаваемый браузер или клиент: маркер в User-Agent и отображаемое имя.
+type product struct {
+	token string
+	name  string
+}
+
+// Порядок важен: Edge, Opera и Яндекс Браузер содержат маркер Chrome, а Chrome — маркер Safari.
+var browsers = []product{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"YaBrowser/", "Yandex Browser"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Version/", "Safari"},
+}
+
+// Клиенты без браузера: скрипты, CI, HTTP-библиотеки.
+var clients = []product{
+	{"curl/", "curl"},
+	{"Wget/", "Wget"},
+	{"PostmanRuntime/", "Postman"},
+	{"insomnia/", "Insomnia"},
+	{"python-requests/", "Python requests"},
+	{"python-httpx/", "Python httpx"},
+	{"Go-http-client/", "Go HTTP client"},
+	{"okhttp/", "OkHttp"},
+	{"axios/", "axios"},
+	{"node-fetch/", "node-fetch"},
+}
+
+// Parse возвращает описание устройства: браузер с основной версией и операционную систему.
+func Parse(userAgent string) string {
+	if strings.TrimSpace(userAgent) == "" {
+		return Unknown
+	}
+
+	for _, client := range clients {
+		if version, ok := findVersion(userAgent, client.token); ok {
+			return withVersion(client.name, version)
+		}
+	}
+
+	os := parseOS(userAgent)
+	browser := ""
+	for _, b := range browsers {
+		if version, ok := findVersion(userAgent, b.token); ok {
+			if b.name == "Safari" && !strings.Contains(userAgent, "Safari/") {
+				continue
+			}
+			browser = withVersion(b.name, version)
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return Unknown
+	}
+}
+
+// parseOS определяет операционную систему или устройство.
+func parseOS(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "iPhone"):
+		return "iPhone"
+	case strings.Contains(userAgent, "iPad"):
+		return "iPad"
+	case strings.Contains(userAgent, "Android"):
+		return "Android"
+	case strings.Contains(userAgent, "Windows"):
+		return "Windows"
+	case strings.Contains(userAgent, "CrOS"):
+		return "ChromeOS"
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		return "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		return "Linux"
+	default:
+		return ""
+	}
+}
+
+// findVersion ищет маркер и возвращает основную версию, записанную после него.
+func findVersion(userAgent string, token string) (string, bool) {
+	i := strings.Index(userAgent, token)
+	if i < 0 {
+		return "", false
+	}
+	version := userAgent[i+len(token):]
+	if end := strings.IndexAny(version, " ;)"); end >= 0 {
+		version = version[:end]
+	}
+	major, _, _ := strings.Cut(version, ".")
+	return major, true
+}
+
+func withVersion(name string, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}